@@ -0,0 +1,43 @@
+// Command cli plays chess in a terminal, against the built-in AI or
+// between two people at the same keyboard. It needs no display, so it works
+// over SSH.
+//
+// Moves are typed in SAN ("Nf3", "exd5", "O-O", "e8=Q") or coordinates
+// ("g1f3", "e7e8q"). Type "help" for the commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/cli"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+func main() {
+	ascii := flag.Bool("ascii", false, "draw pieces as letters instead of chess symbols")
+	noColor := flag.Bool("nocolor", os.Getenv("NO_COLOR") != "", "draw the board without colors")
+	aiColor := flag.String("ai", "black", "color the AI plays: white, black or off")
+	level := flag.Int("level", ai.DefaultSkillLevel, "AI skill level from 1 to 20")
+	fen := flag.String("fen", "", "position to start from")
+	flag.Parse()
+
+	options := cli.Options{
+		ASCII:   *ascii,
+		Color:   !*noColor,
+		AI:      *aiColor != "off",
+		AIColor: game.BlackPlayer,
+		Level:   *level,
+		FEN:     *fen,
+	}
+	if *aiColor == "white" {
+		options.AIColor = game.WhitePlayer
+	}
+
+	if err := cli.Run(os.Stdin, os.Stdout, options); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
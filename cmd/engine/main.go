@@ -0,0 +1,37 @@
+// Command engine runs the built-in AI as a chess engine for chess GUIs and
+// tools. It speaks UCI, or CECP (WinBoard/XBoard) when the first command is
+// "xboard", so the same binary can be installed in either kind of GUI:
+//
+//	xboard -fcp "engine -threads 2"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/engine"
+)
+
+func main() {
+	threads := flag.Int("threads", runtime.NumCPU(), "search threads")
+	weightsPath := flag.String("weights", "", "JSON evaluation weights (see cmd/tune)")
+	flag.Parse()
+
+	options := engine.Options{Threads: *threads}
+	if *weightsPath != "" {
+		weights, err := ai.LoadWeights(*weightsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading weights: %v\n", err)
+			os.Exit(1)
+		}
+		options.Weights = &weights
+	}
+
+	if err := engine.Run(os.Stdin, os.Stdout, options); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commands: %v\n", err)
+		os.Exit(1)
+	}
+}
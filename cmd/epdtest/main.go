@@ -0,0 +1,167 @@
+// Command epdtest runs the engine on EPD test suites such as WAC and
+// reports how many positions it solves.
+//
+// A position is solved when the engine plays one of the bm moves and none
+// of the am moves. With -min the command exits with status 1 when fewer
+// positions are solved, so it can guard against regressions in CI:
+//
+//	epdtest -movetime 1000 -min 250 wac.epd
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+func main() {
+	depth := flag.Int("depth", 0, "search depth (0 for no depth limit)")
+	moveTime := flag.Int("movetime", 1000, "search time per position in milliseconds (0 for no time limit)")
+	nodes := flag.Int("nodes", 0, "node limit per position (0 for no limit)")
+	threads := flag.Int("threads", runtime.NumCPU(), "search threads")
+	weightsPath := flag.String("weights", "", "JSON evaluation weights (see cmd/tune)")
+	minSolved := flag.Int("min", 0, "exit with status 1 when fewer positions are solved")
+	quiet := flag.Bool("q", false, "only print failures and the summary")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Println("Usage: epdtest [flags] suite.epd...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	limits := ai.SearchLimits{
+		Depth:    *depth,
+		MoveTime: time.Duration(*moveTime) * time.Millisecond,
+		Nodes:    *nodes,
+		Threads:  *threads,
+	}
+	if limits.Depth == 0 && limits.MoveTime == 0 && limits.Nodes == 0 {
+		fatalf("Set at least one of -depth, -movetime or -nodes")
+	}
+	if *weightsPath != "" {
+		weights, err := ai.LoadWeights(*weightsPath)
+		if err != nil {
+			fatalf("Error loading weights: %v", err)
+		}
+		limits.Weights = &weights
+	}
+
+	solved, total := 0, 0
+	start := time.Now()
+	for _, path := range flag.Args() {
+		records, err := readSuite(path)
+		if err != nil {
+			fatalf("Error reading %s: %v", path, err)
+		}
+
+		for i, record := range records {
+			total++
+			id := record.ID()
+			if id == "" {
+				id = fmt.Sprintf("%s:%d", path, i+1)
+			}
+
+			ok, played, result, err := runPosition(record, limits)
+			if err != nil {
+				fmt.Printf("%-14s error: %v\n", id, err)
+				continue
+			}
+			if ok {
+				solved++
+			}
+			if !ok || !*quiet {
+				verdict := "ok"
+				if !ok {
+					verdict = "FAIL"
+				}
+				fmt.Printf("%-14s %-4s played %-8s expected %-16s depth %2d  score %6s  nodes %d\n",
+					id, verdict, played, expected(record), result.Depth, ai.FormatScore(result.Score), result.Nodes)
+			}
+		}
+	}
+
+	fmt.Printf("\nSolved %d/%d (%.1f%%) in %s\n", solved, total, percent(solved, total), time.Since(start).Round(time.Millisecond))
+	if solved < *minSolved {
+		fmt.Printf("Below the required %d solved\n", *minSolved)
+		os.Exit(1)
+	}
+}
+
+func readSuite(path string) ([]*game.EPD, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return game.ReadEPD(file)
+}
+
+// runPosition searches one record and judges the move against its bm and
+// am operations
+func runPosition(record *game.EPD, limits ai.SearchLimits) (bool, string, ai.SearchResult, error) {
+	position, err := record.Game()
+	if err != nil {
+		return false, "", ai.SearchResult{}, err
+	}
+	position.TimerActive = false
+
+	best, err := toUCI(position, record.Moves("bm"))
+	if err != nil {
+		return false, "", ai.SearchResult{}, fmt.Errorf("bm: %w", err)
+	}
+	avoid, err := toUCI(position, record.Moves("am"))
+	if err != nil {
+		return false, "", ai.SearchResult{}, fmt.Errorf("am: %w", err)
+	}
+	if len(best) == 0 && len(avoid) == 0 {
+		return false, "", ai.SearchResult{}, fmt.Errorf("no bm or am operation")
+	}
+
+	result := ai.SearchWithLimits(position, limits)
+	move := position.UCI(result.Move.From, result.Move.To, result.Move.Promotion)
+	played := position.SAN(result.Move.From, result.Move.To, result.Move.Promotion)
+
+	ok := len(best) == 0 || best[move]
+	if avoid[move] {
+		ok = false
+	}
+	return ok, played, result, nil
+}
+
+// toUCI converts SAN moves of the position to a set of UCI moves
+func toUCI(position *game.GameState, sans []string) (map[string]bool, error) {
+	moves := map[string]bool{}
+	for _, san := range sans {
+		from, to, promotion, err := position.ParseSAN(san)
+		if err != nil {
+			return nil, err
+		}
+		moves[position.UCI(from, to, promotion)] = true
+	}
+	return moves, nil
+}
+
+func expected(record *game.EPD) string {
+	if bm := record.Operations["bm"]; bm != "" {
+		return bm
+	}
+	return "not " + record.Operations["am"]
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(part) / float64(total)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Printf(format+"\n", args...)
+	os.Exit(1)
+}
@@ -1,18 +1,35 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 
+	"github.com/h3bzzz/go-chess/core/ai"
 	"github.com/h3bzzz/go-chess/core/game"
 	"github.com/h3bzzz/go-chess/core/gui"
+	"github.com/h3bzzz/go-chess/core/savegame"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 )
 
 func main() {
+	weightsPath := flag.String("weights", "", "JSON evaluation weights for the AI (see cmd/tune)")
+	flag.Parse()
+
+	if *weightsPath != "" {
+		weights, err := ai.LoadWeights(*weightsPath)
+		if err != nil {
+			fmt.Printf("Error loading weights: %v\n", err)
+			os.Exit(1)
+		}
+		ai.SetWeights(weights)
+	}
+
 	chessGame := game.NewGame()
 
 	chessApp := app.New()
@@ -36,6 +53,7 @@ func main() {
 
 	if chessUI != nil {
 		window.SetContent(chessUI.GetContent())
+		offerResume(chessUI, window)
 	} else {
 		fmt.Println("Error: Failed to create chess UI")
 		window.SetContent(container.NewWithoutLayout())
@@ -43,3 +61,33 @@ func main() {
 
 	window.ShowAndRun()
 }
+
+// offerResume asks whether to resume the autosaved game, if there is one in
+// progress, and autosaves from then on
+func offerResume(chessUI *gui.ChessUI, window fyne.Window) {
+	path, err := savegame.DefaultPath()
+	if err != nil {
+		fmt.Printf("Autosave disabled: %v\n", err)
+		return
+	}
+
+	save, err := savegame.Load(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Error reading autosaved game: %v\n", err)
+	}
+	if err != nil || !save.Resumable() {
+		chessUI.SetAutosave(path)
+		return
+	}
+
+	message := fmt.Sprintf("Resume the %s game from %s after %d moves?",
+		save.Variant, save.Saved.Format("Jan 2 15:04"), len(save.Moves))
+	dialog.ShowConfirm("Resume last game", message, func(resume bool) {
+		if resume {
+			if err := chessUI.ResumeGame(save); err != nil {
+				dialog.ShowError(fmt.Errorf("resuming the last game: %w", err), window)
+			}
+		}
+		chessUI.SetAutosave(path)
+	}, window)
+}
@@ -0,0 +1,179 @@
+// Command match plays games between two engine configurations and reports
+// the result with an Elo estimate.
+//
+// Engines are described as comma separated key=value pairs:
+//
+//	name=Level10,skill=10
+//	name=Deep,depth=4,movetime=1000
+//	name=Stockfish,cmd=/usr/bin/stockfish,movetime=100,option.Skill Level=5
+//
+// Without cmd the built-in engine is used. skill sets the built-in skill
+// level; depth, movetime (milliseconds) and nodes limit the search, threads
+// sets the number of search goroutines and weights loads evaluation weights
+// written by cmd/tune.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/match"
+)
+
+func main() {
+	engine1 := flag.String("engine1", "name=Engine1,skill=20,depth=3", "first engine configuration")
+	engine2 := flag.String("engine2", "name=Engine2,skill=10", "second engine configuration")
+	games := flag.Int("games", 20, "number of games to play")
+	openingsPath := flag.String("openings", "", "PGN file or file with one FEN per line to start games from")
+	pgnOut := flag.String("pgnout", "match.pgn", "file to append finished games to")
+	maxPlies := flag.Int("maxplies", 400, "adjudicate a draw after this many half-moves")
+	resignScore := flag.Int("resign-score", 1000, "centipawn score for resign adjudication")
+	resignMoves := flag.Int("resign-moves", 3, "consecutive moves below -resign-score to lose (0 disables)")
+	drawScore := flag.Int("draw-score", 10, "centipawn score for draw adjudication")
+	drawMoves := flag.Int("draw-moves", 8, "consecutive moves within draw-score to draw (0 disables)")
+	drawMinPly := flag.Int("draw-min-ply", 80, "half-moves before draw adjudication applies")
+	sprt := flag.String("sprt", "", "run an SPRT with bounds elo0,elo1 and stop when decided")
+	alpha := flag.Float64("alpha", 0.05, "SPRT false positive rate")
+	beta := flag.Float64("beta", 0.05, "SPRT false negative rate")
+	flag.Parse()
+
+	cfg := match.DefaultConfig()
+	cfg.Games = *games
+	cfg.MaxPlies = *maxPlies
+	cfg.ResignScore = *resignScore
+	cfg.ResignMoves = *resignMoves
+	cfg.DrawScore = *drawScore
+	cfg.DrawMoves = *drawMoves
+	cfg.DrawMinPly = *drawMinPly
+
+	if *openingsPath != "" {
+		openings, err := match.LoadOpenings(*openingsPath)
+		if err != nil {
+			fatalf("Error loading openings: %v", err)
+		}
+		cfg.Openings = openings
+		fmt.Printf("Loaded %d openings from %s\n", len(openings), *openingsPath)
+	}
+
+	if *sprt != "" {
+		bounds := strings.Split(*sprt, ",")
+		if len(bounds) != 2 {
+			fatalf("Invalid -sprt %q: expected elo0,elo1", *sprt)
+		}
+		elo0, err0 := strconv.ParseFloat(bounds[0], 64)
+		elo1, err1 := strconv.ParseFloat(bounds[1], 64)
+		if err0 != nil || err1 != nil {
+			fatalf("Invalid -sprt %q: expected elo0,elo1", *sprt)
+		}
+		cfg.SPRT = &match.SPRT{Elo0: elo0, Elo1: elo1, Alpha: *alpha, Beta: *beta}
+	}
+
+	player1, err := newPlayer(*engine1, "Engine1")
+	if err != nil {
+		fatalf("Error creating engine1: %v", err)
+	}
+	defer player1.Close()
+
+	player2, err := newPlayer(*engine2, "Engine2")
+	if err != nil {
+		fatalf("Error creating engine2: %v", err)
+	}
+	defer player2.Close()
+
+	out, err := os.OpenFile(*pgnOut, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		fatalf("Error opening %s: %v", *pgnOut, err)
+	}
+	defer out.Close()
+
+	event := fmt.Sprintf("%s vs %s", player1.Name(), player2.Name())
+	fmt.Printf("Playing %d games: %s\n", cfg.Games, event)
+
+	stats, err := match.Run(player1, player2, cfg, func(round int, record *match.GameRecord, stats *match.Stats) {
+		fmt.Printf("Game %d: %s - %s %s (%s)\n", round, record.White, record.Black, record.Result, record.Reason)
+		fmt.Printf("  %s\n", stats)
+		if cfg.SPRT != nil {
+			fmt.Printf("  %s\n", cfg.SPRT.Describe(stats))
+		}
+
+		if _, err := fmt.Fprintln(out, record.PGN(event, round).String()); err != nil {
+			fmt.Printf("Error writing PGN: %v\n", err)
+		}
+	})
+	if err != nil {
+		fmt.Printf("Match stopped: %v\n", err)
+	}
+
+	fmt.Printf("\nFinal result for %s: %s\n", player1.Name(), stats)
+	if cfg.SPRT != nil {
+		fmt.Println(cfg.SPRT.Describe(stats))
+	}
+}
+
+// newPlayer builds a player from a key=value engine description
+func newPlayer(spec, defaultName string) (match.Player, error) {
+	name := defaultName
+	skill := ai.MaxSkillLevel
+	var limits ai.SearchLimits
+	var command string
+	var args []string
+	options := map[string]string{}
+
+	for _, part := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid engine option %q", part)
+		}
+
+		var err error
+		switch {
+		case key == "name":
+			name = value
+		case key == "skill":
+			skill, err = strconv.Atoi(value)
+		case key == "depth":
+			limits.Depth, err = strconv.Atoi(value)
+		case key == "threads":
+			limits.Threads, err = strconv.Atoi(value)
+		case key == "nodes":
+			limits.Nodes, err = strconv.Atoi(value)
+		case key == "movetime":
+			var ms int
+			ms, err = strconv.Atoi(value)
+			limits.MoveTime = time.Duration(ms) * time.Millisecond
+		case key == "weights":
+			var weights ai.EvalWeights
+			weights, err = ai.LoadWeights(value)
+			limits.Weights = &weights
+		case key == "cmd":
+			command = value
+		case key == "arg":
+			args = append(args, value)
+		case strings.HasPrefix(key, "option."):
+			options[strings.TrimPrefix(key, "option.")] = value
+		default:
+			return nil, fmt.Errorf("unknown engine option %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %v", key, err)
+		}
+	}
+
+	if command != "" {
+		return match.NewUCIPlayer(name, command, args, limits, options)
+	}
+	if limits.Depth == 0 && limits.Nodes == 0 && limits.MoveTime == 0 {
+		limits.Depth = 3
+	}
+	return match.NewEnginePlayer(name, limits, skill), nil
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Printf(format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,67 @@
+// Command server hosts chess games for players connecting from the GUI's
+// "Play Online" dialog or any other client of the core/network protocol.
+//
+// Clients connect over plain TCP, sending one JSON message per line, or
+// over WebSocket:
+//
+//	server -addr :7878 -ws :7879
+//
+// The server keeps the clocks. A player who disconnects during a game can
+// reconnect and resume it until -abandon has passed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/h3bzzz/go-chess/core/network"
+)
+
+func main() {
+	addr := flag.String("addr", ":7878", "TCP address to listen on")
+	wsAddr := flag.String("ws", "", "address to accept WebSocket clients on (empty to disable)")
+	abandon := flag.Duration("abandon", network.DefaultServerConfig().AbandonTimeout, "how long a disconnected player keeps their games (0 to wait forever)")
+	flag.Parse()
+
+	cfg := network.DefaultServerConfig()
+	cfg.AbandonTimeout = *abandon
+	cfg.Logf = log.Printf
+	server := network.NewServer(cfg)
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fatalf("Error listening on %s: %v", *addr, err)
+	}
+	log.Printf("Accepting TCP clients on %s", listener.Addr())
+
+	if *wsAddr != "" {
+		go func() {
+			log.Printf("Accepting WebSocket clients on %s", *wsAddr)
+			if err := http.ListenAndServe(*wsAddr, server); err != nil {
+				fatalf("Error serving WebSocket clients: %v", err)
+			}
+		}()
+	}
+
+	go func() {
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		<-interrupt
+		log.Printf("Shutting down")
+		server.Close()
+	}()
+
+	if err := server.Serve(listener); err != nil {
+		fatalf("Error accepting clients: %v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Printf(format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,74 @@
+// Command tune fits the evaluation weights to a set of labeled positions
+// using Texel's method and writes the result as JSON or Go source.
+//
+// Each input line holds a FEN followed by the result of the game the
+// position was taken from:
+//
+//	rnbqkb1r/pppp1ppp/5n2/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3 "1/2-1/2"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+)
+
+func main() {
+	input := flag.String("positions", "", "labeled position file (FEN + result per line)")
+	startPath := flag.String("start", "", "JSON weights to start from (default: built-in weights)")
+	output := flag.String("out", "weights.json", "file to write the tuned weights to")
+	format := flag.String("format", "json", "output format: json or go")
+	passes := flag.Int("passes", 50, "maximum passes over the weights")
+	fitK := flag.Bool("fit-k", true, "fit the logistic scaling constant before tuning")
+	flag.Parse()
+
+	if *input == "" {
+		fmt.Println("Usage: tune -positions positions.txt [-out weights.json]")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	positions, err := ai.LoadTuningPositions(*input)
+	if err != nil {
+		fatalf("Error loading positions: %v", err)
+	}
+	fmt.Printf("Loaded %d positions from %s\n", len(positions), *input)
+
+	weights := ai.DefaultWeights()
+	if *startPath != "" {
+		weights, err = ai.LoadWeights(*startPath)
+		if err != nil {
+			fatalf("Error loading start weights: %v", err)
+		}
+	}
+
+	tuner := ai.NewTuner(positions)
+	tuner.Log = func(format string, args ...interface{}) {
+		fmt.Printf(format+"\n", args...)
+	}
+	if *fitK {
+		fmt.Printf("Fitted K = %.4f\n", tuner.FitK(&weights))
+	}
+
+	tuned := tuner.Tune(weights, *passes)
+
+	switch *format {
+	case "json":
+		err = ai.SaveWeights(*output, tuned)
+	case "go":
+		err = os.WriteFile(*output, []byte(tuned.GoSource()), 0644)
+	default:
+		fatalf("Unknown format %q", *format)
+	}
+	if err != nil {
+		fatalf("Error writing %s: %v", *output, err)
+	}
+	fmt.Printf("Tuned weights written to %s\n", *output)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Printf(format+"\n", args...)
+	os.Exit(1)
+}
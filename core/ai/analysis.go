@@ -0,0 +1,264 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// MoveClass grades a move by how much evaluation it gave away
+type MoveClass int
+
+const (
+	GoodMove MoveClass = iota
+	Inaccuracy
+	Mistake
+	Blunder
+)
+
+// Centipawn loss thresholds for each move class
+const (
+	InaccuracyLoss = 50
+	MistakeLoss    = 100
+	BlunderLoss    = 300
+)
+
+// maxCountedLoss caps the loss of a single move so that one missed mate
+// does not dominate the average
+const maxCountedLoss = 1000
+
+// PGN numeric annotation glyphs for the move classes
+const (
+	nagMistake    = 2
+	nagBlunder    = 4
+	nagInaccuracy = 6
+)
+
+func (c MoveClass) String() string {
+	switch c {
+	case Inaccuracy:
+		return "Inaccuracy"
+	case Mistake:
+		return "Mistake"
+	case Blunder:
+		return "Blunder"
+	default:
+		return "Good"
+	}
+}
+
+// AnalysisOptions bounds the engine search run on every position
+type AnalysisOptions struct {
+	Depth    int
+	MoveTime time.Duration
+}
+
+// MoveAnalysis is the engine's verdict on a single move of the game.
+// Evaluations are in centipawns from White's point of view.
+type MoveAnalysis struct {
+	Ply        int
+	Player     int
+	SAN        string
+	BestSAN    string
+	EvalBefore int
+	EvalAfter  int
+	Loss       int
+	Accuracy   float64
+	Class      MoveClass
+}
+
+// SideSummary aggregates the move analysis of one player
+type SideSummary struct {
+	Moves        int
+	AverageLoss  float64
+	Accuracy     float64
+	Inaccuracies int
+	Mistakes     int
+	Blunders     int
+}
+
+// GameAnalysis is the result of analyzing every move of a game
+type GameAnalysis struct {
+	Moves []MoveAnalysis
+	// Evals holds the evaluation after each ply, starting with the initial position
+	Evals []int
+	White SideSummary
+	Black SideSummary
+}
+
+// AnalyzeGame searches every position of the game and grades each move by
+// centipawn loss. progress, if not nil, is called after each position.
+func AnalyzeGame(gameState *game.GameState, options AnalysisOptions, progress func(done, total int)) *GameAnalysis {
+	limits := SearchLimits{Depth: options.Depth, MoveTime: options.MoveTime}
+	history := gameState.MoveHistory
+	total := len(history) + 1
+
+	analysis := &GameAnalysis{}
+	best := make([]SearchResult, total)
+	position := gameState.PositionAt(0)
+
+	for ply := 0; ply < total; ply++ {
+		best[ply] = SearchWithLimits(position, limits)
+		score := best[ply].Score
+		if position.CurrentTurn == game.BlackPlayer {
+			score = -score
+		}
+		analysis.Evals = append(analysis.Evals, score)
+
+		if progress != nil {
+			progress(ply+1, total)
+		}
+
+		if ply < len(history) {
+			move := history[ply]
+			san := position.SAN(move.From, move.To, move.Promotion)
+			bestSAN := ""
+			if best[ply].Move.Piece != game.Empty {
+				bestSAN = position.SAN(best[ply].Move.From, best[ply].Move.To, best[ply].Move.Promotion)
+			}
+			analysis.Moves = append(analysis.Moves, MoveAnalysis{
+				Ply:     ply,
+				Player:  position.CurrentTurn,
+				SAN:     san,
+				BestSAN: bestSAN,
+			})
+			position.MakeMoveWithPromotion(move.From, move.To, move.Promotion)
+		}
+	}
+
+	for i := range analysis.Moves {
+		move := &analysis.Moves[i]
+		move.EvalBefore = analysis.Evals[i]
+		move.EvalAfter = analysis.Evals[i+1]
+
+		before, after := move.EvalBefore, move.EvalAfter
+		if move.Player == game.BlackPlayer {
+			before, after = -before, -after
+		}
+
+		if move.SAN != move.BestSAN {
+			move.Loss = clampLoss(before) - clampLoss(after)
+			if move.Loss < 0 {
+				move.Loss = 0
+			}
+		}
+		move.Accuracy = moveAccuracy(before, before-move.Loss)
+		move.Class = classifyLoss(move.Loss)
+	}
+
+	analysis.White = summarize(analysis.Moves, game.WhitePlayer)
+	analysis.Black = summarize(analysis.Moves, game.BlackPlayer)
+
+	return analysis
+}
+
+// Annotate writes the analysis into a PGN game as NAGs and comments
+func (a *GameAnalysis) Annotate(pgn *game.PGNGame) {
+	for i, move := range a.Moves {
+		if i >= len(pgn.Moves) {
+			break
+		}
+
+		evalComment := fmt.Sprintf("[%%eval %s]", pgnEval(move.EvalAfter))
+		switch move.Class {
+		case Inaccuracy:
+			pgn.Moves[i].NAG = nagInaccuracy
+		case Mistake:
+			pgn.Moves[i].NAG = nagMistake
+		case Blunder:
+			pgn.Moves[i].NAG = nagBlunder
+		}
+
+		if move.Class == GoodMove {
+			pgn.Moves[i].Comment = evalComment
+		} else {
+			pgn.Moves[i].Comment = fmt.Sprintf("%s (%s → %s) %s. %s was best.",
+				evalComment, FormatScore(move.EvalBefore), FormatScore(move.EvalAfter),
+				move.Class, move.BestSAN)
+		}
+	}
+
+	pgn.Tags["Annotator"] = "Go Chess"
+}
+
+// pgnEval formats a score for the %eval command, in pawns or as #N for mates
+func pgnEval(score int) string {
+	if score >= MateScore-1000 {
+		return fmt.Sprintf("#%d", (MateScore-score+1)/2)
+	}
+	if score <= -(MateScore - 1000) {
+		return fmt.Sprintf("#-%d", (MateScore+score+1)/2)
+	}
+	return fmt.Sprintf("%.2f", float64(score)/100)
+}
+
+func classifyLoss(loss int) MoveClass {
+	switch {
+	case loss >= BlunderLoss:
+		return Blunder
+	case loss >= MistakeLoss:
+		return Mistake
+	case loss >= InaccuracyLoss:
+		return Inaccuracy
+	default:
+		return GoodMove
+	}
+}
+
+func clampLoss(score int) int {
+	if score > maxCountedLoss {
+		return maxCountedLoss
+	}
+	if score < -maxCountedLoss {
+		return -maxCountedLoss
+	}
+	return score
+}
+
+// winPercent maps a centipawn score to winning chances between 0 and 100
+func winPercent(score int) float64 {
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(clampLoss(score))))-1)
+}
+
+// moveAccuracy converts the drop in winning chances over a move into an
+// accuracy percentage, as popularised by online analysis boards
+func moveAccuracy(before, after int) float64 {
+	drop := winPercent(before) - winPercent(after)
+	if drop < 0 {
+		drop = 0
+	}
+	accuracy := 103.1668*math.Exp(-0.04354*drop) - 3.1669
+	return math.Max(0, math.Min(100, accuracy))
+}
+
+func summarize(moves []MoveAnalysis, player int) SideSummary {
+	var summary SideSummary
+	totalLoss := 0
+	totalAccuracy := 0.0
+
+	for _, move := range moves {
+		if move.Player != player {
+			continue
+		}
+		summary.Moves++
+		totalLoss += move.Loss
+		totalAccuracy += move.Accuracy
+
+		switch move.Class {
+		case Inaccuracy:
+			summary.Inaccuracies++
+		case Mistake:
+			summary.Mistakes++
+		case Blunder:
+			summary.Blunders++
+		}
+	}
+
+	if summary.Moves > 0 {
+		summary.AverageLoss = float64(totalLoss) / float64(summary.Moves)
+		summary.Accuracy = totalAccuracy / float64(summary.Moves)
+	}
+	return summary
+}
@@ -0,0 +1,101 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// MateScore is the score of a position where the side to move is checkmated.
+// Mates found deeper in the tree score slightly less so that shorter mates
+// are preferred.
+const MateScore = 100000
+
+// Evaluate returns a static evaluation of the board in centipawns from
+// White's point of view, using the active weights
+func Evaluate(board [8][8]int) int {
+	weights := ActiveWeights()
+	return EvaluateWithWeights(board, &weights)
+}
+
+// EvaluateWithWeights returns a static evaluation of the board in
+// centipawns from White's point of view
+func EvaluateWithWeights(board [8][8]int, weights *EvalWeights) int {
+	score := 0
+
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			piece := board[y][x]
+			if piece == game.Empty || piece == game.WhiteKing || piece == game.BlackKing {
+				continue
+			}
+
+			value := weights.material(piece) + positionBonus(weights, piece, x, y)
+			if game.IsPieceWhite(piece) {
+				score += value
+			} else {
+				score -= value
+			}
+		}
+	}
+
+	return score
+}
+
+// positionBonus rewards central pieces, advanced pawns and developed
+// minor pieces
+func positionBonus(weights *EvalWeights, piece, x, y int) int {
+	// Rank counted from the piece owner's side of the board
+	rank := y
+	if game.IsPieceBlack(piece) {
+		rank = 7 - y
+	}
+
+	center := 0
+	if x >= 2 && x <= 5 && y >= 2 && y <= 5 {
+		center = weights.Center
+		if x >= 3 && x <= 4 && y >= 3 && y <= 4 {
+			center = weights.InnerCenter
+		}
+	}
+
+	switch piece {
+	case game.WhitePawn, game.BlackPawn:
+		return center/2 + (rank-1)*weights.PawnAdvance
+	case game.WhiteKnight, game.BlackKnight, game.WhiteBishop, game.BlackBishop:
+		bonus := center
+		if rank == 0 {
+			bonus -= weights.Undeveloped
+		}
+		return bonus
+	case game.WhiteQueen, game.BlackQueen:
+		return center / 2
+	}
+
+	return 0
+}
+
+// WinProbability converts a centipawn score into the expected score for
+// White between 0 and 1
+func WinProbability(score int) float64 {
+	if score >= MateScore-1000 {
+		return 1
+	}
+	if score <= -(MateScore - 1000) {
+		return 0
+	}
+	return 1 / (1 + math.Pow(10, -float64(score)/400))
+}
+
+// FormatScore renders a White point of view score in pawns, e.g. "+0.4",
+// or as a mate distance in moves, e.g. "M3" or "-M2"
+func FormatScore(score int) string {
+	if score >= MateScore-1000 {
+		return fmt.Sprintf("M%d", (MateScore-score+1)/2)
+	}
+	if score <= -(MateScore - 1000) {
+		return fmt.Sprintf("-M%d", (MateScore+score+1)/2)
+	}
+	return fmt.Sprintf("%+.1f", float64(score)/100)
+}
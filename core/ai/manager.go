@@ -2,6 +2,7 @@ package ai
 
 import (
 	"fmt"
+	"runtime"
 	"time"
 
 	"github.com/h3bzzz/go-chess/core/game"
@@ -15,15 +16,19 @@ type AIManager struct {
 	ticker         *time.Ticker
 	stopChan       chan bool
 	onMoveCallback func()
-	aiDifficulty   int
+	skillLevel     int
+	threads        int
+	pondering      bool
 }
 
 func NewAIManager(gameState *game.GameState) *AIManager {
 	return &AIManager{
-		gameState: gameState,
-		enabled:   false,
-		aiColor:   game.BlackPlayer,
-		stopChan:  make(chan bool),
+		gameState:  gameState,
+		enabled:    false,
+		aiColor:    game.BlackPlayer,
+		stopChan:   make(chan bool),
+		skillLevel: DefaultSkillLevel,
+		threads:    runtime.NumCPU(),
 	}
 }
 
@@ -45,22 +50,55 @@ func (m *AIManager) SetAIColor(color int) {
 	m.rebuildAI()
 }
 
-func (m *AIManager) SetDifficulty(difficulty int) {
-	m.aiDifficulty = difficulty
+// SetSkillLevel sets the AI strength between MinSkillLevel and MaxSkillLevel
+func (m *AIManager) SetSkillLevel(level int) {
+	m.skillLevel = NewSkill(level).Level
 	m.rebuildAI()
 }
 
+func (m *AIManager) GetSkillLevel() int {
+	return m.skillLevel
+}
+
+// SetThreads sets the number of search goroutines, at least one. It
+// defaults to the number of CPUs.
+func (m *AIManager) SetThreads(threads int) {
+	if threads < 1 {
+		threads = 1
+	}
+	m.threads = threads
+	m.rebuildAI()
+}
+
+func (m *AIManager) GetThreads() int {
+	return m.threads
+}
+
+// SetPondering lets the AI think on the opponent's time. After each move it
+// searches the position after the expected reply, and answers at once when
+// the reply is played.
+func (m *AIManager) SetPondering(enabled bool) {
+	m.pondering = enabled
+	if m.ai != nil {
+		m.ai.SetPondering(enabled)
+	}
+}
+
+func (m *AIManager) IsPondering() bool {
+	return m.pondering
+}
+
 func (m *AIManager) rebuildAI() {
 	playerColor := game.BlackPlayer
 	if m.aiColor == game.BlackPlayer {
 		playerColor = game.WhitePlayer
 	}
-	m.ai = NewChessAI(m.gameState, playerColor, 2)
-
-	// Set the difficulty if it was previously configured
-	if m.ai != nil && m.aiDifficulty > 0 {
-		m.ai.difficulty = m.aiDifficulty
+	if m.ai != nil {
+		m.ai.StopPondering()
 	}
+	m.ai = NewChessAI(m.gameState, playerColor, m.skillLevel)
+	m.ai.SetThreads(m.threads)
+	m.ai.SetPondering(m.pondering)
 }
 
 func (m *AIManager) SetMoveCallback(callback func()) {
@@ -98,6 +136,9 @@ func (m *AIManager) Start() {
 }
 
 func (m *AIManager) Stop() {
+	if m.ai != nil {
+		m.ai.StopPondering()
+	}
 	if m.ticker != nil {
 		m.ticker.Stop()
 		m.ticker = nil
@@ -0,0 +1,144 @@
+package ai
+
+import (
+	"sync"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// ponderSearch is a background search of the position expected after the
+// opponent's reply
+type ponderSearch struct {
+	mutex sync.Mutex
+	// reply is the predicted reply; ply and key identify the position after
+	// it once it is known
+	reply Move
+	ply   int
+	key   string
+
+	moves  []Move
+	cancel chan struct{}
+	done   chan struct{}
+}
+
+// SetPondering turns thinking on the opponent's time on or off
+func (ai *ChessAI) SetPondering(enabled bool) {
+	ai.ponderMutex.Lock()
+	ai.pondering = enabled
+	ai.ponderMutex.Unlock()
+
+	if !enabled {
+		ai.StopPondering()
+	}
+}
+
+// StopPondering cancels a running ponder search and waits for it to end
+func (ai *ChessAI) StopPondering() {
+	ai.ponderMutex.Lock()
+	ponder := ai.ponder
+	ai.ponder = nil
+	ai.ponderMutex.Unlock()
+
+	if ponder != nil {
+		close(ponder.cancel)
+		<-ponder.done
+	}
+}
+
+// PonderMove returns the opponent's reply the AI is pondering on, once it
+// has predicted one
+func (ai *ChessAI) PonderMove() (Move, bool) {
+	ai.ponderMutex.Lock()
+	ponder := ai.ponder
+	ai.ponderMutex.Unlock()
+
+	if ponder == nil {
+		return Move{}, false
+	}
+	ponder.mutex.Lock()
+	defer ponder.mutex.Unlock()
+	return ponder.reply, ponder.ply > 0
+}
+
+// startPondering predicts the opponent's reply in the current position and
+// searches the position after it until the AI's turn comes
+func (ai *ChessAI) startPondering() {
+	position := ai.gameState.Clone()
+	position.TimerActive = false
+	if position.IsGameOver() {
+		return
+	}
+
+	ponder := &ponderSearch{cancel: make(chan struct{}), done: make(chan struct{})}
+	limits := ai.searchLimits()
+	limits.Cancel = ponder.cancel
+
+	ai.ponderMutex.Lock()
+	if !ai.pondering || ai.ponder != nil {
+		ai.ponderMutex.Unlock()
+		return
+	}
+	ai.ponder = ponder
+	ai.ponderMutex.Unlock()
+
+	go func() {
+		defer close(ponder.done)
+
+		predicted := SearchWithLimits(position, limits)
+		if isCancelled(ponder.cancel) {
+			return
+		}
+		if position.MakeMoveWithPromotion(predicted.Move.From, predicted.Move.To, predicted.Move.Promotion) == game.InvalidMove ||
+			position.IsGameOver() {
+			return
+		}
+
+		ponder.mutex.Lock()
+		ponder.reply = predicted.Move
+		ponder.ply = len(position.MoveHistory)
+		ponder.key = position.PositionKey()
+		ponder.mutex.Unlock()
+
+		moves := SearchRootMoves(position, limits)
+		if !isCancelled(ponder.cancel) {
+			ponder.moves = moves
+		}
+	}()
+}
+
+// takePonderResult ends pondering. On a ponder hit, when the opponent
+// played the predicted reply, it waits for the ponder search and returns its
+// scored moves. On a miss the search is cancelled and nil is returned.
+func (ai *ChessAI) takePonderResult() []Move {
+	ai.ponderMutex.Lock()
+	ponder := ai.ponder
+	ai.ponder = nil
+	ai.ponderMutex.Unlock()
+
+	if ponder == nil {
+		return nil
+	}
+
+	ponder.mutex.Lock()
+	hit := ponder.ply > 0 && ponder.ply == len(ai.gameState.MoveHistory) &&
+		ponder.key == ai.gameState.PositionKey()
+	ponder.mutex.Unlock()
+
+	if !hit {
+		close(ponder.cancel)
+		<-ponder.done
+		return nil
+	}
+
+	<-ponder.done
+	return ponder.moves
+}
+
+func isCancelled(cancel <-chan struct{}) bool {
+	select {
+	case <-cancel:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,423 @@
+package ai
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// maxSearchDepth bounds iterative deepening when only a time limit is given
+const maxSearchDepth = 64
+
+// maxQuiescenceDepth bounds the number of captures followed past the
+// nominal search depth
+const maxQuiescenceDepth = 6
+
+// SearchResult holds the outcome of a search. Score is in centipawns from
+// the point of view of the side to move.
+type SearchResult struct {
+	Move  Move
+	Score int
+	Depth int
+	Nodes int
+}
+
+// SearchLimits bounds a search. A zero Depth means no depth limit when a
+// MoveTime, Clock or Nodes limit is given, and depth 1 otherwise. The first
+// iteration always completes regardless of the time and node limits.
+type SearchLimits struct {
+	Depth    int
+	MoveTime time.Duration
+	Nodes    int
+	// Clock lets the time manager budget the move from the clock state
+	Clock *TimeControl
+	// Weights overrides the active evaluation weights for this search
+	Weights *EvalWeights
+	// Threads is the number of search goroutines; 0 means one
+	Threads int
+	// Cancel, when closed, stops the search at once, even during the first
+	// iteration. The result of a cancelled search may have no move.
+	Cancel <-chan struct{}
+}
+
+// sharedSearch is the state shared by the threads of one search
+type sharedSearch struct {
+	tt    *transpositionTable
+	nodes atomic.Int64
+	stop  atomic.Bool
+}
+
+// searcher is one search thread. Thread 0 is the main thread: it enforces
+// the limits and its result is the result of the search. The other threads
+// only fill the shared transposition table (Lazy SMP).
+type searcher struct {
+	id       int
+	shared   *sharedSearch
+	weights  EvalWeights
+	nodes    int
+	maxNodes int
+	deadline time.Time
+	cancel   <-chan struct{}
+	timer    *timeManager
+	canStop  bool
+	stopped  bool
+}
+
+func newSearcher(id int, shared *sharedSearch, limits SearchLimits) *searcher {
+	s := &searcher{id: id, shared: shared, maxNodes: limits.Nodes, cancel: limits.Cancel, weights: ActiveWeights()}
+	if limits.Weights != nil {
+		s.weights = *limits.Weights
+	}
+	if limits.MoveTime > 0 {
+		s.deadline = time.Now().Add(limits.MoveTime)
+	}
+	if limits.Clock != nil && id == 0 {
+		budget := AllocateTime(*limits.Clock)
+		s.timer = &timeManager{start: time.Now(), soft: budget.Soft}
+		if hard := s.timer.start.Add(budget.Hard); s.deadline.IsZero() || hard.Before(s.deadline) {
+			s.deadline = hard
+		}
+	}
+	return s
+}
+
+// Search runs a fixed depth alpha-beta search and returns the best move for
+// the side to move
+func Search(gameState *game.GameState, depth int) SearchResult {
+	return SearchWithLimits(gameState, SearchLimits{Depth: depth})
+}
+
+// SearchWithLimits runs an iterative deepening alpha-beta search until the
+// depth or time limit is reached. The result of the deepest completed
+// iteration is returned.
+func SearchWithLimits(gameState *game.GameState, limits SearchLimits) SearchResult {
+	result, _ := searchIterative(gameState, limits, false)
+	return result
+}
+
+// SearchRootMoves scores every legal move of the position with a full
+// window search and returns them best first. Scores are from the point of
+// view of the side to move.
+func SearchRootMoves(gameState *game.GameState, limits SearchLimits) []Move {
+	_, moves := searchIterative(gameState, limits, true)
+	return moves
+}
+
+// searchIterative deepens the search until a limit is hit. With allMoves
+// set every root move gets an exact score instead of a bound.
+func searchIterative(gameState *game.GameState, limits SearchLimits, allMoves bool) (SearchResult, []Move) {
+	maxDepth := limits.Depth
+	if maxDepth <= 0 {
+		maxDepth = 1
+		if limits.MoveTime > 0 || limits.Clock != nil || limits.Nodes > 0 {
+			maxDepth = maxSearchDepth
+		}
+	}
+
+	root := gameState.Clone()
+	root.TimerActive = false
+
+	shared := &sharedSearch{tt: newTranspositionTable(ttSizeBits)}
+	main := newSearcher(0, shared, limits)
+
+	moves := orderMoves(generateMoves(root, root.CurrentTurn))
+	if len(moves) == 0 {
+		return SearchResult{Score: main.terminalScore(root, 0)}, nil
+	}
+
+	var helpers sync.WaitGroup
+	for id := 1; id < limits.Threads; id++ {
+		helper := newSearcher(id, shared, limits)
+		helperMoves := rotateMoves(moves, id)
+		helpers.Add(1)
+		go func() {
+			defer helpers.Done()
+			helper.deepen(root.Clone(), helperMoves, maxDepth, false)
+		}()
+	}
+
+	result, scored := main.deepen(root, moves, maxDepth, allMoves)
+	shared.stop.Store(true)
+	helpers.Wait()
+
+	result.Nodes = int(shared.nodes.Load())
+	sortMovesByScore(scored)
+	return result, scored
+}
+
+// deepen runs iterative deepening up to maxDepth and returns the result of
+// the deepest completed iteration. Helper threads with an odd id start one
+// ply deeper so that the threads spread over neighbouring depths.
+func (s *searcher) deepen(root *game.GameState, moves []Move, maxDepth int, allMoves bool) (SearchResult, []Move) {
+	result := SearchResult{Move: moves[0]}
+	var scored []Move
+	for depth := 1 + s.id%2; depth <= maxDepth; depth++ {
+		iteration, iterationMoves, completed := s.searchRoot(root, moves, depth, allMoves)
+		if !completed {
+			break
+		}
+		result = iteration
+		scored = iterationMoves
+		s.canStop = true
+
+		// Search the best move first on the next iteration
+		moves = promoteMove(moves, result.Move)
+
+		if result.Score >= MateScore-maxSearchDepth || result.Score <= -(MateScore-maxSearchDepth) {
+			break
+		}
+		if s.timer != nil && !s.timer.nextIteration(result) {
+			break
+		}
+	}
+
+	return result, scored
+}
+
+// rotateMoves gives a helper thread its own root move order, keeping the
+// expected best move first
+func rotateMoves(moves []Move, id int) []Move {
+	rotated := append([]Move{}, moves...)
+	if rest := rotated[1:]; len(rest) > 1 {
+		shift := id % len(rest)
+		copy(rest, append(append([]Move{}, moves[1+shift:]...), moves[1:1+shift]...))
+	}
+	return rotated
+}
+
+// EvaluatePosition searches the position to the given depth and returns the
+// score in centipawns from White's point of view
+func EvaluatePosition(gameState *game.GameState, depth int) int {
+	score := Search(gameState, depth).Score
+	if gameState.CurrentTurn == game.BlackPlayer {
+		return -score
+	}
+	return score
+}
+
+// searchRoot searches every root move to depth and reports whether the
+// iteration finished within the limits. The scored root moves are returned
+// alongside the best one.
+func (s *searcher) searchRoot(root *game.GameState, moves []Move, depth int, allMoves bool) (SearchResult, []Move, bool) {
+	result := SearchResult{Depth: depth, Score: -MateScore - 1}
+	scored := make([]Move, 0, len(moves))
+
+	alpha, beta := -MateScore-1, MateScore+1
+	for _, move := range moves {
+		child := root.Clone()
+		child.MakeMoveWithPromotion(move.From, move.To, move.Promotion)
+		score := -s.negamax(child, depth-1, -beta, -alpha, 1)
+		if s.stopped {
+			return result, nil, false
+		}
+
+		move.Score = score
+		scored = append(scored, move)
+		if score > result.Score {
+			result.Move = move
+			result.Score = score
+		}
+		if score > alpha && !allMoves {
+			alpha = score
+		}
+	}
+
+	return result, scored, true
+}
+
+// checkLimits stops the search once the time or node budget is spent. The
+// node budget counts the nodes of every thread. The first iteration of the
+// main thread is only interrupted by Cancel, so that there is always a move.
+func (s *searcher) checkLimits() {
+	if s.shared.stop.Load() {
+		s.stopped = true
+		return
+	}
+	if s.id != 0 {
+		return
+	}
+
+	select {
+	case <-s.cancel:
+		s.stopped = true
+	default:
+	}
+	if s.canStop {
+		if s.maxNodes > 0 && s.shared.nodes.Load() >= int64(s.maxNodes) {
+			s.stopped = true
+		}
+		if s.nodes&63 == 0 && !s.deadline.IsZero() && time.Now().After(s.deadline) {
+			s.stopped = true
+		}
+	}
+	if s.stopped {
+		s.shared.stop.Store(true)
+	}
+}
+
+// countNode adds a visited node to the thread and search totals
+func (s *searcher) countNode() {
+	s.nodes++
+	s.shared.nodes.Add(1)
+}
+
+func (s *searcher) negamax(gameState *game.GameState, depth, alpha, beta, ply int) int {
+	s.countNode()
+	s.checkLimits()
+	if s.stopped {
+		return 0
+	}
+
+	if gameState.GameStatus != game.InProgress {
+		return statusScore(gameState, gameState.GameStatus, ply)
+	}
+
+	if depth <= 0 {
+		return s.quiesce(gameState, alpha, beta, ply, 0)
+	}
+
+	key := zobristKey(gameState)
+	entry, found := s.shared.tt.probe(key, ply)
+	if found && entry.depth >= depth {
+		switch {
+		case entry.flag == ttExact,
+			entry.flag == ttLower && entry.score >= beta,
+			entry.flag == ttUpper && entry.score <= alpha:
+			return entry.score
+		}
+	}
+
+	moves := orderMoves(generateMoves(gameState, gameState.CurrentTurn))
+	if len(moves) == 0 {
+		return s.terminalScore(gameState, ply)
+	}
+	if found && entry.hasMove {
+		moves = promoteMove(moves, entry.move)
+	}
+
+	flag := ttUpper
+	var bestMove *Move
+	for i, move := range moves {
+		child := gameState.Clone()
+		child.MakeMoveWithPromotion(move.From, move.To, move.Promotion)
+		score := -s.negamax(child, depth-1, -beta, -alpha, ply+1)
+		if s.stopped {
+			return 0
+		}
+
+		if score >= beta {
+			s.shared.tt.store(key, ply, depth, ttLower, beta, &moves[i])
+			return beta
+		}
+		if score > alpha {
+			alpha = score
+			flag = ttExact
+			bestMove = &moves[i]
+		}
+	}
+
+	s.shared.tt.store(key, ply, depth, flag, alpha, bestMove)
+	return alpha
+}
+
+// quiesce extends the search through captures only, so that positions are
+// not evaluated in the middle of an exchange
+func (s *searcher) quiesce(gameState *game.GameState, alpha, beta, ply, qply int) int {
+	standPat := s.sideScore(gameState)
+	if standPat >= beta || qply >= maxQuiescenceDepth {
+		return standPat
+	}
+	if standPat > alpha {
+		alpha = standPat
+	}
+
+	moves := orderMoves(generateMoves(gameState, gameState.CurrentTurn))
+	for _, move := range moves {
+		if move.CapturePiece == game.Empty {
+			// Captures are ordered first, so the rest are quiet moves
+			break
+		}
+
+		s.countNode()
+		child := gameState.Clone()
+		child.MakeMoveWithPromotion(move.From, move.To, move.Promotion)
+		if child.GameStatus == game.WhiteWon || child.GameStatus == game.BlackWon {
+			return -statusScore(child, child.GameStatus, ply+1)
+		}
+
+		score := -s.quiesce(child, -beta, -alpha, ply+1, qply+1)
+		if score >= beta {
+			return beta
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+
+	return alpha
+}
+
+// terminalScore scores a position in which the side to move has no moves
+func (s *searcher) terminalScore(gameState *game.GameState, ply int) int {
+	if gameState.Variant != nil {
+		status, _ := gameState.Variant.Outcome(gameState)
+		return statusScore(gameState, status, ply)
+	}
+	if game.IsInCheck(gameState.Board, gameState.CurrentTurn) {
+		return -(MateScore - ply)
+	}
+	return 0
+}
+
+// statusScore scores a finished game from the side to move's point of view.
+// In most games the side to move has lost, but an Antichess player wins by
+// running out of moves.
+func statusScore(gameState *game.GameState, status game.GameStatus, ply int) int {
+	switch {
+	case status == game.WhiteWon && gameState.CurrentTurn == game.WhitePlayer,
+		status == game.BlackWon && gameState.CurrentTurn == game.BlackPlayer:
+		return MateScore - ply
+	case status == game.WhiteWon, status == game.BlackWon:
+		return -(MateScore - ply)
+	}
+	return 0
+}
+
+// sideScore returns the static evaluation from the side to move's point of view
+func (s *searcher) sideScore(gameState *game.GameState) int {
+	score := EvaluateWithWeights(gameState.Board, &s.weights)
+	if gameState.Variant != nil {
+		score = variantScore(gameState, score)
+	}
+	if gameState.CurrentTurn == game.BlackPlayer {
+		return -score
+	}
+	return score
+}
+
+// orderMoves puts captures of valuable pieces by cheap pieces first so that
+// alpha-beta cuts off earlier
+func orderMoves(moves []Move) []Move {
+	for i := range moves {
+		moves[i].Score = 0
+		if moves[i].CapturePiece != game.Empty {
+			moves[i].Score = PieceValues[moves[i].CapturePiece]*10 - PieceValues[moves[i].Piece]/10
+		}
+	}
+	sortMovesByScore(moves)
+	return moves
+}
+
+// promoteMove moves best to the front of moves, keeping the rest in order
+func promoteMove(moves []Move, best Move) []Move {
+	for i, move := range moves {
+		if move.From == best.From && move.To == best.To && move.Promotion == best.Promotion {
+			copy(moves[1:i+1], moves[:i])
+			moves[0] = move
+			break
+		}
+	}
+	return moves
+}
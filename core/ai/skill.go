@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"math/rand"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// Skill level bounds. MaxSkillLevel plays the best move the search finds.
+const (
+	MinSkillLevel     = 1
+	MaxSkillLevel     = 20
+	DefaultSkillLevel = 10
+)
+
+// skillCandidates is the number of best root moves a weakened AI chooses from
+const skillCandidates = 4
+
+// Skill limits the strength of the AI. Lower levels search shallower, with
+// fewer nodes, and sometimes prefer a slightly worse move.
+type Skill struct {
+	Level int
+}
+
+// NewSkill returns a skill clamped to the valid level range
+func NewSkill(level int) Skill {
+	if level < MinSkillLevel {
+		level = MinSkillLevel
+	}
+	if level > MaxSkillLevel {
+		level = MaxSkillLevel
+	}
+	return Skill{Level: level}
+}
+
+// Limits returns the search limits for the skill level. Depth grows every
+// five levels and the node budget doubles every two levels in between.
+func (s Skill) Limits() SearchLimits {
+	return SearchLimits{
+		Depth: 1 + (s.Level-MinSkillLevel)/5,
+		Nodes: 100 << (s.Level / 2),
+	}
+}
+
+// weakness is how strongly the skill level leans towards worse moves
+func (s Skill) weakness() int {
+	return 120 - 2*s.Level
+}
+
+// PickMove chooses among root moves scored best first. Below the maximum
+// level each candidate gets a random bonus of up to a pawn, scaled by the
+// weakness of the level, so moves close to the best are often played while
+// moves that lose more than the bonus never are.
+func (s Skill) PickMove(moves []Move, random *rand.Rand) Move {
+	if s.Level >= MaxSkillLevel || len(moves) == 1 {
+		return moves[0]
+	}
+
+	candidates := moves
+	if len(candidates) > skillCandidates {
+		candidates = candidates[:skillCandidates]
+	}
+
+	topScore := candidates[0].Score
+	delta := topScore - candidates[len(candidates)-1].Score
+	if delta > PieceValues[game.WhitePawn] {
+		// Cap the random spread at a pawn
+		delta = PieceValues[game.WhitePawn]
+	}
+
+	weakness := s.weakness()
+	best := candidates[0]
+	maxScore := -MateScore * 2
+	for _, move := range candidates {
+		push := delta * random.Intn(weakness) / 128
+		if move.Score+push > maxScore {
+			maxScore = move.Score + push
+			best = move
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,102 @@
+package ai
+
+import "time"
+
+// moveOverhead is kept in reserve on every move for the time it takes to
+// deliver the move to the GUI or opponent
+const moveOverhead = 50 * time.Millisecond
+
+// defaultMovesToGo is the number of moves the remaining time is spread
+// over when the time control has no moves-to-go
+const defaultMovesToGo = 30
+
+// hardTimeFactor is how far past the soft budget a move may run
+const hardTimeFactor = 4
+
+// minimumTime is the budget of a move when the clock is nearly or already
+// used up
+const minimumTime = time.Millisecond
+
+// failLowMargin is the score drop between iterations, in centipawns, that
+// makes the search spend more time on the move
+const failLowMargin = 30
+
+// TimeControl is the clock state of the side to move
+type TimeControl struct {
+	Remaining time.Duration
+	Increment time.Duration
+	// MovesToGo is the number of moves until the next time control; 0
+	// means the remaining time must last the whole game
+	MovesToGo int
+}
+
+// TimeBudget is the time allowed for one move. The search starts no new
+// iteration once the soft budget is used, stretched when the position looks
+// unclear, and stops outright at the hard budget.
+type TimeBudget struct {
+	Soft time.Duration
+	Hard time.Duration
+}
+
+// AllocateTime splits the remaining time, plus the increments still to
+// come, between the moves still to play, holding back the move overhead for
+// each of them. The hard budget never exceeds three quarters of the time
+// on the clock after the overhead.
+func AllocateTime(tc TimeControl) TimeBudget {
+	movesToGo := tc.MovesToGo
+	if movesToGo <= 0 {
+		movesToGo = defaultMovesToGo
+	}
+
+	available := tc.Remaining + tc.Increment*time.Duration(movesToGo-1) -
+		moveOverhead*time.Duration(movesToGo+2)
+	maxTime := (tc.Remaining - moveOverhead) * 3 / 4
+	if available <= 0 || maxTime <= 0 {
+		// Move as fast as possible. The clock may already be used up, or
+		// even negative, but the search still needs a budget to move in.
+		return TimeBudget{Soft: minimumTime, Hard: minimumTime}
+	}
+
+	soft := available / time.Duration(movesToGo)
+	if soft > maxTime {
+		soft = maxTime
+	}
+	hard := soft * hardTimeFactor
+	if hard > maxTime {
+		hard = maxTime
+	}
+
+	return TimeBudget{Soft: soft, Hard: hard}
+}
+
+// timeManager decides between iterations whether the main thread should
+// keep deepening
+type timeManager struct {
+	start           time.Time
+	soft            time.Duration
+	bestMoveChanges float64
+	last            SearchResult
+	hasLast         bool
+}
+
+// nextIteration reports whether another iteration fits in the budget after
+// an iteration finished with result. The budget grows when the best move
+// keeps changing or the score drops. Since an iteration takes longer than
+// all the previous ones together, no new one starts past half the budget.
+func (t *timeManager) nextIteration(result SearchResult) bool {
+	scale := 1.0
+	if t.hasLast {
+		t.bestMoveChanges /= 2
+		if result.Move.From != t.last.Move.From || result.Move.To != t.last.Move.To || result.Move.Promotion != t.last.Move.Promotion {
+			t.bestMoveChanges++
+		}
+		scale += t.bestMoveChanges
+		if result.Score < t.last.Score-failLowMargin {
+			scale *= 1.5
+		}
+	}
+	t.last = result
+	t.hasLast = true
+
+	return time.Since(t.start) < time.Duration(float64(t.soft)*scale/2)
+}
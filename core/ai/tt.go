@@ -0,0 +1,182 @@
+package ai
+
+import (
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// ttSizeBits sets the number of transposition table entries per search
+const ttSizeBits = 16
+
+// Bound types of a stored score
+const (
+	ttExact = iota
+	ttLower
+	ttUpper
+)
+
+// mateThreshold separates mate scores, which depend on the ply they were
+// found at, from ordinary evaluations
+const mateThreshold = MateScore - 2*maxSearchDepth
+
+var (
+	zobristPieces   [13][64]uint64
+	zobristBlack    uint64
+	zobristCastling [6]uint64
+	zobristChecks   [2][3]uint64
+	zobristPockets  [2][5][16]uint64
+)
+
+func init() {
+	random := rand.New(rand.NewSource(20240601))
+	for piece := range zobristPieces {
+		for square := range zobristPieces[piece] {
+			zobristPieces[piece][square] = random.Uint64()
+		}
+	}
+	zobristBlack = random.Uint64()
+	for i := range zobristCastling {
+		zobristCastling[i] = random.Uint64()
+	}
+	for player := range zobristChecks {
+		for checks := range zobristChecks[player] {
+			zobristChecks[player][checks] = random.Uint64()
+		}
+	}
+	for player := range zobristPockets {
+		for index := range zobristPockets[player] {
+			for count := range zobristPockets[player][index] {
+				zobristPockets[player][index][count] = random.Uint64()
+			}
+		}
+	}
+}
+
+// zobristKey hashes the board, the side to move and the castling state,
+// the checks given in Three-check and the pockets in Crazyhouse
+func zobristKey(gameState *game.GameState) uint64 {
+	var key uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if piece := gameState.Board[y][x]; piece != game.Empty {
+				key ^= zobristPieces[piece][y*8+x]
+			}
+		}
+	}
+	if gameState.CurrentTurn == game.BlackPlayer {
+		key ^= zobristBlack
+	}
+	for i, moved := range []bool{
+		gameState.WhiteKingMoved, gameState.WhiteRookAMoved, gameState.WhiteRookHMoved,
+		gameState.BlackKingMoved, gameState.BlackRookAMoved, gameState.BlackRookHMoved,
+	} {
+		if moved {
+			key ^= zobristCastling[i]
+		}
+	}
+	if _, ok := gameState.Variant.(game.ThreeCheck); ok {
+		for player := range zobristChecks {
+			if checks := gameState.ChecksGiven(player); checks > 0 {
+				key ^= zobristChecks[player][min(checks, 3)-1]
+			}
+		}
+	}
+	for player, pocket := range gameState.Pockets {
+		for index, count := range pocket {
+			if count > 0 {
+				key ^= zobristPockets[player][index][min(count, 15)]
+			}
+		}
+	}
+	return key
+}
+
+// ttEntry stores the key XORed with the data, so that an entry torn by
+// concurrent writers fails verification instead of returning wrong data.
+// This keeps the table lock-free for the search threads.
+type ttEntry struct {
+	check atomic.Uint64
+	data  atomic.Uint64
+}
+
+// transpositionTable caches search results shared by all search threads
+type transpositionTable struct {
+	entries []ttEntry
+	mask    uint64
+}
+
+// ttResult is an unpacked table entry
+type ttResult struct {
+	score   int
+	depth   int
+	flag    int
+	move    Move
+	hasMove bool
+}
+
+func newTranspositionTable(bits int) *transpositionTable {
+	return &transpositionTable{
+		entries: make([]ttEntry, 1<<bits),
+		mask:    1<<bits - 1,
+	}
+}
+
+// probe looks up a position. Mate scores are returned relative to ply.
+func (t *transpositionTable) probe(key uint64, ply int) (ttResult, bool) {
+	entry := &t.entries[key&t.mask]
+	data := entry.data.Load()
+	if entry.check.Load()^data != key {
+		return ttResult{}, false
+	}
+
+	result := ttResult{
+		score:   int(int32(uint32(data))),
+		depth:   int(data >> 32 & 0xff),
+		flag:    int(data >> 40 & 0x3),
+		hasMove: data>>58&1 == 1,
+	}
+	if result.hasMove {
+		result.move = Move{
+			From:      game.Position{X: int(data >> 42 & 7), Y: int(data >> 45 & 7)},
+			To:        game.Position{X: int(data >> 48 & 7), Y: int(data >> 51 & 7)},
+			Promotion: int(data >> 54 & 0xf),
+		}
+	}
+
+	switch {
+	case result.score > mateThreshold:
+		result.score -= ply
+	case result.score < -mateThreshold:
+		result.score += ply
+	}
+	return result, true
+}
+
+// store saves a search result unless a deeper result for the same position
+// is already there
+func (t *transpositionTable) store(key uint64, ply, depth, flag, score int, move *Move) {
+	entry := &t.entries[key&t.mask]
+	if old := entry.data.Load(); entry.check.Load()^old == key && int(old>>32&0xff) > depth {
+		return
+	}
+
+	// Store mate scores relative to this node rather than the root
+	switch {
+	case score > mateThreshold:
+		score += ply
+	case score < -mateThreshold:
+		score -= ply
+	}
+
+	data := uint64(uint32(int32(score))) | uint64(depth&0xff)<<32 | uint64(flag)<<40
+	if move != nil {
+		data |= uint64(move.From.X)<<42 | uint64(move.From.Y)<<45 |
+			uint64(move.To.X)<<48 | uint64(move.To.Y)<<51 |
+			uint64(move.Promotion&0xf)<<54 | 1<<58
+	}
+
+	entry.check.Store(key ^ data)
+	entry.data.Store(data)
+}
@@ -0,0 +1,237 @@
+package ai
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// TuningPosition is a position labeled with the result of the game it was
+// taken from, from White's point of view: 1, 0.5 or 0
+type TuningPosition struct {
+	Board  [8][8]int
+	Result float64
+}
+
+// LoadTuningPositions reads a labeled position set. Each line holds a FEN,
+// with or without the move counters, followed by the game result, written
+// as 1-0, 0-1, 1/2-1/2 or as 1.0, 0.5 and 0.0, optionally quoted, bracketed
+// or in an EPD c9 operation. The result is the c9 operand if there is one
+// and the last field otherwise; a bare 1 or 0 only counts right after the
+// four FEN fields or in c9, where it cannot be a move counter.
+func LoadTuningPositions(path string) ([]TuningPosition, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var positions []TuningPosition
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(strings.NewReplacer(";", " ", "\"", " ", "[", " ", "]", " ").Replace(line))
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("%s:%d: expected a FEN and a result", path, lineNumber)
+		}
+
+		token, bare := fields[len(fields)-1], len(fields) == 5
+		for i := 4; i < len(fields)-1; i++ {
+			if fields[i] == "c9" {
+				token, bare = fields[i+1], true
+				break
+			}
+		}
+		result, ok := parseResult(token)
+		if !ok && bare {
+			result, ok = parseBareResult(token)
+		}
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: no game result found", path, lineNumber)
+		}
+
+		position, err := game.NewGameFromFEN(strings.Join(fields[:4], " "))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		positions = append(positions, TuningPosition{Board: position.Board, Result: result})
+	}
+
+	return positions, scanner.Err()
+}
+
+func parseResult(token string) (float64, bool) {
+	switch token {
+	case "1-0", "1.0":
+		return 1, true
+	case "0-1", "0.0":
+		return 0, true
+	case "1/2-1/2", "0.5":
+		return 0.5, true
+	}
+	return 0, false
+}
+
+// parseBareResult reads a result written as a bare 1 or 0
+func parseBareResult(token string) (float64, bool) {
+	switch token {
+	case "1":
+		return 1, true
+	case "0":
+		return 0, true
+	}
+	return 0, false
+}
+
+// Tuner fits evaluation weights to game results by minimizing the mean
+// squared error between the results and the evaluation mapped through a
+// logistic function (Texel's tuning method). Positions should be quiet,
+// since they are scored with the static evaluation only.
+type Tuner struct {
+	Positions []TuningPosition
+	// K scales centipawns in the logistic function
+	K float64
+	// Workers is the number of goroutines computing the error
+	Workers int
+	// Log, if not nil, receives progress messages
+	Log func(format string, args ...interface{})
+}
+
+// NewTuner creates a tuner using every CPU
+func NewTuner(positions []TuningPosition) *Tuner {
+	return &Tuner{
+		Positions: positions,
+		K:         1,
+		Workers:   runtime.NumCPU(),
+	}
+}
+
+// Error returns the mean squared error of the weights over the position set
+func (t *Tuner) Error(weights *EvalWeights) float64 {
+	return t.errorWithK(weights, t.K)
+}
+
+func (t *Tuner) errorWithK(weights *EvalWeights, k float64) float64 {
+	if len(t.Positions) == 0 {
+		return 0
+	}
+
+	workers := t.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	chunk := (len(t.Positions) + workers - 1) / workers
+	sums := make([]float64, workers)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		start := worker * chunk
+		end := start + chunk
+		if start >= len(t.Positions) {
+			break
+		}
+		if end > len(t.Positions) {
+			end = len(t.Positions)
+		}
+
+		wg.Add(1)
+		go func(worker int, positions []TuningPosition) {
+			defer wg.Done()
+			sum := 0.0
+			for _, position := range positions {
+				eval := float64(EvaluateWithWeights(position.Board, weights))
+				predicted := 1 / (1 + math.Pow(10, -k*eval/400))
+				sum += (position.Result - predicted) * (position.Result - predicted)
+			}
+			sums[worker] = sum
+		}(worker, t.Positions[start:end])
+	}
+	wg.Wait()
+
+	total := 0.0
+	for _, sum := range sums {
+		total += sum
+	}
+	return total / float64(len(t.Positions))
+}
+
+// FitK finds the scaling constant that best fits the weights to the
+// results, so that tuning does not simply rescale every weight
+func (t *Tuner) FitK(weights *EvalWeights) float64 {
+	low, high := 0.0, 5.0
+	for i := 0; i < 60; i++ {
+		m1 := low + (high-low)/3
+		m2 := high - (high-low)/3
+		if t.errorWithK(weights, m1) < t.errorWithK(weights, m2) {
+			high = m2
+		} else {
+			low = m1
+		}
+	}
+	t.K = (low + high) / 2
+	return t.K
+}
+
+// Tune improves the weights by local search, nudging one weight at a time
+// and keeping any change that lowers the error, until no change helps or
+// maxPasses passes over the weights are done. The pawn value is held fixed
+// as the unit of the evaluation.
+func (t *Tuner) Tune(start EvalWeights, maxPasses int) EvalWeights {
+	best := start
+	bestError := t.Error(&best)
+	t.log("Initial error %.6f (K=%.3f)", bestError, t.K)
+
+	for pass := 1; pass <= maxPasses; pass++ {
+		improved := false
+		params := best.params()
+
+		// Skip the pawn, which anchors the centipawn scale
+		for i := 1; i < len(params); i++ {
+			for _, step := range []int{1, -1} {
+				*params[i] += step
+				candidateError := t.Error(&best)
+				if candidateError < bestError {
+					bestError = candidateError
+					improved = true
+					// Keep stepping in the same direction while it helps
+					for {
+						*params[i] += step
+						nextError := t.Error(&best)
+						if nextError >= bestError {
+							*params[i] -= step
+							break
+						}
+						bestError = nextError
+					}
+					break
+				}
+				*params[i] -= step
+			}
+		}
+
+		t.log("Pass %d: error %.6f", pass, bestError)
+		if !improved {
+			break
+		}
+	}
+
+	return best
+}
+
+func (t *Tuner) log(format string, args ...interface{}) {
+	if t.Log != nil {
+		t.Log(format, args...)
+	}
+}
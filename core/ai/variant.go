@@ -0,0 +1,66 @@
+package ai
+
+import "github.com/h3bzzz/go-chess/core/game"
+
+// hillBonus rewards a King of the Hill king by its distance to the center,
+// indexed by the number of king moves needed to reach it
+var hillBonus = [8]int{0, 300, 120, 50, 20, 0, 0, 0}
+
+// checkBonus rewards the checks given in Three-check
+var checkBonus = [4]int{0, 150, 450, 0}
+
+// variantScore adjusts a White point of view evaluation for the rules of
+// the game's variant
+func variantScore(gameState *game.GameState, score int) int {
+	switch gameState.Variant.(type) {
+	case game.Antichess:
+		// Material is a burden when the goal is to lose it
+		return -score
+	case game.KingOfTheHill:
+		return score + hillScore(gameState.Board, game.WhitePlayer) - hillScore(gameState.Board, game.BlackPlayer)
+	case game.Crazyhouse:
+		return score + pocketScore(gameState, game.WhitePlayer) - pocketScore(gameState, game.BlackPlayer)
+	case game.ThreeCheck:
+		return score + checkBonus[min(gameState.ChecksGiven(game.WhitePlayer), 3)] -
+			checkBonus[min(gameState.ChecksGiven(game.BlackPlayer), 3)]
+	}
+	return score
+}
+
+// hillScore returns the center bonus of the player's king
+func hillScore(board [8][8]int, player int) int {
+	king := game.WhiteKing
+	if player == game.BlackPlayer {
+		king = game.BlackKing
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if board[y][x] != king {
+				continue
+			}
+			distance := 7
+			for _, hill := range []game.Position{{X: 3, Y: 3}, {X: 4, Y: 3}, {X: 3, Y: 4}, {X: 4, Y: 4}} {
+				distance = min(distance, max(abs(hill.X-x), abs(hill.Y-y)))
+			}
+			return hillBonus[distance]
+		}
+	}
+	return 0
+}
+
+// pocketScore values the pieces the player can drop like pieces on the
+// board
+func pocketScore(gameState *game.GameState, player int) int {
+	score := 0
+	for _, piece := range gameState.PocketPieces(player) {
+		score += PieceValues[piece] * gameState.PocketCount(player, piece)
+	}
+	return score
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
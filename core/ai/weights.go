@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// EvalWeights are the tunable terms of the static evaluation, in centipawns
+type EvalWeights struct {
+	Pawn   int `json:"pawn"`
+	Knight int `json:"knight"`
+	Bishop int `json:"bishop"`
+	Rook   int `json:"rook"`
+	Queen  int `json:"queen"`
+
+	// Center is the bonus for a square in the 4x4 center and InnerCenter
+	// the bonus for the four central squares
+	Center      int `json:"center"`
+	InnerCenter int `json:"inner_center"`
+	// PawnAdvance is the bonus per rank a pawn has advanced
+	PawnAdvance int `json:"pawn_advance"`
+	// Undeveloped is the penalty for a minor piece on its back rank
+	Undeveloped int `json:"undeveloped"`
+}
+
+// DefaultWeights returns the hand-picked evaluation weights
+func DefaultWeights() EvalWeights {
+	return EvalWeights{
+		Pawn:        100,
+		Knight:      320,
+		Bishop:      330,
+		Rook:        500,
+		Queen:       900,
+		Center:      10,
+		InnerCenter: 20,
+		PawnAdvance: 5,
+		Undeveloped: 15,
+	}
+}
+
+var (
+	weightsMutex  sync.RWMutex
+	activeWeights = DefaultWeights()
+)
+
+// ActiveWeights returns the weights used by Evaluate
+func ActiveWeights() EvalWeights {
+	weightsMutex.RLock()
+	defer weightsMutex.RUnlock()
+	return activeWeights
+}
+
+// SetWeights replaces the weights used by Evaluate and updates PieceValues
+// so that move ordering agrees with the evaluation
+func SetWeights(weights EvalWeights) {
+	weightsMutex.Lock()
+	defer weightsMutex.Unlock()
+
+	activeWeights = weights
+	for piece, value := range weights.pieceValues() {
+		PieceValues[piece] = value
+	}
+}
+
+// LoadWeights reads evaluation weights from a JSON file. Terms missing from
+// the file keep their default values.
+func LoadWeights(path string) (EvalWeights, error) {
+	weights := DefaultWeights()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return weights, err
+	}
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return weights, fmt.Errorf("parsing weights %s: %w", path, err)
+	}
+	return weights, nil
+}
+
+// SaveWeights writes evaluation weights to a JSON file
+func SaveWeights(path string, weights EvalWeights) error {
+	data, err := json.MarshalIndent(weights, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// GoSource renders the weights as a Go function that can replace DefaultWeights
+func (w EvalWeights) GoSource() string {
+	return fmt.Sprintf(`// DefaultWeights returns the tuned evaluation weights
+func DefaultWeights() EvalWeights {
+	return EvalWeights{
+		Pawn:        %d,
+		Knight:      %d,
+		Bishop:      %d,
+		Rook:        %d,
+		Queen:       %d,
+		Center:      %d,
+		InnerCenter: %d,
+		PawnAdvance: %d,
+		Undeveloped: %d,
+	}
+}
+`, w.Pawn, w.Knight, w.Bishop, w.Rook, w.Queen, w.Center, w.InnerCenter, w.PawnAdvance, w.Undeveloped)
+}
+
+// params lists the weights in a fixed order for the tuner
+func (w *EvalWeights) params() []*int {
+	return []*int{
+		&w.Pawn, &w.Knight, &w.Bishop, &w.Rook, &w.Queen,
+		&w.Center, &w.InnerCenter, &w.PawnAdvance, &w.Undeveloped,
+	}
+}
+
+func (w EvalWeights) pieceValues() map[int]int {
+	return map[int]int{
+		game.WhitePawn:   w.Pawn,
+		game.BlackPawn:   w.Pawn,
+		game.WhiteKnight: w.Knight,
+		game.BlackKnight: w.Knight,
+		game.WhiteBishop: w.Bishop,
+		game.BlackBishop: w.Bishop,
+		game.WhiteRook:   w.Rook,
+		game.BlackRook:   w.Rook,
+		game.WhiteQueen:  w.Queen,
+		game.BlackQueen:  w.Queen,
+	}
+}
+
+// material returns the value of a piece under these weights
+func (w *EvalWeights) material(piece int) int {
+	switch piece {
+	case game.WhitePawn, game.BlackPawn:
+		return w.Pawn
+	case game.WhiteKnight, game.BlackKnight:
+		return w.Knight
+	case game.WhiteBishop, game.BlackBishop:
+		return w.Bishop
+	case game.WhiteRook, game.BlackRook:
+		return w.Rook
+	case game.WhiteQueen, game.BlackQueen:
+		return w.Queen
+	}
+	return 0
+}
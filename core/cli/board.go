@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// ANSI escape sequences used to draw the board in color
+const (
+	ansiReset      = "\x1b[0m"
+	ansiLight      = "\x1b[48;5;180m"
+	ansiDark       = "\x1b[48;5;137m"
+	ansiLastLight  = "\x1b[48;5;186m"
+	ansiLastDark   = "\x1b[48;5;143m"
+	ansiWhitePiece = "\x1b[1;97m"
+	ansiBlackPiece = "\x1b[1;30m"
+)
+
+// Piece symbols by piece, from pawn to king
+var (
+	asciiPieces  = " PNBRQKpnbrqk"
+	unicodeWhite = []string{"♙", "♘", "♗", "♖", "♕", "♔"}
+	unicodeBlack = []string{"♟", "♞", "♝", "♜", "♛", "♚"}
+)
+
+// renderer draws positions as text
+type renderer struct {
+	// ascii uses letters instead of Unicode chess symbols
+	ascii bool
+	// color paints the squares and pieces with ANSI escapes
+	color bool
+}
+
+// render draws the position seen from the given player's side, marking the
+// squares of the last move when colors are on
+func (r renderer) render(g *game.GameState, from int) string {
+	var last *game.Move
+	if len(g.MoveHistory) > 0 {
+		last = &g.MoveHistory[len(g.MoveHistory)-1]
+	}
+
+	ranks := []int{7, 6, 5, 4, 3, 2, 1, 0}
+	files := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	if from == game.BlackPlayer {
+		ranks = []int{0, 1, 2, 3, 4, 5, 6, 7}
+		files = []int{7, 6, 5, 4, 3, 2, 1, 0}
+	}
+
+	var sb strings.Builder
+	for _, y := range ranks {
+		fmt.Fprintf(&sb, " %d ", y+1)
+		for _, x := range files {
+			pos := game.Position{X: x, Y: y}
+			sb.WriteString(r.square(g.GetPieceAtPosition(pos), (x+y)%2 == 1, last != nil && (last.From == pos || last.To == pos)))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("   ")
+	for _, x := range files {
+		if r.color {
+			fmt.Fprintf(&sb, " %c ", 'a'+x)
+		} else {
+			fmt.Fprintf(&sb, "%c ", 'a'+x)
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// square draws one square. Without colors, empty dark squares are dotted so
+// the board stays readable.
+func (r renderer) square(piece int, light, lastMove bool) string {
+	if !r.color {
+		if piece == game.Empty {
+			if light {
+				return "  "
+			}
+			return ". "
+		}
+		return r.symbol(piece) + " "
+	}
+
+	background := ansiDark
+	switch {
+	case light && lastMove:
+		background = ansiLastLight
+	case light:
+		background = ansiLight
+	case lastMove:
+		background = ansiLastDark
+	}
+	if piece == game.Empty {
+		return background + "   " + ansiReset
+	}
+	foreground := ansiBlackPiece
+	if piece <= game.WhiteKing {
+		foreground = ansiWhitePiece
+	}
+	return background + foreground + " " + r.symbol(piece) + " " + ansiReset
+}
+
+// symbol returns the letter or chess symbol of a piece. In color the filled
+// symbols are used for both sides, told apart by their color.
+func (r renderer) symbol(piece int) string {
+	if r.ascii {
+		return string(asciiPieces[piece])
+	}
+	white := piece <= game.WhiteKing
+	kind := piece - 1
+	if !white {
+		kind -= 6
+	}
+	if white && !r.color {
+		return unicodeWhite[kind]
+	}
+	return unicodeBlack[kind]
+}
@@ -0,0 +1,327 @@
+// Package cli plays chess over text streams, against the built-in AI or
+// between two people at the same keyboard. It backs the terminal client in
+// cmd/cli.
+//
+// Moves are typed in SAN ("Nf3", "exd5", "O-O", "e8=Q") or coordinates
+// ("g1f3", "e7e8q"). Type "help" for the commands.
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+const helpText = `Moves: SAN such as Nf3, exd5, O-O, e8=Q, or coordinates such as g1f3, e7e8q
+Commands:
+  new               start a new game
+  undo              take back your last move (and the AI's reply)
+  fen [FEN]         show the position as FEN, or set up a position
+  pgn               show the game as PGN
+  ai on|off         play against the AI or against another person
+  ai white|black    choose the AI's color
+  level [1-20]      show or set the AI skill level
+  flip              turn the board around
+  board             show the board again
+  help              show this help
+  quit              leave`
+
+// Options configure a session
+type Options struct {
+	// ASCII draws pieces as letters instead of chess symbols
+	ASCII bool
+	// Color draws the board with ANSI colors
+	Color bool
+	// AI plays AIColor against the user; without it two people play
+	AI      bool
+	AIColor int
+	// Level is the AI skill level
+	Level int
+	// FEN is the position to start from instead of the standard one
+	FEN string
+}
+
+// session is a game in the terminal
+type session struct {
+	game   *game.GameState
+	out    io.Writer
+	render renderer
+	// positions counts how often each position has occurred, for the
+	// repetition rule
+	positions map[string]int
+
+	aiOn    bool
+	aiColor int
+	level   int
+	// view is the side the board is drawn from
+	view int
+}
+
+// Run plays a session, reading moves and commands from in until it ends or
+// the user quits
+func Run(in io.Reader, out io.Writer, options Options) error {
+	s := &session{
+		out:     out,
+		render:  renderer{ascii: options.ASCII, color: options.Color},
+		aiOn:    options.AI,
+		aiColor: options.AIColor,
+		level:   ai.NewSkill(options.Level).Level,
+	}
+	s.setGame(game.NewGame())
+	if options.FEN != "" {
+		if err := s.setPosition(options.FEN); err != nil {
+			return fmt.Errorf("invalid FEN: %w", err)
+		}
+	}
+	s.view = s.humanColor()
+
+	fmt.Fprintln(s.out, `Type a move, or "help" for the commands.`)
+	s.showBoard()
+	s.playAI()
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(s.out, "%s> ", colorName(s.game.CurrentTurn))
+		if !scanner.Scan() {
+			fmt.Fprintln(s.out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !s.command(line) {
+			return nil
+		}
+	}
+}
+
+// command runs a line of input and reports whether to keep playing
+func (s *session) command(line string) bool {
+	fields := strings.Fields(line)
+	args := fields[1:]
+	switch strings.ToLower(fields[0]) {
+	case "quit", "exit":
+		return false
+	case "help", "?":
+		fmt.Fprintln(s.out, helpText)
+	case "board":
+		s.showBoard()
+	case "flip":
+		s.view = 1 - s.view
+		s.showBoard()
+	case "new":
+		s.setGame(game.NewGame())
+		s.view = s.humanColor()
+		s.showBoard()
+		s.playAI()
+	case "undo":
+		s.undo()
+	case "fen":
+		if len(args) == 0 {
+			fmt.Fprintln(s.out, s.game.FEN())
+			break
+		}
+		if err := s.setPosition(strings.Join(args, " ")); err != nil {
+			fmt.Fprintf(s.out, "Invalid FEN: %v\n", err)
+			break
+		}
+		s.showBoard()
+		s.playAI()
+	case "pgn":
+		fmt.Fprint(s.out, s.game.ToPGN().String())
+	case "ai":
+		s.setAI(args)
+	case "level":
+		if len(args) == 0 {
+			fmt.Fprintf(s.out, "AI skill level %d\n", s.level)
+			break
+		}
+		level, err := strconv.Atoi(args[0])
+		if err != nil || level < ai.MinSkillLevel || level > ai.MaxSkillLevel {
+			fmt.Fprintf(s.out, "The level is a number from %d to %d\n", ai.MinSkillLevel, ai.MaxSkillLevel)
+			break
+		}
+		s.level = level
+		fmt.Fprintf(s.out, "AI skill level %d\n", level)
+	default:
+		s.move(line)
+	}
+	return true
+}
+
+// move plays the user's move, then the AI's reply
+func (s *session) move(text string) {
+	if s.game.IsGameOver() {
+		fmt.Fprintln(s.out, `The game is over. Type "new" to play again.`)
+		return
+	}
+	from, to, promotion, err := s.parseMove(text)
+	if err != nil {
+		fmt.Fprintf(s.out, "%v. Type \"help\" for the commands.\n", err)
+		return
+	}
+	s.game.MakeMoveWithPromotion(from, to, promotion)
+	s.positions[s.game.PositionKey()]++
+	s.adjudicate()
+	s.showBoard()
+	s.playAI()
+}
+
+// parseMove reads a move in SAN or coordinates
+func (s *session) parseMove(text string) (game.Position, game.Position, int, error) {
+	from, to, promotion, err := s.game.ParseSAN(text)
+	if err == nil {
+		return from, to, promotion, nil
+	}
+	if uciFrom, uciTo, uciPromotion, uciErr := s.game.ParseUCI(text); uciErr == nil {
+		if !s.game.IsLegal(uciFrom, uciTo, uciPromotion) {
+			return from, to, promotion, fmt.Errorf("%s is not legal here", text)
+		}
+		return uciFrom, uciTo, uciPromotion, nil
+	}
+	return from, to, promotion, err
+}
+
+// playAI lets the AI move while it is its turn
+func (s *session) playAI() {
+	for s.aiOn && s.game.CurrentTurn == s.aiColor && !s.game.IsGameOver() {
+		before := s.game.Clone()
+		engine := ai.NewChessAI(s.game, 1-s.aiColor, s.level)
+		if !engine.MakeMove() {
+			return
+		}
+		move := s.game.MoveHistory[len(s.game.MoveHistory)-1]
+		fmt.Fprintf(s.out, "AI plays %s\n", before.SAN(move.From, move.To, move.Promotion))
+		s.positions[s.game.PositionKey()]++
+		s.adjudicate()
+		s.showBoard()
+	}
+}
+
+// adjudicate ends the game when a draw rule applies, which playing the
+// moves does not check
+func (s *session) adjudicate() {
+	if s.game.IsGameOver() {
+		return
+	}
+	result, termination, over := s.game.Outcome(s.positions)
+	if !over {
+		return
+	}
+	switch result {
+	case "1-0":
+		s.game.GameStatus = game.WhiteWon
+	case "0-1":
+		s.game.GameStatus = game.BlackWon
+	default:
+		s.game.GameStatus = game.GameDraw
+	}
+	s.game.Termination = termination
+}
+
+// undo takes back the last move, and the AI's move before it so that it is
+// the user's turn again
+func (s *session) undo() {
+	if !s.takeBack() {
+		fmt.Fprintln(s.out, "There is no move to take back")
+		return
+	}
+	if s.aiOn && s.game.CurrentTurn == s.aiColor {
+		s.takeBack()
+	}
+	s.showBoard()
+	s.playAI()
+}
+
+// takeBack undoes the last move and forgets the position it led to
+func (s *session) takeBack() bool {
+	key := s.game.PositionKey()
+	if !s.game.UndoLastMove() {
+		return false
+	}
+	s.positions[key]--
+	return true
+}
+
+// setAI handles "ai on|off|white|black"
+func (s *session) setAI(args []string) {
+	if len(args) != 1 {
+		state := "off"
+		if s.aiOn {
+			state = "plays " + colorName(s.aiColor)
+		}
+		fmt.Fprintf(s.out, "AI %s. Use ai on|off|white|black.\n", state)
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case "on":
+		s.aiOn = true
+	case "off":
+		s.aiOn = false
+	case "white":
+		s.aiOn, s.aiColor = true, game.WhitePlayer
+	case "black":
+		s.aiOn, s.aiColor = true, game.BlackPlayer
+	default:
+		fmt.Fprintln(s.out, "Use ai on|off|white|black")
+		return
+	}
+	if s.aiOn {
+		fmt.Fprintf(s.out, "AI plays %s\n", colorName(s.aiColor))
+	} else {
+		fmt.Fprintln(s.out, "AI off")
+	}
+	s.playAI()
+}
+
+// setPosition starts a game from a FEN position
+func (s *session) setPosition(fen string) error {
+	position, err := game.NewGameFromFEN(fen)
+	if err != nil {
+		return err
+	}
+	s.setGame(position)
+	return nil
+}
+
+// setGame starts counting positions afresh from the game's position
+func (s *session) setGame(g *game.GameState) {
+	s.game = g
+	s.positions = map[string]int{g.PositionKey(): 1}
+	s.adjudicate()
+}
+
+// humanColor returns the side the user plays
+func (s *session) humanColor() int {
+	if s.aiOn {
+		return 1 - s.aiColor
+	}
+	return game.WhitePlayer
+}
+
+// showBoard prints the board and the state of the game
+func (s *session) showBoard() {
+	fmt.Fprintln(s.out)
+	fmt.Fprint(s.out, s.render.render(s.game, s.view))
+	status := s.game.GetGameStatus()
+	if s.game.GameStatus == game.GameDraw && s.game.Termination != "" {
+		status = "Draw by " + s.game.Termination
+	}
+	if opening, ok := s.game.ClassifyOpening(); ok {
+		status += fmt.Sprintf("  (%s %s)", opening.ECO, opening.Name)
+	}
+	fmt.Fprintln(s.out, status)
+}
+
+func colorName(color int) string {
+	if color == game.WhitePlayer {
+		return "white"
+	}
+	return "black"
+}
@@ -0,0 +1,465 @@
+// Package correspondence keeps correspondence games, played over days with
+// a time limit per move, in a local database file.
+package correspondence
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/game"
+	"github.com/h3bzzz/go-chess/core/store"
+)
+
+// Database buckets
+const (
+	gamesBucket     = "correspondence"
+	vacationsBucket = "vacations"
+)
+
+// Limits of the time controls and vacations
+const (
+	MaxDaysPerMove = 60
+	// MaxVacationDays is how many days of vacation a player may take in a
+	// calendar year
+	MaxVacationDays = 30
+)
+
+// Day is the unit of correspondence time controls
+const Day = 24 * time.Hour
+
+// Errors of Manager.Move
+var (
+	// ErrGameOver is returned for moves in a finished game
+	ErrGameOver = errors.New("the game is over")
+	// ErrTimeForfeit is returned for a move made after the deadline, which
+	// loses the game instead
+	ErrTimeForfeit = errors.New("the time for the move ran out")
+)
+
+// Game is a correspondence game
+type Game struct {
+	ID    int    `json:"id"`
+	White string `json:"white"`
+	Black string `json:"black"`
+	// Variant is "Standard", "Chess960" or the name of one of game.Variants
+	Variant     string    `json:"variant"`
+	StartFEN    string    `json:"start_fen"`
+	Moves       []string  `json:"moves,omitempty"`
+	DaysPerMove int       `json:"days_per_move"`
+	Started     time.Time `json:"started"`
+	// LastMove is when the time of the side to move started running
+	LastMove    time.Time `json:"last_move"`
+	Result      string    `json:"result,omitempty"`
+	Termination string    `json:"termination,omitempty"`
+}
+
+// Vacation is a period in which a player's games wait for them
+type Vacation struct {
+	From  time.Time `json:"from"`
+	Until time.Time `json:"until"`
+}
+
+// Manager creates and plays the games of a database
+type Manager struct {
+	db *store.DB
+	// Now returns the current time
+	Now func() time.Time
+}
+
+// DefaultPath returns where correspondence games are stored for the current
+// user
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-chess", "correspondence.db"), nil
+}
+
+// Open opens the database at path, creating it if needed
+func Open(path string) (*Manager, error) {
+	db, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{db: db, Now: time.Now}, nil
+}
+
+// Close closes the database
+func (m *Manager) Close() error {
+	return m.db.Close()
+}
+
+// NewGame starts a game between two players. A Chess960 game starts from a
+// random position.
+func (m *Manager) NewGame(white, black, variant string, daysPerMove int) (*Game, error) {
+	if white == "" || black == "" {
+		return nil, errors.New("both players need a name")
+	}
+	if daysPerMove < 1 || daysPerMove > MaxDaysPerMove {
+		return nil, fmt.Errorf("days per move must be between 1 and %d", MaxDaysPerMove)
+	}
+
+	startFEN := game.StandardFEN
+	switch variant {
+	case "", "Standard":
+		variant = "Standard"
+	case "Chess960":
+		fen, err := game.Chess960FEN(game.RandomChess960ID())
+		if err != nil {
+			return nil, err
+		}
+		startFEN = fen
+	default:
+		rules, ok := game.VariantByName(variant)
+		if !ok {
+			return nil, fmt.Errorf("unknown variant %q", variant)
+		}
+		variant, startFEN = rules.Name(), rules.StartFEN()
+	}
+
+	now := m.Now()
+	g := &Game{
+		White:       white,
+		Black:       black,
+		Variant:     variant,
+		StartFEN:    startFEN,
+		DaysPerMove: daysPerMove,
+		Started:     now,
+		LastMove:    now,
+	}
+	err := m.db.Update(func(tx *store.Tx) error {
+		id, err := tx.NextSequence(gamesBucket)
+		if err != nil {
+			return err
+		}
+		g.ID = int(id)
+		return tx.PutJSON(gamesBucket, gameKey(g.ID), g)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Game returns a game by ID
+func (m *Manager) Game(id int) (*Game, error) {
+	var g Game
+	err := m.db.View(func(tx *store.Tx) error {
+		return tx.GetJSON(gamesBucket, gameKey(id), &g)
+	})
+	if err == store.ErrNotFound {
+		return nil, fmt.Errorf("no correspondence game %d", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// Games returns every game: those in progress first, the most urgent
+// first, then the finished ones, most recent first
+func (m *Manager) Games() ([]*Game, error) {
+	var games []*Game
+	err := m.db.View(func(tx *store.Tx) error {
+		return tx.ForEach(gamesBucket, func(key string, value []byte) error {
+			var g Game
+			if err := tx.GetJSON(gamesBucket, key, &g); err != nil {
+				return err
+			}
+			games = append(games, &g)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deadlines := map[int]time.Time{}
+	for _, g := range games {
+		if !g.Over() {
+			if deadlines[g.ID], err = m.Deadline(g); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.SliceStable(games, func(i, j int) bool {
+		a, b := games[i], games[j]
+		if a.Over() != b.Over() {
+			return !a.Over()
+		}
+		if !a.Over() {
+			return deadlines[a.ID].Before(deadlines[b.ID])
+		}
+		return a.ID > b.ID
+	})
+	return games, nil
+}
+
+// Move plays a move in UCI notation for the side to move and returns the
+// game after it
+func (m *Manager) Move(id int, uci string) (*Game, error) {
+	g, err := m.Game(id)
+	if err != nil {
+		return nil, err
+	}
+	if g.Over() {
+		return g, ErrGameOver
+	}
+
+	state, positions, err := g.replay()
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.Now()
+	deadline, err := m.Deadline(g)
+	if err != nil {
+		return nil, err
+	}
+	if now.After(deadline) {
+		g.end(game.WinResult(1-state.CurrentTurn), "time forfeit")
+		if err := m.save(g); err != nil {
+			return nil, err
+		}
+		return g, ErrTimeForfeit
+	}
+
+	from, to, promotion, err := state.ParseUCI(uci)
+	if err != nil {
+		return g, err
+	}
+	if !state.IsLegal(from, to, promotion) {
+		return g, fmt.Errorf("illegal move %q", uci)
+	}
+	g.Moves = append(g.Moves, state.UCI(from, to, promotion))
+	state.MakeMoveWithPromotion(from, to, promotion)
+	positions[state.PositionKey()]++
+	g.LastMove = now
+
+	if result, termination, over := state.Outcome(positions); over {
+		g.end(result, termination)
+	}
+	return g, m.save(g)
+}
+
+// Resign ends a game in progress as a loss for player
+func (m *Manager) Resign(id, player int) (*Game, error) {
+	g, err := m.Game(id)
+	if err != nil {
+		return nil, err
+	}
+	if g.Over() {
+		return g, ErrGameOver
+	}
+	g.end(game.WinResult(1-player), "resignation")
+	return g, m.save(g)
+}
+
+// CheckTimeouts ends every game whose player to move has let the deadline
+// pass and returns those games
+func (m *Manager) CheckTimeouts() ([]*Game, error) {
+	games, err := m.Games()
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.Now()
+	var timedOut []*Game
+	for _, g := range games {
+		if g.Over() {
+			continue
+		}
+		deadline, err := m.Deadline(g)
+		if err != nil {
+			return timedOut, err
+		}
+		if !now.After(deadline) {
+			continue
+		}
+		turn, err := g.Turn()
+		if err != nil {
+			return timedOut, err
+		}
+		g.end(game.WinResult(1-turn), "time forfeit")
+		if err := m.save(g); err != nil {
+			return timedOut, err
+		}
+		timedOut = append(timedOut, g)
+	}
+	return timedOut, nil
+}
+
+// Deadline returns when the player to move loses on time. Vacations of that
+// player stop the clock.
+func (m *Manager) Deadline(g *Game) (time.Time, error) {
+	turn, err := g.Turn()
+	if err != nil {
+		return time.Time{}, err
+	}
+	vacations, err := m.Vacations(g.Player(turn))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return deadline(g.LastMove, time.Duration(g.DaysPerMove)*Day, vacations), nil
+}
+
+// deadline adds allowed to start, skipping the vacations, which are sorted
+// by start
+func deadline(start time.Time, allowed time.Duration, vacations []Vacation) time.Time {
+	t, left := start, allowed
+	for _, vacation := range vacations {
+		if !vacation.Until.After(t) {
+			continue
+		}
+		if vacation.From.After(t) {
+			if vacation.From.Sub(t) >= left {
+				break
+			}
+			left -= vacation.From.Sub(t)
+		}
+		t = vacation.Until
+	}
+	return t.Add(left)
+}
+
+// TakeVacation pauses the player's games for a number of days from a date,
+// within MaxVacationDays per calendar year
+func (m *Manager) TakeVacation(player string, from time.Time, days int) error {
+	if days < 1 {
+		return errors.New("a vacation lasts at least a day")
+	}
+	return m.db.Update(func(tx *store.Tx) error {
+		var vacations []Vacation
+		if err := tx.GetJSON(vacationsBucket, player, &vacations); err != nil && err != store.ErrNotFound {
+			return err
+		}
+
+		taken := 0
+		for _, vacation := range vacations {
+			if vacation.From.Year() == from.Year() {
+				taken += int(vacation.Until.Sub(vacation.From) / Day)
+			}
+		}
+		if taken+days > MaxVacationDays {
+			return fmt.Errorf("%s has %d vacation days left in %d", player, MaxVacationDays-taken, from.Year())
+		}
+
+		vacations = append(vacations, Vacation{From: from, Until: from.Add(time.Duration(days) * Day)})
+		sort.Slice(vacations, func(i, j int) bool { return vacations[i].From.Before(vacations[j].From) })
+		return tx.PutJSON(vacationsBucket, player, vacations)
+	})
+}
+
+// Vacations returns the player's vacations in order
+func (m *Manager) Vacations(player string) ([]Vacation, error) {
+	var vacations []Vacation
+	err := m.db.View(func(tx *store.Tx) error {
+		return tx.GetJSON(vacationsBucket, player, &vacations)
+	})
+	if err == store.ErrNotFound {
+		return nil, nil
+	}
+	return vacations, err
+}
+
+// OnVacation reports whether the player is on vacation at a time
+func (m *Manager) OnVacation(player string, at time.Time) (bool, error) {
+	vacations, err := m.Vacations(player)
+	for _, vacation := range vacations {
+		if !at.Before(vacation.From) && at.Before(vacation.Until) {
+			return true, err
+		}
+	}
+	return false, err
+}
+
+func (m *Manager) save(g *Game) error {
+	return m.db.Update(func(tx *store.Tx) error {
+		return tx.PutJSON(gamesBucket, gameKey(g.ID), g)
+	})
+}
+
+// Over reports whether the game has finished
+func (g *Game) Over() bool {
+	return g.Result != ""
+}
+
+// Player returns the name of the player of a color
+func (g *Game) Player(color int) string {
+	if color == game.BlackPlayer {
+		return g.Black
+	}
+	return g.White
+}
+
+// Turn returns the color to move
+func (g *Game) Turn() (int, error) {
+	state, _, err := g.replay()
+	if err != nil {
+		return 0, err
+	}
+	return state.CurrentTurn, nil
+}
+
+// State replays the game. The returned state has its timer stopped and, for
+// a finished game, the final result.
+func (g *Game) State() (*game.GameState, error) {
+	state, _, err := g.replay()
+	if err != nil {
+		return nil, err
+	}
+	switch g.Result {
+	case "1-0":
+		state.GameStatus = game.WhiteWon
+	case "0-1":
+		state.GameStatus = game.BlackWon
+	case "1/2-1/2":
+		state.GameStatus = game.GameDraw
+	}
+	if g.Over() {
+		state.Termination = g.Termination
+	}
+	return state, nil
+}
+
+// replay plays the moves from the start position and counts how often each
+// position occurred
+func (g *Game) replay() (*game.GameState, map[string]int, error) {
+	state, err := game.NewGameFromFEN(g.StartFEN)
+	if err != nil {
+		return nil, nil, err
+	}
+	if g.Variant == "Chess960" {
+		state.Chess960 = true
+	} else if rules, ok := game.VariantByName(g.Variant); ok {
+		state.SetVariant(rules)
+	}
+	state.TimerActive = false
+
+	positions := map[string]int{state.PositionKey(): 1}
+	for i, move := range g.Moves {
+		from, to, promotion, err := state.ParseUCI(move)
+		if err != nil {
+			return nil, nil, fmt.Errorf("game %d move %d: %v", g.ID, i+1, err)
+		}
+		if state.MakeMoveWithPromotion(from, to, promotion) == game.InvalidMove {
+			return nil, nil, fmt.Errorf("game %d move %d: illegal move %q", g.ID, i+1, move)
+		}
+		positions[state.PositionKey()]++
+	}
+	return state, positions, nil
+}
+
+func (g *Game) end(result, termination string) {
+	g.Result, g.Termination = result, termination
+}
+
+// gameKey keeps games in ID order in the database
+func gameKey(id int) string {
+	return fmt.Sprintf("%010d", id)
+}
@@ -0,0 +1,188 @@
+// Package engine runs the built-in AI as a chess engine for other programs,
+// speaking the UCI or the CECP (WinBoard/XBoard) protocol over text streams.
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// Name is the engine name reported to GUIs
+const Name = "go-chess"
+
+// Options configure the engine
+type Options struct {
+	// Threads is the number of search goroutines
+	Threads int
+	// Weights overrides the evaluation weights when set
+	Weights *ai.EvalWeights
+}
+
+// protocol handles the commands of one protocol
+type protocol interface {
+	// command runs a command line and reports whether to keep running
+	command(fields []string) bool
+	// searched is called with the result of a search that was not stopped
+	// by stopSearch
+	searched(result ai.SearchResult)
+}
+
+// engine is the state shared by the protocols: the position, the running
+// search and the output. Commands and search results are handled on one
+// goroutine, so none of it needs locking.
+type engine struct {
+	out      io.Writer
+	options  Options
+	position *game.GameState
+
+	// cancel stops the running search; nil when idle
+	cancel  chan struct{}
+	results chan ai.SearchResult
+}
+
+// Run reads commands from in and answers on out until "quit" or the end of
+// the input. The first command picks the protocol: "xboard" starts CECP,
+// anything else UCI.
+func Run(in io.Reader, out io.Writer, options Options) error {
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		readErr <- scanner.Err()
+		close(lines)
+	}()
+
+	e := &engine{
+		out:      out,
+		options:  options,
+		position: newPosition(),
+		results:  make(chan ai.SearchResult, 1),
+	}
+	var p protocol
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				e.stopSearch()
+				return <-readErr
+			}
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			if p == nil {
+				if fields[0] == "xboard" {
+					p = newXBoard(e)
+				} else {
+					p = newUCI(e)
+				}
+			}
+			if !p.command(fields) {
+				e.stopSearch()
+				return nil
+			}
+		case result := <-e.results:
+			e.cancel = nil
+			p.searched(result)
+		}
+	}
+}
+
+// send writes a line of output
+func (e *engine) send(format string, args ...interface{}) {
+	fmt.Fprintf(e.out, format+"\n", args...)
+}
+
+// startSearch searches the current position in the background. The result
+// goes to the protocol's searched method.
+func (e *engine) startSearch(limits ai.SearchLimits) {
+	e.stopSearch()
+	e.cancel = make(chan struct{})
+	limits.Cancel = e.cancel
+	limits.Threads = e.options.Threads
+	limits.Weights = e.options.Weights
+	position := e.position.Clone()
+	go func() {
+		e.results <- ai.SearchWithLimits(position, limits)
+	}()
+}
+
+// searching reports whether a search is running
+func (e *engine) searching() bool {
+	return e.cancel != nil
+}
+
+// stopSearch stops the running search and returns its result, which the
+// protocol's searched method does not see
+func (e *engine) stopSearch() (ai.SearchResult, bool) {
+	if e.cancel == nil {
+		return ai.SearchResult{}, false
+	}
+	close(e.cancel)
+	result := <-e.results
+	e.cancel = nil
+	return result, true
+}
+
+// bestMove returns the move of a search result in UCI notation. A search
+// cancelled before its first iteration has no move, so any legal move is
+// played instead. It returns "" when there is no legal move.
+func (e *engine) bestMove(result ai.SearchResult) string {
+	move := result.Move
+	if move.From != move.To {
+		return e.position.UCI(move.From, move.To, move.Promotion)
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			from := game.Position{X: x, Y: y}
+			piece := e.position.GetPieceAtPosition(from)
+			if piece == game.Empty || (piece <= game.WhiteKing) != (e.position.CurrentTurn == game.WhitePlayer) {
+				continue
+			}
+			if moves := e.position.GetPossibleMoves(from); len(moves) > 0 {
+				return e.position.UCI(from, moves[0], game.WhiteQueen)
+			}
+		}
+	}
+	return ""
+}
+
+// play makes a move given in UCI notation on the position
+func (e *engine) play(move string) error {
+	from, to, promotion, err := e.position.ParseUCI(move)
+	if err != nil {
+		return err
+	}
+	if !e.position.IsLegal(from, to, promotion) {
+		return fmt.Errorf("illegal move %s", move)
+	}
+	e.position.MakeMoveWithPromotion(from, to, promotion)
+	return nil
+}
+
+// newPosition returns the standard starting position with the clock off
+func newPosition() *game.GameState {
+	position := game.NewGame()
+	position.TimerActive = false
+	return position
+}
+
+// formatScore writes a score as UCI does: "cp 35", or "mate 3" when a mate
+// is found, negative when the engine is mated
+func formatScore(score int) string {
+	if score >= ai.MateScore-1000 {
+		return fmt.Sprintf("mate %d", (ai.MateScore-score+1)/2)
+	}
+	if score <= -(ai.MateScore - 1000) {
+		return fmt.Sprintf("mate -%d", (ai.MateScore+score+1)/2)
+	}
+	return fmt.Sprintf("cp %d", score)
+}
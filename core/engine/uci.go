@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// uci speaks the Universal Chess Interface
+type uci struct {
+	e *engine
+}
+
+func newUCI(e *engine) *uci {
+	return &uci{e: e}
+}
+
+func (u *uci) command(fields []string) bool {
+	e := u.e
+	switch fields[0] {
+	case "uci":
+		e.send("id name %s", Name)
+		e.send("id author h3bzzz")
+		e.send("option name Threads type spin default %d min 1 max 256", max(e.options.Threads, 1))
+		e.send("uciok")
+	case "isready":
+		e.send("readyok")
+	case "setoption":
+		u.setOption(fields[1:])
+	case "ucinewgame":
+		e.stopSearch()
+		e.position = newPosition()
+	case "position":
+		e.stopSearch()
+		if err := u.setPosition(fields[1:]); err != nil {
+			e.send("info string %v", err)
+		}
+	case "go":
+		e.startSearch(u.limits(fields[1:]))
+	case "stop":
+		if result, ok := e.stopSearch(); ok {
+			u.searched(result)
+		}
+	case "quit":
+		return false
+	}
+	return true
+}
+
+func (u *uci) searched(result ai.SearchResult) {
+	e := u.e
+	if result.Move.From != result.Move.To {
+		e.send("info depth %d score %s nodes %d", result.Depth, formatScore(result.Score), result.Nodes)
+	}
+	move := e.bestMove(result)
+	if move == "" {
+		move = "0000"
+	}
+	e.send("bestmove %s", move)
+}
+
+// setOption handles "setoption name <name> value <value>"
+func (u *uci) setOption(args []string) {
+	if len(args) == 4 && args[0] == "name" && strings.EqualFold(args[1], "Threads") && args[2] == "value" {
+		if threads, err := strconv.Atoi(args[3]); err == nil && threads > 0 {
+			u.e.options.Threads = threads
+		}
+	}
+}
+
+// setPosition handles "position startpos|fen <fen> [moves <moves>...]"
+func (u *uci) setPosition(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	position := newPosition()
+	rest := args[1:]
+	if args[0] == "fen" {
+		end := len(rest)
+		for i, arg := range rest {
+			if arg == "moves" {
+				end = i
+				break
+			}
+		}
+		var err error
+		if position, err = game.NewGameFromFEN(strings.Join(rest[:end], " ")); err != nil {
+			return err
+		}
+		position.TimerActive = false
+		rest = rest[end:]
+	}
+	u.e.position = position
+
+	if len(rest) > 0 && rest[0] == "moves" {
+		for _, move := range rest[1:] {
+			if err := u.e.play(move); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// limits reads the arguments of "go". Without any limit the search runs
+// until "stop".
+func (u *uci) limits(args []string) ai.SearchLimits {
+	var limits ai.SearchLimits
+	var clocks [2]ai.TimeControl
+	timed := false
+	infinite := true
+	for i := 0; i < len(args); i++ {
+		if args[i] == "infinite" {
+			continue
+		}
+		if i+1 >= len(args) {
+			break
+		}
+		value, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			continue
+		}
+		ms := time.Duration(value) * time.Millisecond
+		switch args[i] {
+		case "depth":
+			limits.Depth = value
+		case "nodes":
+			limits.Nodes = value
+		case "movetime":
+			limits.MoveTime = ms
+		case "wtime":
+			clocks[game.WhitePlayer].Remaining, timed = ms, true
+		case "btime":
+			clocks[game.BlackPlayer].Remaining, timed = ms, true
+		case "winc":
+			clocks[game.WhitePlayer].Increment = ms
+		case "binc":
+			clocks[game.BlackPlayer].Increment = ms
+		case "movestogo":
+			clocks[game.WhitePlayer].MovesToGo = value
+			clocks[game.BlackPlayer].MovesToGo = value
+		default:
+			continue
+		}
+		infinite = false
+		i++
+	}
+	if timed {
+		clock := clocks[u.e.position.CurrentTurn]
+		limits.Clock = &clock
+	}
+	if infinite {
+		limits.Depth = maxDepth
+	}
+	return limits
+}
+
+// maxDepth stands for an unlimited search depth
+const maxDepth = 64
@@ -0,0 +1,244 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// xboard speaks the Chess Engine Communication Protocol of WinBoard and
+// XBoard, version 2
+type xboard struct {
+	e *engine
+
+	// force makes the engine only record moves, playing neither side
+	force bool
+	// color is the side the engine plays when not in force mode
+	color int
+	// post sends the search result before each move
+	post bool
+
+	// The time control: mps moves per base time, or the whole game when 0,
+	// plus an increment per move; st is a fixed time per move and sd a
+	// depth limit, both unset when 0
+	mps  int
+	base time.Duration
+	inc  time.Duration
+	st   time.Duration
+	sd   int
+	// clock is the engine's remaining time, as last sent with "time"
+	clock time.Duration
+
+	started time.Time
+}
+
+func newXBoard(e *engine) *xboard {
+	return &xboard{e: e, color: game.BlackPlayer, base: 5 * time.Minute}
+}
+
+func (x *xboard) command(fields []string) bool {
+	e := x.e
+	args := fields[1:]
+	switch fields[0] {
+	case "xboard", "accepted", "rejected", "random", "computer", "name", "rating", "hard", "easy", "otim", "white", "black", "draw", "variant":
+		// Nothing to do
+	case "protover":
+		e.send(`feature myname="%s" ping=1 setboard=1 usermove=1 time=1 draw=0 sigint=0 sigterm=0 reuse=1 analyze=0 colors=0 variants="normal" done=1`, Name)
+	case "ping":
+		if len(args) > 0 {
+			e.send("pong %s", args[0])
+		}
+	case "new":
+		e.stopSearch()
+		e.position = newPosition()
+		x.force = false
+		x.color = game.BlackPlayer
+		x.sd = 0
+	case "force":
+		e.stopSearch()
+		x.force = true
+	case "go":
+		x.force = false
+		x.color = e.position.CurrentTurn
+		x.think()
+	case "playother":
+		x.force = false
+		x.color = 1 - e.position.CurrentTurn
+	case "?":
+		if result, ok := e.stopSearch(); ok {
+			x.searched(result)
+		}
+	case "usermove":
+		if len(args) > 0 {
+			x.userMove(args[0])
+		}
+	case "level":
+		x.setLevel(args)
+	case "st":
+		if len(args) > 0 {
+			if seconds, err := strconv.ParseFloat(args[0], 64); err == nil {
+				x.st = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	case "sd":
+		if len(args) > 0 {
+			x.sd, _ = strconv.Atoi(args[0])
+		}
+	case "time":
+		if len(args) > 0 {
+			if centiseconds, err := strconv.Atoi(args[0]); err == nil {
+				x.clock = time.Duration(centiseconds) * 10 * time.Millisecond
+			}
+		}
+	case "setboard":
+		e.stopSearch()
+		position, err := game.NewGameFromFEN(strings.Join(args, " "))
+		if err != nil {
+			e.send("tellusererror Illegal position: %v", err)
+			break
+		}
+		position.TimerActive = false
+		e.position = position
+	case "undo", "remove":
+		e.stopSearch()
+		plies := 1
+		if fields[0] == "remove" {
+			plies = 2
+		}
+		for i := 0; i < plies; i++ {
+			e.position.UndoLastMove()
+		}
+	case "result":
+		e.stopSearch()
+		x.force = true
+	case "post":
+		x.post = true
+	case "nopost":
+		x.post = false
+	case "quit":
+		return false
+	default:
+		// Without usermove=1 accepted, moves come bare
+		if _, _, _, err := e.position.ParseUCI(fields[0]); err == nil {
+			x.userMove(fields[0])
+		} else {
+			e.send("Error (unknown command): %s", fields[0])
+		}
+	}
+	return true
+}
+
+// userMove plays the opponent's move and answers it when it is the
+// engine's turn
+func (x *xboard) userMove(move string) {
+	e := x.e
+	if e.searching() || e.position.IsGameOver() {
+		e.send("Illegal move: %s", move)
+		return
+	}
+	if err := e.play(move); err != nil {
+		e.send("Illegal move: %s", move)
+		return
+	}
+	if x.announceResult() {
+		return
+	}
+	if !x.force && e.position.CurrentTurn == x.color {
+		x.think()
+	}
+}
+
+// think starts searching for the engine's move
+func (x *xboard) think() {
+	e := x.e
+	if e.position.IsGameOver() {
+		x.announceResult()
+		return
+	}
+	limits := ai.SearchLimits{Depth: x.sd}
+	switch {
+	case x.st > 0:
+		limits.MoveTime = x.st
+	case x.clock > 0:
+		clock := ai.TimeControl{Remaining: x.clock, Increment: x.inc}
+		if x.mps > 0 {
+			played := len(e.position.MoveHistory) / 2
+			clock.MovesToGo = x.mps - played%x.mps
+		}
+		limits.Clock = &clock
+	case x.sd == 0:
+		limits.Clock = &ai.TimeControl{Remaining: x.base, Increment: x.inc, MovesToGo: x.mps}
+	}
+	x.started = time.Now()
+	e.startSearch(limits)
+}
+
+func (x *xboard) searched(result ai.SearchResult) {
+	e := x.e
+	move := e.bestMove(result)
+	if move == "" {
+		x.announceResult()
+		return
+	}
+	if x.post && result.Move.From != result.Move.To {
+		score := result.Score
+		if score >= ai.MateScore-1000 {
+			// XBoard's convention for mate scores
+			score = 100000 + (ai.MateScore-score+1)/2
+		} else if score <= -(ai.MateScore - 1000) {
+			score = -100000 - (ai.MateScore+score+1)/2
+		}
+		e.send("%d %d %d %d %s", result.Depth, score, time.Since(x.started).Milliseconds()/10, result.Nodes, move)
+	}
+	if err := e.play(move); err != nil {
+		e.send("tellusererror %v", err)
+		return
+	}
+	e.send("move %s", move)
+	x.announceResult()
+}
+
+// announceResult reports the end of the game and reports whether it is over
+func (x *xboard) announceResult() bool {
+	position := x.e.position
+	if !position.IsGameOver() {
+		return false
+	}
+	x.e.send("%s {%s}", position.ResultString(), position.GetGameStatus())
+	return true
+}
+
+// setLevel handles "level MPS BASE INC", where BASE is minutes or
+// minutes:seconds and INC is seconds
+func (x *xboard) setLevel(args []string) {
+	if len(args) != 3 {
+		return
+	}
+	mps, err := strconv.Atoi(args[0])
+	if err != nil {
+		return
+	}
+	minutes, seconds, found := strings.Cut(args[1], ":")
+	m, err := strconv.Atoi(minutes)
+	if err != nil {
+		return
+	}
+	base := time.Duration(m) * time.Minute
+	if found {
+		s, err := strconv.Atoi(seconds)
+		if err != nil {
+			return
+		}
+		base += time.Duration(s) * time.Second
+	}
+	inc, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return
+	}
+	x.mps, x.base, x.inc = mps, base, time.Duration(inc*float64(time.Second))
+	x.st = 0
+	x.clock = 0
+}
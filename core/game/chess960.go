@@ -0,0 +1,326 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Castling sides, used to index GameState.CastlingRooks
+const (
+	Queenside = iota
+	Kingside
+)
+
+// StandardChess960ID is the Chess960 number of the standard setup
+const StandardChess960ID = 518
+
+// chess960Knights lists the knight placements among the five squares left
+// after the bishops and queen, in Scharnagl numbering order
+var chess960Knights = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+// Chess960BackRank returns White's back rank pieces for a Chess960 start
+// position numbered 0 to 959, with 518 the standard setup
+func Chess960BackRank(id int) ([8]int, error) {
+	var rank [8]int
+	if id < 0 || id > 959 {
+		return rank, fmt.Errorf("invalid Chess960 position %d: must be 0 to 959", id)
+	}
+
+	n := id
+	rank[2*(n%4)+1] = WhiteBishop
+	n /= 4
+	rank[2*(n%4)] = WhiteBishop
+	n /= 4
+
+	// place puts a piece on the index-th empty square
+	place := func(index, piece int) {
+		for x := range rank {
+			if rank[x] != Empty {
+				continue
+			}
+			if index == 0 {
+				rank[x] = piece
+				return
+			}
+			index--
+		}
+	}
+
+	place(n%6, WhiteQueen)
+	n /= 6
+	knights := chess960Knights[n]
+	// Place the second knight first so the first index is unaffected
+	place(knights[1], WhiteKnight)
+	place(knights[0], WhiteKnight)
+
+	place(0, WhiteRook)
+	place(0, WhiteKing)
+	place(0, WhiteRook)
+	return rank, nil
+}
+
+// RandomChess960ID returns a random Chess960 position number
+func RandomChess960ID() int {
+	return rand.Intn(960)
+}
+
+// Chess960FEN returns the X-FEN of a Chess960 start position
+func Chess960FEN(id int) (string, error) {
+	rank, err := Chess960BackRank(id)
+	if err != nil {
+		return "", err
+	}
+
+	var white, black strings.Builder
+	for _, piece := range rank {
+		white.WriteByte(fenLetter(piece))
+		black.WriteByte(fenLetter(piece + BlackPawn - WhitePawn))
+	}
+	return fmt.Sprintf("%s/pppppppp/8/8/8/8/PPPPPPPP/%s w KQkq - 0 1", black.String(), white.String()), nil
+}
+
+// NewChess960Game creates a Chess960 game from a start position number
+func NewChess960Game(id int) (*GameState, error) {
+	fen, err := Chess960FEN(id)
+	if err != nil {
+		return nil, err
+	}
+	g, err := NewGameFromFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	g.Chess960 = true
+	return g, nil
+}
+
+// backRank returns the home rank of a player
+func backRank(player int) int {
+	if player == WhitePlayer {
+		return 0
+	}
+	return 7
+}
+
+func playerOf(piece int) int {
+	if IsPieceWhite(piece) {
+		return WhitePlayer
+	}
+	return BlackPlayer
+}
+
+func rookOf(player int) int {
+	if player == WhitePlayer {
+		return WhiteRook
+	}
+	return BlackRook
+}
+
+// rookLeft clears the castling right of a rook that moved from or was
+// captured on pos
+func (g *GameState) rookLeft(player int, pos Position) {
+	if pos.Y != backRank(player) {
+		return
+	}
+	flags := g.castlingFlags()
+	for _, side := range []int{Queenside, Kingside} {
+		if pos.X == g.CastlingRooks[player][side] {
+			flags[player*3+1+side] = true
+		}
+	}
+	g.setCastlingFlags(flags)
+}
+
+// castlingAllowed reports whether neither the king nor the rook of a
+// castling side has moved
+func (g *GameState) castlingAllowed(player, side int) bool {
+	if player == WhitePlayer {
+		if g.WhiteKingMoved {
+			return false
+		}
+		if side == Queenside {
+			return !g.WhiteRookAMoved
+		}
+		return !g.WhiteRookHMoved
+	}
+	if g.BlackKingMoved {
+		return false
+	}
+	if side == Queenside {
+		return !g.BlackRookAMoved
+	}
+	return !g.BlackRookHMoved
+}
+
+// castlingTarget returns the square the king is moved to to castle: two
+// files over in standard chess, and onto its own rook in Chess960
+func (g *GameState) castlingTarget(player, side int, kingFrom Position) Position {
+	if g.Chess960 {
+		return Position{X: g.CastlingRooks[player][side], Y: kingFrom.Y}
+	}
+	if side == Queenside {
+		return Position{X: kingFrom.X - 2, Y: kingFrom.Y}
+	}
+	return Position{X: kingFrom.X + 2, Y: kingFrom.Y}
+}
+
+// castlingSide reports whether the king move from→to castles, and on which
+// side
+func (g *GameState) castlingSide(from, to Position) (int, bool) {
+	piece := g.Board[from.Y][from.X]
+	if piece != WhiteKing && piece != BlackKing {
+		return 0, false
+	}
+	player := playerOf(piece)
+	if from.Y != backRank(player) || to.Y != from.Y {
+		return 0, false
+	}
+
+	for _, side := range []int{Queenside, Kingside} {
+		if g.castlingAllowed(player, side) && g.castlingTarget(player, side, from) == to {
+			return side, true
+		}
+	}
+	return 0, false
+}
+
+// castlingMoves returns the castling moves of the king on pos. Every square
+// between the king and rook and their destinations must be empty, and the
+// king may not be in check or pass through or land on an attacked square.
+func castlingMoves(board [8][8]int, pos Position, g *GameState) []Position {
+	piece := board[pos.Y][pos.X]
+	player := playerOf(piece)
+	rank := backRank(player)
+	if pos.Y != rank {
+		return nil
+	}
+
+	rook := rookOf(player)
+
+	var moves []Position
+	for _, side := range []int{Queenside, Kingside} {
+		rookFile := g.CastlingRooks[player][side]
+		if !g.castlingAllowed(player, side) || board[rank][rookFile] != rook {
+			continue
+		}
+		if side == Queenside && rookFile > pos.X || side == Kingside && rookFile < pos.X {
+			continue
+		}
+
+		kingTo, rookTo := 2, 3
+		if side == Kingside {
+			kingTo, rookTo = 6, 5
+		}
+
+		low := minInt(minInt(pos.X, kingTo), minInt(rookFile, rookTo))
+		high := maxInt(maxInt(pos.X, kingTo), maxInt(rookFile, rookTo))
+		clear := true
+		for x := low; x <= high; x++ {
+			if x != pos.X && x != rookFile && board[rank][x] != Empty {
+				clear = false
+				break
+			}
+		}
+		if !clear || !kingPathSafe(board, player, rank, pos.X, rookFile, kingTo) {
+			continue
+		}
+
+		moves = append(moves, g.castlingTarget(player, side, pos))
+	}
+	return moves
+}
+
+// kingPathSafe reports whether the king is not attacked on any square from
+// its start to its castling destination, with the castling rook lifted
+func kingPathSafe(board [8][8]int, player, rank, kingFrom, rookFile, kingTo int) bool {
+	king := board[rank][kingFrom]
+	step := 1
+	if kingTo < kingFrom {
+		step = -1
+	}
+	for x := kingFrom; ; x += step {
+		temp := board
+		temp[rank][kingFrom] = Empty
+		temp[rank][rookFile] = Empty
+		temp[rank][x] = king
+		if IsInCheck(temp, player) {
+			return false
+		}
+		if x == kingTo {
+			return true
+		}
+	}
+}
+
+// castle moves the king and rook of a castling move to their destinations
+func (g *GameState) castle(from Position, side int) {
+	player := playerOf(g.Board[from.Y][from.X])
+	king, rook := g.Board[from.Y][from.X], WhiteRook
+	if player == BlackPlayer {
+		rook = BlackRook
+	}
+
+	kingTo, rookTo := 2, 3
+	if side == Kingside {
+		kingTo, rookTo = 6, 5
+	}
+
+	g.Board[from.Y][from.X] = Empty
+	g.Board[from.Y][g.CastlingRooks[player][side]] = Empty
+	g.Board[from.Y][kingTo] = king
+	g.Board[from.Y][rookTo] = rook
+}
+
+// uncastle reverses a castling move
+func (g *GameState) uncastle(move Move) {
+	player := playerOf(move.Piece)
+	rook := WhiteRook
+	if player == BlackPlayer {
+		rook = BlackRook
+	}
+
+	side := Queenside
+	if move.To.X > move.From.X {
+		side = Kingside
+	}
+	kingTo, rookTo := 2, 3
+	if side == Kingside {
+		kingTo, rookTo = 6, 5
+	}
+
+	rank := move.From.Y
+	g.Board[rank][kingTo] = Empty
+	g.Board[rank][rookTo] = Empty
+	g.Board[rank][g.CastlingRooks[player][side]] = rook
+	g.Board[rank][move.From.X] = move.Piece
+}
+
+// castlingFlags returns the king and rook moved flags, in the order white
+// king, white queenside rook, white kingside rook, then the same for Black
+func (g *GameState) castlingFlags() [6]bool {
+	return [6]bool{
+		g.WhiteKingMoved, g.WhiteRookAMoved, g.WhiteRookHMoved,
+		g.BlackKingMoved, g.BlackRookAMoved, g.BlackRookHMoved,
+	}
+}
+
+func (g *GameState) setCastlingFlags(flags [6]bool) {
+	g.WhiteKingMoved, g.WhiteRookAMoved, g.WhiteRookHMoved = flags[0], flags[1], flags[2]
+	g.BlackKingMoved, g.BlackRookAMoved, g.BlackRookHMoved = flags[3], flags[4], flags[5]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,231 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dropper is implemented by variants in which captured pieces go to the
+// capturer's pocket and can be dropped back on the board as a move
+type Dropper interface {
+	Variant
+	// LegalDrops returns the squares the piece, in the color of the side
+	// to move, may be dropped on
+	LegalDrops(g *GameState, piece int) []Position
+}
+
+// Crazyhouse follows the standard rules, except that captured pieces join
+// the capturer's army and can be dropped on any empty square instead of
+// moving. Pawns cannot be dropped on the first or last rank, and promoted
+// pieces return to the pocket as pawns.
+type Crazyhouse struct{ standardRules }
+
+func (Crazyhouse) Name() string { return "Crazyhouse" }
+
+func (Crazyhouse) StartFEN() string {
+	return "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1"
+}
+
+func (Crazyhouse) LegalDrops(g *GameState, piece int) []Position {
+	player := playerOf(piece)
+	// A drop only adds a piece, so it can leave the king in check only
+	// when the king is already in check
+	inCheck := IsInCheck(g.Board, player)
+
+	var squares []Position
+	for y := 0; y < 8; y++ {
+		if (piece == WhitePawn || piece == BlackPawn) && (y == 0 || y == 7) {
+			continue
+		}
+		for x := 0; x < 8; x++ {
+			if g.Board[y][x] != Empty {
+				continue
+			}
+			if inCheck {
+				board := g.Board
+				board[y][x] = piece
+				if IsInCheck(board, player) {
+					continue
+				}
+			}
+			squares = append(squares, Position{X: x, Y: y})
+		}
+	}
+	return squares
+}
+
+// Drops reports whether the game's variant allows dropping pieces
+func (g *GameState) Drops() bool {
+	_, ok := g.Variant.(Dropper)
+	return ok
+}
+
+// PocketPieces returns the pieces, in the player's color, the player can
+// drop, each listed once
+func (g *GameState) PocketPieces(player int) []int {
+	if !g.Drops() {
+		return nil
+	}
+	var pieces []int
+	for i, count := range g.Pockets[player] {
+		if count > 0 {
+			pieces = append(pieces, pocketPiece(i, player))
+		}
+	}
+	return pieces
+}
+
+// PocketCount returns how many of the piece the player holds
+func (g *GameState) PocketCount(player, piece int) int {
+	return g.Pockets[player][pocketIndex(piece)]
+}
+
+// GetPossibleDrops returns the squares the piece may be dropped on. The
+// piece may be given in either color and is dropped by the side to move.
+func (g *GameState) GetPossibleDrops(piece int) []Position {
+	dropper, ok := g.Variant.(Dropper)
+	if !ok || piece == Empty || piece == WhiteKing || piece == BlackKing {
+		return nil
+	}
+	piece = pocketPiece(pocketIndex(piece), g.CurrentTurn)
+	if g.Pockets[g.CurrentTurn][pocketIndex(piece)] == 0 {
+		return nil
+	}
+	return dropper.LegalDrops(g, piece)
+}
+
+// MakeDrop drops a piece from the side to move's pocket on to. The piece
+// may be given in either color.
+func (g *GameState) MakeDrop(piece int, to Position) MoveResult {
+	if !IsValidBoardPosition(to) {
+		return InvalidMove
+	}
+
+	legal := false
+	for _, square := range g.GetPossibleDrops(piece) {
+		if square == to {
+			legal = true
+			break
+		}
+	}
+	if !legal {
+		return InvalidMove
+	}
+
+	piece = pocketPiece(pocketIndex(piece), g.CurrentTurn)
+	move := Move{
+		From:            to,
+		To:              to,
+		Piece:           piece,
+		Promotion:       piece,
+		Drop:            true,
+		HalfmoveClock:   g.HalfmoveClock,
+		CastlingFlags:   g.castlingFlags(),
+		PromotedSquares: g.PromotedSquares,
+	}
+
+	g.Board[to.Y][to.X] = piece
+	g.Pockets[g.CurrentTurn][pocketIndex(piece)]--
+	return g.finishMove(move)
+}
+
+// pocketIndex returns the pocket slot of a piece of either color
+func pocketIndex(piece int) int {
+	if IsPieceBlack(piece) {
+		piece -= BlackPawn - WhitePawn
+	}
+	return piece - WhitePawn
+}
+
+// pocketPiece returns the piece of a pocket slot in the player's color
+func pocketPiece(index, player int) int {
+	if player == BlackPlayer {
+		return BlackPawn + index
+	}
+	return WhitePawn + index
+}
+
+// capturedForPocket returns the piece a capture adds to the pocket: the
+// captured piece, or a pawn when it was promoted
+func capturedForPocket(captured int, promoted bool) int {
+	if promoted {
+		return WhitePawn
+	}
+	return captured
+}
+
+// pocketCapture adds a captured piece to the pocket of the side to move
+func (g *GameState) pocketCapture(captured int, promoted bool) {
+	g.Pockets[g.CurrentTurn][pocketIndex(capturedForPocket(captured, promoted))]++
+}
+
+func isPromoted(squares uint64, pos Position) bool {
+	return squares&(1<<uint(pos.Y*8+pos.X)) != 0
+}
+
+// movePromoted keeps PromotedSquares up to date after a move from→to
+func (g *GameState) movePromoted(from, to Position, promotes bool) {
+	fromBit, toBit := uint64(1)<<uint(from.Y*8+from.X), uint64(1)<<uint(to.Y*8+to.X)
+	wasPromoted := g.PromotedSquares&fromBit != 0
+	g.PromotedSquares &^= fromBit | toBit
+	if wasPromoted || promotes {
+		g.PromotedSquares |= toBit
+	}
+}
+
+// formatPocket returns the Crazyhouse FEN pocket, e.g. "[QNpp]"
+func (g *GameState) formatPocket() string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for _, player := range []int{WhitePlayer, BlackPlayer} {
+		for index := len(g.Pockets[player]) - 1; index >= 0; index-- {
+			for i := 0; i < g.Pockets[player][index]; i++ {
+				sb.WriteByte(fenLetter(pocketPiece(index, player)))
+			}
+		}
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// parsePocket reads the pieces of a Crazyhouse FEN pocket
+func parsePocket(text string) ([2][5]int, error) {
+	var pockets [2][5]int
+	for i := 0; i < len(text); i++ {
+		piece, ok := fenPieces[text[i]]
+		if !ok || piece == WhiteKing || piece == BlackKing {
+			return pockets, fmt.Errorf("bad pocket piece %q", text[i])
+		}
+		pockets[playerOf(piece)][pocketIndex(piece)]++
+	}
+	return pockets, nil
+}
+
+// DropNotation returns the notation of a drop, e.g. "N@f3". SAN leaves out
+// the letter of a pawn; UCI always writes it.
+func DropNotation(piece int, to Position, pawnLetter bool) string {
+	letter := pieceLetters[piece]
+	if piece == WhitePawn || piece == BlackPawn {
+		letter = ""
+		if pawnLetter {
+			letter = "P"
+		}
+	}
+	return letter + "@" + SquareName(to)
+}
+
+// parseDrop reads a drop such as "N@f3", "P@e4" or "@e4" for the side to
+// move
+func (g *GameState) parseDrop(text string) (int, Position, error) {
+	at := strings.IndexByte(text, '@')
+	letter := strings.ToUpper(text[:at])
+	if letter == "" {
+		letter = "P"
+	}
+	piece := pieceFromLetter(letter, g.CurrentTurn)
+	to, ok := ParseSquare(text[at+1:])
+	if piece == Empty || !ok {
+		return Empty, Position{}, fmt.Errorf("invalid drop %q", text)
+	}
+	return piece, to, nil
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ecoTable lists openings as tab-separated ECO code, name and moves in SAN
+//
+//go:embed eco.tsv
+var ecoTable string
+
+// Opening is a named opening line of the ECO classification
+type Opening struct {
+	ECO   string
+	Name  string
+	Moves string
+}
+
+var (
+	ecoOnce      sync.Once
+	ecoPositions map[string]Opening
+	ecoErr       error
+	// ecoDepth is the number of half-moves of the longest line
+	ecoDepth int
+)
+
+// loadOpenings replays every line of the table and indexes the openings by
+// the position they reach, so transpositions are recognized. The first line
+// reaching a position names it.
+func loadOpenings() (map[string]Opening, error) {
+	ecoOnce.Do(func() {
+		ecoPositions = map[string]Opening{}
+		lines := strings.Split(strings.TrimSpace(ecoTable), "\n")
+		for i, line := range lines[1:] {
+			fields := strings.Split(line, "\t")
+			if len(fields) != 3 {
+				ecoErr = fmt.Errorf("opening table line %d: want 3 fields, got %d", i+2, len(fields))
+				return
+			}
+			opening := Opening{ECO: fields[0], Name: fields[1], Moves: fields[2]}
+
+			g := NewGame()
+			g.TimerActive = false
+			plies := 0
+			for _, san := range strings.Fields(opening.Moves) {
+				if strings.HasSuffix(san, ".") {
+					continue
+				}
+				from, to, promotion, err := g.ParseSAN(san)
+				if err != nil {
+					ecoErr = fmt.Errorf("opening table line %d (%s): %v", i+2, opening.Name, err)
+					return
+				}
+				g.MakeMoveWithPromotion(from, to, promotion)
+				plies++
+			}
+			ecoDepth = max(ecoDepth, plies)
+			if _, ok := ecoPositions[g.PositionKey()]; !ok {
+				ecoPositions[g.PositionKey()] = opening
+			}
+		}
+	})
+	return ecoPositions, ecoErr
+}
+
+// Openings returns the number of positions in the opening table, or an
+// error if the table cannot be read
+func Openings() (int, error) {
+	positions, err := loadOpenings()
+	return len(positions), err
+}
+
+// ClassifyOpening names the opening of the game: the deepest position of
+// the game found in the opening table. Only standard chess from the usual
+// starting position is classified.
+func (g *GameState) ClassifyOpening() (Opening, bool) {
+	if g.Variant != nil || g.Chess960 || (g.StartFEN != "" && g.StartFEN != StandardFEN) {
+		return Opening{}, false
+	}
+	positions, err := loadOpenings()
+	if err != nil {
+		return Opening{}, false
+	}
+
+	opening, found := Opening{}, false
+	replay := g.PositionAt(0)
+	for ply, move := range g.MoveHistory {
+		if ply >= ecoDepth {
+			break
+		}
+		replay.MakeMoveWithPromotion(move.From, move.To, move.Promotion)
+		if named, ok := positions[replay.PositionKey()]; ok {
+			opening, found = named, true
+		}
+	}
+	return opening, found
+}
@@ -0,0 +1,113 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EPD is an Extended Position Description record: a position without move
+// counters followed by operations such as bm (best moves), am (moves to
+// avoid) and id
+type EPD struct {
+	// FEN is the position with the halfmove clock and move number taken from
+	// the hmvc and fmvn operations, or 0 and 1
+	FEN string
+	// Operations maps each opcode to its operand with quotes removed
+	Operations map[string]string
+}
+
+// ParseEPD parses a single EPD line
+func ParseEPD(line string) (*EPD, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("invalid EPD %q: expected at least 4 fields", line)
+	}
+
+	epd := &EPD{Operations: map[string]string{}}
+	rest := strings.TrimSpace(line)
+	for i := 0; i < 4; i++ {
+		rest = strings.TrimSpace(rest[len(fields[i]):])
+	}
+	for _, operation := range splitOperations(rest) {
+		opcode, operand, _ := strings.Cut(operation, " ")
+		epd.Operations[opcode] = strings.Trim(strings.TrimSpace(operand), "\"")
+	}
+
+	halfmove, fullmove := 0, 1
+	if value, err := strconv.Atoi(epd.Operations["hmvc"]); err == nil {
+		halfmove = value
+	}
+	if value, err := strconv.Atoi(epd.Operations["fmvn"]); err == nil {
+		fullmove = value
+	}
+	epd.FEN = fmt.Sprintf("%s %d %d", strings.Join(fields[:4], " "), halfmove, fullmove)
+
+	if _, err := NewGameFromFEN(epd.FEN); err != nil {
+		return nil, err
+	}
+	return epd, nil
+}
+
+// ReadEPD reads EPD records, one per line, skipping blank lines and lines
+// starting with #
+func ReadEPD(r io.Reader) ([]*EPD, error) {
+	var records []*EPD
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		epd, err := ParseEPD(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, epd)
+	}
+	return records, scanner.Err()
+}
+
+// Game returns the position of the record
+func (e *EPD) Game() (*GameState, error) {
+	return NewGameFromFEN(e.FEN)
+}
+
+// ID returns the id operation
+func (e *EPD) ID() string {
+	return e.Operations["id"]
+}
+
+// Moves returns the moves of a move list operation such as bm, am or pv,
+// in the SAN given in the record
+func (e *EPD) Moves(opcode string) []string {
+	return strings.Fields(e.Operations[opcode])
+}
+
+// splitOperations splits the operations part of an EPD line at semicolons
+// outside quoted strings
+func splitOperations(text string) []string {
+	var operations []string
+	var current strings.Builder
+	quoted := false
+	for _, c := range text {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			current.WriteRune(c)
+		case c == ';' && !quoted:
+			if operation := strings.TrimSpace(current.String()); operation != "" {
+				operations = append(operations, operation)
+			}
+			current.Reset()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	if operation := strings.TrimSpace(current.String()); operation != "" {
+		operations = append(operations, operation)
+	}
+	return operations
+}
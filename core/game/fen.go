@@ -0,0 +1,357 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StandardFEN is the FEN of the standard starting position
+const StandardFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// fenPieces maps FEN piece letters to pieces
+var fenPieces = map[byte]int{
+	'P': WhitePawn, 'N': WhiteKnight, 'B': WhiteBishop, 'R': WhiteRook, 'Q': WhiteQueen, 'K': WhiteKing,
+	'p': BlackPawn, 'n': BlackKnight, 'b': BlackBishop, 'r': BlackRook, 'q': BlackQueen, 'k': BlackKing,
+}
+
+// NewGameFromFEN creates a game starting from the position described by fen.
+// The castling field may use X-FEN or Shredder-FEN rook files, and a
+// position whose castling king or rooks are off their standard squares is
+// played as Chess960. En passant targets are not supported and are ignored.
+func NewGameFromFEN(fen string) (*GameState, error) {
+	fields := strings.Fields(fen)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("invalid FEN %q: expected at least 4 fields", fen)
+	}
+
+	g := NewGame()
+	g.StartFEN = fen
+
+	placement := fields[0]
+	if open := strings.IndexByte(placement, '['); open >= 0 && strings.HasSuffix(placement, "]") {
+		pockets, err := parsePocket(placement[open+1 : len(placement)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid FEN %q: %v", fen, err)
+		}
+		g.Pockets = pockets
+		placement = placement[:open]
+	}
+
+	board, promoted, err := parseFENBoard(placement)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FEN %q: %v", fen, err)
+	}
+	g.Board = board
+	g.PromotedSquares = promoted
+
+	switch fields[1] {
+	case "w":
+		g.CurrentTurn = WhitePlayer
+	case "b":
+		g.CurrentTurn = BlackPlayer
+	default:
+		return nil, fmt.Errorf("invalid FEN %q: bad side to move %q", fen, fields[1])
+	}
+
+	if err := g.parseCastlingRights(fields[2]); err != nil {
+		return nil, fmt.Errorf("invalid FEN %q: %v", fen, err)
+	}
+
+	if len(fields) > 4 {
+		clock, err := strconv.Atoi(fields[4])
+		if err != nil || clock < 0 {
+			return nil, fmt.Errorf("invalid FEN %q: bad halfmove clock %q", fen, fields[4])
+		}
+		g.HalfmoveClock = clock
+	}
+
+	g.GameStatus, g.Termination = g.setUpStatus()
+
+	return g, nil
+}
+
+// parseFENBoard reads the piece placement field. Pieces marked with a
+// trailing "~" are promoted, as in Crazyhouse FEN.
+func parseFENBoard(placement string) ([8][8]int, uint64, error) {
+	var board [8][8]int
+	var promoted uint64
+
+	ranks := strings.Split(placement, "/")
+	if len(ranks) != 8 {
+		return board, promoted, fmt.Errorf("expected 8 ranks, got %d", len(ranks))
+	}
+
+	for i, rank := range ranks {
+		y := 7 - i
+		x := 0
+		for j := 0; j < len(rank); j++ {
+			c := rank[j]
+			if c >= '1' && c <= '8' {
+				x += int(c - '0')
+				continue
+			}
+			if c == '~' && x > 0 {
+				promoted |= 1 << uint(y*8+x-1)
+				continue
+			}
+			piece, ok := fenPieces[c]
+			if !ok {
+				return board, promoted, fmt.Errorf("unknown piece %q", c)
+			}
+			if x > 7 {
+				return board, promoted, fmt.Errorf("rank %d is too long", y+1)
+			}
+			board[y][x] = piece
+			x++
+		}
+		if x != 8 {
+			return board, promoted, fmt.Errorf("rank %d has %d squares", y+1, x)
+		}
+	}
+
+	return board, promoted, nil
+}
+
+// FEN returns the Forsyth-Edwards Notation of the current position
+func (g *GameState) FEN() string {
+	_, startMove := g.startMoveNumber()
+	fullmove := startMove + (g.startPly()+len(g.MoveHistory))/2
+
+	return fmt.Sprintf("%s %d %d", g.PositionKey(), g.HalfmoveClock, fullmove)
+}
+
+// PositionKey returns the first four FEN fields, which identify a position
+// for repetition detection regardless of move counters. Crazyhouse games add
+// their pockets and promoted pieces.
+func (g *GameState) PositionKey() string {
+	var sb strings.Builder
+	drops := g.Drops()
+
+	for y := 7; y >= 0; y-- {
+		empty := 0
+		for x := 0; x < 8; x++ {
+			piece := g.Board[y][x]
+			if piece == Empty {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			sb.WriteByte(fenLetter(piece))
+			if drops && isPromoted(g.PromotedSquares, Position{X: x, Y: y}) {
+				sb.WriteByte('~')
+			}
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+		if y > 0 {
+			sb.WriteByte('/')
+		}
+	}
+	if drops {
+		sb.WriteString(g.formatPocket())
+	}
+
+	if g.CurrentTurn == WhitePlayer {
+		sb.WriteString(" w ")
+	} else {
+		sb.WriteString(" b ")
+	}
+
+	sb.WriteString(g.castlingRights())
+	sb.WriteString(" -")
+
+	return sb.String()
+}
+
+// ShredderFEN returns the FEN with castling rights given as rook files, as
+// used by Shredder-FEN for Chess960
+func (g *GameState) ShredderFEN() string {
+	fields := strings.Fields(g.FEN())
+	fields[2] = g.formatCastlingRights(true)
+	return strings.Join(fields, " ")
+}
+
+// castlingRights returns the FEN castling field, in X-FEN for Chess960
+func (g *GameState) castlingRights() string {
+	return g.formatCastlingRights(false)
+}
+
+// formatCastlingRights writes the castling field. A right requires the
+// king and rook to be unmoved and still on their starting squares. X-FEN
+// uses K and Q unless another rook stands between the castling rook and
+// the edge of the board; Shredder-FEN always gives the rook's file.
+func (g *GameState) formatCastlingRights(shredder bool) string {
+	rights := ""
+	for _, player := range []int{WhitePlayer, BlackPlayer} {
+		king, ok := g.castlingKingFile(player)
+		if !ok {
+			continue
+		}
+		for _, side := range []int{Kingside, Queenside} {
+			rookFile := g.CastlingRooks[player][side]
+			if !g.castlingAllowed(player, side) || g.Board[backRank(player)][rookFile] != rookOf(player) {
+				continue
+			}
+
+			letter := byte('K')
+			if side == Queenside {
+				letter = 'Q'
+			}
+			if shredder || g.outermostRook(player, side, king) != rookFile {
+				letter = byte('A' + rookFile)
+			}
+			if player == BlackPlayer {
+				letter += 'a' - 'A'
+			}
+			rights += string(letter)
+		}
+	}
+	if rights == "" {
+		return "-"
+	}
+	return rights
+}
+
+// parseCastlingRights reads a FEN, X-FEN or Shredder-FEN castling field
+func (g *GameState) parseCastlingRights(field string) error {
+	g.WhiteKingMoved, g.BlackKingMoved = true, true
+	g.WhiteRookAMoved, g.WhiteRookHMoved = true, true
+	g.BlackRookAMoved, g.BlackRookHMoved = true, true
+	if field == "-" {
+		return nil
+	}
+
+	for _, c := range field {
+		player := WhitePlayer
+		letter := c
+		if c >= 'a' && c <= 'z' {
+			player = BlackPlayer
+			letter = c - 'a' + 'A'
+		}
+
+		king, ok := g.backRankKing(player)
+		if !ok {
+			return fmt.Errorf("castling right %q without a king on the back rank", c)
+		}
+
+		var side, rookFile int
+		switch {
+		case letter == 'K':
+			side = Kingside
+			rookFile = g.outermostRook(player, side, king)
+		case letter == 'Q':
+			side = Queenside
+			rookFile = g.outermostRook(player, side, king)
+		case letter >= 'A' && letter <= 'H':
+			rookFile = int(letter - 'A')
+			side = Kingside
+			if rookFile < king {
+				side = Queenside
+			}
+		default:
+			return fmt.Errorf("bad castling rights %q", field)
+		}
+		if rookFile < 0 || rookFile == king {
+			return fmt.Errorf("castling right %q without a rook", c)
+		}
+
+		g.CastlingRooks[player][side] = rookFile
+		flags := g.castlingFlags()
+		flags[player*3] = false
+		flags[player*3+1+side] = false
+		g.setCastlingFlags(flags)
+
+		if king != 4 || (side == Queenside && rookFile != 0) || (side == Kingside && rookFile != 7) {
+			g.Chess960 = true
+		}
+	}
+	return nil
+}
+
+// castlingKingFile returns the file of the player's king when it is on its
+// back rank and has not moved
+func (g *GameState) castlingKingFile(player int) (int, bool) {
+	if player == WhitePlayer && g.WhiteKingMoved || player == BlackPlayer && g.BlackKingMoved {
+		return 0, false
+	}
+	return g.backRankKing(player)
+}
+
+// backRankKing returns the file of the player's king when it is on its back
+// rank
+func (g *GameState) backRankKing(player int) (int, bool) {
+	king := WhiteKing
+	if player == BlackPlayer {
+		king = BlackKing
+	}
+	rank := backRank(player)
+	for x := 0; x < 8; x++ {
+		if g.Board[rank][x] == king {
+			return x, true
+		}
+	}
+	return 0, false
+}
+
+// outermostRook returns the file of the player's rook nearest the edge on
+// one side of the king, or -1 if there is none
+func (g *GameState) outermostRook(player, side, king int) int {
+	rank := backRank(player)
+	if side == Kingside {
+		for x := 7; x > king; x-- {
+			if g.Board[rank][x] == rookOf(player) {
+				return x
+			}
+		}
+	} else {
+		for x := 0; x < king; x++ {
+			if g.Board[rank][x] == rookOf(player) {
+				return x
+			}
+		}
+	}
+	return -1
+}
+
+func fenLetter(piece int) byte {
+	for letter, p := range fenPieces {
+		if p == piece {
+			return letter
+		}
+	}
+	return '?'
+}
+
+// startMoveNumber returns who moved first and the full move number of the
+// game's starting position
+func (g *GameState) startMoveNumber() (int, int) {
+	return fenStart(g.StartFEN)
+}
+
+// startPly is 1 when Black moved first, so that move numbers line up
+func (g *GameState) startPly() int {
+	if side, _ := g.startMoveNumber(); side == BlackPlayer {
+		return 1
+	}
+	return 0
+}
+
+// newGameFromStart returns a fresh game at the starting position of g
+func (g *GameState) newGameFromStart() *GameState {
+	start := NewGame()
+	if g.StartFEN != "" {
+		if position, err := NewGameFromFEN(g.StartFEN); err == nil {
+			start = position
+			start.LastMoveTime = time.Now()
+		}
+	}
+	start.Chess960 = g.Chess960
+	start.SetVariant(g.Variant)
+	return start
+}
@@ -3,9 +3,21 @@ package game
 import "time"
 
 func (g *GameState) MakeMove(from, to Position) MoveResult {
+	return g.MakeMoveWithPromotion(from, to, Empty)
+}
+
+// MakeMoveWithPromotion makes a move and, if a pawn reaches the last rank,
+// promotes it to the given piece. Promotion may be given in either color and
+// defaults to a queen when Empty. A move with from equal to to drops the
+// promotion piece from the pocket (see MakeDrop). A move that ends a variant
+// game returns Checkmate for a win and Draw for a draw.
+func (g *GameState) MakeMoveWithPromotion(from, to Position, promotion int) MoveResult {
 	if !IsValidBoardPosition(from) || !IsValidBoardPosition(to) {
 		return InvalidMove
 	}
+	if from == to {
+		return g.MakeDrop(promotion, to)
+	}
 
 	piece := g.Board[from.Y][from.X]
 
@@ -20,7 +32,9 @@ func (g *GameState) MakeMove(from, to Position) MoveResult {
 
 	var validMoves []Position
 
-	if piece == WhiteKing || piece == BlackKing {
+	if g.Variant != nil {
+		validMoves = g.GetPossibleMoves(from)
+	} else if piece == WhiteKing || piece == BlackKing {
 		validMoves = GetPieceMovesWithGameState(g.Board, from, g)
 	} else {
 		validMoves = GetPieceMoves(g.Board, from)
@@ -39,73 +53,100 @@ func (g *GameState) MakeMove(from, to Position) MoveResult {
 	}
 
 	capturedPiece := g.Board[to.Y][to.X]
+	castlingFlags := g.castlingFlags()
+	promotedSquares := g.PromotedSquares
 
-	isCastling := false
-	if piece == WhiteKing || piece == BlackKing {
-		if to.X-from.X == 2 {
-			isCastling = true
-			if piece == WhiteKing {
-				g.Board[0][5] = WhiteRook
-				g.Board[0][7] = Empty
-			} else {
-				g.Board[7][5] = BlackRook
-				g.Board[7][7] = Empty
-			}
-		} else if from.X-to.X == 2 {
-			isCastling = true
-			if piece == WhiteKing {
-				g.Board[0][3] = WhiteRook
-				g.Board[0][0] = Empty
-			} else {
-				g.Board[7][3] = BlackRook
-				g.Board[7][0] = Empty
-			}
-		}
+	castlingSide, isCastling := g.castlingSide(from, to)
+	if isCastling {
+		// In Chess960 the target square holds the king's own rook
+		capturedPiece = Empty
+		g.castle(from, castlingSide)
+	} else {
+		g.Board[to.Y][to.X] = piece
+		g.Board[from.Y][from.X] = Empty
+	}
+
+	promotedTo := Empty
+	if (piece == WhitePawn && to.Y == 7) || (piece == BlackPawn && to.Y == 0) {
+		promotedTo = promotionPiece(piece, promotion)
+		g.Board[to.Y][to.X] = promotedTo
 	}
 
-	g.Board[to.Y][to.X] = piece
-	g.Board[from.Y][from.X] = Empty
+	if g.Drops() {
+		if capturedPiece != Empty {
+			g.pocketCapture(capturedPiece, isPromoted(promotedSquares, to))
+		}
+		g.movePromoted(from, to, promotedTo != Empty)
+	}
 
 	if piece == WhiteKing {
 		g.WhiteKingMoved = true
 	} else if piece == BlackKing {
 		g.BlackKingMoved = true
-	} else if piece == WhiteRook {
-		if from.X == 0 && from.Y == 0 {
-			g.WhiteRookAMoved = true
-		} else if from.X == 7 && from.Y == 0 {
-			g.WhiteRookHMoved = true
-		}
-	} else if piece == BlackRook {
-		if from.X == 0 && from.Y == 7 {
-			g.BlackRookAMoved = true
-		} else if from.X == 7 && from.Y == 7 {
-			g.BlackRookHMoved = true
+	} else if piece == WhiteRook || piece == BlackRook {
+		g.rookLeft(playerOf(piece), from)
+	}
+	if capturedPiece == WhiteRook || capturedPiece == BlackRook {
+		g.rookLeft(playerOf(capturedPiece), to)
+	}
+
+	var exploded []PlacedPiece
+	if g.Variant != nil && capturedPiece != Empty {
+		exploded = g.Variant.AfterCapture(g, to)
+		for _, removed := range exploded {
+			if removed.Piece == WhiteRook || removed.Piece == BlackRook {
+				g.rookLeft(playerOf(removed.Piece), removed.Position)
+			}
 		}
 	}
 
+	return g.finishMove(Move{
+		From:            from,
+		To:              to,
+		Piece:           piece,
+		Captured:        capturedPiece,
+		Promotion:       promotedTo,
+		Castling:        isCastling,
+		HalfmoveClock:   g.HalfmoveClock,
+		CastlingFlags:   castlingFlags,
+		Exploded:        exploded,
+		PromotedSquares: promotedSquares,
+	})
+}
+
+// finishMove passes the turn after a move has been made on the board,
+// records the move and decides whether the game is over
+func (g *GameState) finishMove(move Move) MoveResult {
 	g.CurrentTurn = 1 - g.CurrentTurn
 
-	isCheck := IsInCheck(g.Board, g.CurrentTurn)
+	isCheck := g.InCheck(g.CurrentTurn)
 	isCheckmate := false
 
-	if isCheck {
+	if isCheck && g.Variant == nil {
 		isCheckmate = IsCheckmate(g.Board, g.CurrentTurn)
 	}
+	move.Check = isCheck
+	move.Checkmate = isCheckmate
 
-	move := Move{
-		From:      from,
-		To:        to,
-		Piece:     piece,
-		Captured:  capturedPiece,
-		Check:     isCheck,
-		Checkmate: isCheckmate,
-		Castling:  isCastling,
+	if move.Piece == WhitePawn || move.Piece == BlackPawn || move.Captured != Empty {
+		g.HalfmoveClock = 0
+	} else {
+		g.HalfmoveClock++
 	}
 
 	g.MoveHistory = append(g.MoveHistory, move)
 
-	if isCheckmate {
+	if g.Variant != nil {
+		if status, termination := g.Variant.Outcome(g); status != InProgress {
+			g.GameStatus = status
+			g.Termination = termination
+			if status == GameDraw {
+				return Draw
+			}
+			g.MoveHistory[len(g.MoveHistory)-1].Checkmate = isCheck && termination == terminationCheckmate
+			return Checkmate
+		}
+	} else if isCheckmate {
 		if g.CurrentTurn == WhitePlayer {
 			g.GameStatus = BlackWon
 		} else {
@@ -114,7 +155,7 @@ func (g *GameState) MakeMove(from, to Position) MoveResult {
 		return Checkmate
 	}
 
-	isStalemate := IsStalemate(g.Board, g.CurrentTurn)
+	isStalemate := g.Variant == nil && IsStalemate(g.Board, g.CurrentTurn)
 
 	if isStalemate {
 		g.GameStatus = GameDraw
@@ -136,13 +177,31 @@ func (g *GameState) MakeMove(from, to Position) MoveResult {
 		return Check
 	}
 
-	if isCastling {
+	if move.Castling {
 		return Castling
 	}
 
 	return ValidMove
 }
 
+// promotionPiece returns the piece a pawn promotes to, in the pawn's color
+func promotionPiece(pawn, promotion int) int {
+	switch promotion {
+	case WhiteKnight, WhiteBishop, WhiteRook, WhiteQueen,
+		BlackKnight, BlackBishop, BlackRook, BlackQueen:
+	default:
+		promotion = WhiteQueen
+	}
+
+	if IsPieceWhite(pawn) && IsPieceBlack(promotion) {
+		return promotion - (BlackPawn - WhitePawn)
+	}
+	if IsPieceBlack(pawn) && IsPieceWhite(promotion) {
+		return promotion + (BlackPawn - WhitePawn)
+	}
+	return promotion
+}
+
 func (g *GameState) GetPossibleMoves(pos Position) []Position {
 	if !IsValidBoardPosition(pos) {
 		return []Position{}
@@ -159,6 +218,17 @@ func (g *GameState) GetPossibleMoves(pos Position) []Position {
 		return []Position{}
 	}
 
+	if g.Variant != nil {
+		return g.Variant.LegalMoves(g, pos)
+	}
+	return g.standardMoves(pos)
+}
+
+// standardMoves returns the legal moves of the piece on pos under the
+// standard rules
+func (g *GameState) standardMoves(pos Position) []Position {
+	piece := g.Board[pos.Y][pos.X]
+
 	var allMoves []Position
 	if piece == WhiteKing || piece == BlackKing {
 		allMoves = GetPieceMovesWithGameState(g.Board, pos, g)
@@ -169,6 +239,12 @@ func (g *GameState) GetPossibleMoves(pos Position) []Position {
 	// Filter out moves that leave the king in check
 	var legalMoves []Position
 	for _, move := range allMoves {
+		if _, castling := g.castlingSide(pos, move); castling {
+			// Castling moves are generated only when the king stays safe
+			legalMoves = append(legalMoves, move)
+			continue
+		}
+
 		// Create a proper copy of the board
 		tempBoard := copyBoard(g.Board)
 		tempPiece := tempBoard[pos.Y][pos.X]
@@ -184,6 +260,74 @@ func (g *GameState) GetPossibleMoves(pos Position) []Position {
 	return legalMoves
 }
 
+// HasLegalMoves reports whether the side to move has at least one legal
+// move, drops included
+func (g *GameState) HasLegalMoves() bool {
+	for _, piece := range g.PocketPieces(g.CurrentTurn) {
+		if len(g.GetPossibleDrops(piece)) > 0 {
+			return true
+		}
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if len(g.GetPossibleMoves(Position{X: x, Y: y})) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Outcome decides whether the game is over, by its rules or by the draw
+// rules. positions counts how often each PositionKey has occurred. It
+// returns the PGN result and the termination, e.g. "checkmate" or
+// "threefold repetition".
+func (g *GameState) Outcome(positions map[string]int) (string, string, bool) {
+	status, termination := g.GameStatus, g.Termination
+	if status == InProgress {
+		if g.Variant != nil {
+			status, termination = g.Variant.Outcome(g)
+		} else {
+			status, termination = standardOutcome(g)
+		}
+	}
+	if status != InProgress {
+		if termination == "" {
+			termination = terminationCheckmate
+			if status == GameDraw {
+				termination = terminationStalemate
+			}
+		}
+		result := "1/2-1/2"
+		switch status {
+		case WhiteWon:
+			result = WinResult(WhitePlayer)
+		case BlackWon:
+			result = WinResult(BlackPlayer)
+		}
+		return result, termination, true
+	}
+
+	if positions[g.PositionKey()] >= 3 {
+		return "1/2-1/2", "threefold repetition", true
+	}
+	if g.HalfmoveClock >= 100 {
+		return "1/2-1/2", "fifty-move rule", true
+	}
+	if g.Variant == nil && IsInsufficientMaterial(g.Board) {
+		return "1/2-1/2", "insufficient material", true
+	}
+	return "", "", false
+}
+
+// WinResult returns the PGN result of a game won by player
+func WinResult(player int) string {
+	if player == WhitePlayer {
+		return "1-0"
+	}
+	return "0-1"
+}
+
 // Helper function to copy a board
 func copyBoard(board [8][8]int) [8][8]int {
 	var newBoard [8][8]int
@@ -203,6 +347,15 @@ func (g *GameState) SelectPosition(pos Position) bool {
 
 	piece := g.Board[pos.Y][pos.X]
 
+	// In Chess960 the king castles by moving onto its own rook
+	if g.SelectedPosition != nil && g.Chess960 {
+		if _, castling := g.castlingSide(*g.SelectedPosition, pos); castling && g.isLegalMove(*g.SelectedPosition, pos) {
+			result := g.MakeMove(*g.SelectedPosition, pos)
+			g.SelectedPosition = nil
+			return result != InvalidMove
+		}
+	}
+
 	if piece != Empty && ((g.CurrentTurn == WhitePlayer && IsPieceWhite(piece)) ||
 		(g.CurrentTurn == BlackPlayer && IsPieceBlack(piece))) {
 		g.SelectedPosition = &Position{X: pos.X, Y: pos.Y}
@@ -226,12 +379,29 @@ func (g *GameState) UndoLastMove() bool {
 
 	lastMove := g.MoveHistory[len(g.MoveHistory)-1]
 
-	g.Board[lastMove.From.Y][lastMove.From.X] = lastMove.Piece
-	g.Board[lastMove.To.Y][lastMove.To.X] = lastMove.Captured
+	if lastMove.Drop {
+		g.Board[lastMove.To.Y][lastMove.To.X] = Empty
+		g.Pockets[playerOf(lastMove.Piece)][pocketIndex(lastMove.Piece)]++
+	} else if lastMove.Castling {
+		g.uncastle(lastMove)
+	} else {
+		g.Board[lastMove.From.Y][lastMove.From.X] = lastMove.Piece
+		g.Board[lastMove.To.Y][lastMove.To.X] = lastMove.Captured
+	}
+	for _, removed := range lastMove.Exploded {
+		g.Board[removed.Position.Y][removed.Position.X] = removed.Piece
+	}
+	if g.Drops() && lastMove.Captured != Empty {
+		g.Pockets[playerOf(lastMove.Piece)][pocketIndex(capturedForPocket(lastMove.Captured, isPromoted(lastMove.PromotedSquares, lastMove.To)))]--
+	}
+	g.PromotedSquares = lastMove.PromotedSquares
+	g.setCastlingFlags(lastMove.CastlingFlags)
 
 	g.GameStatus = InProgress
+	g.Termination = ""
 
 	g.CurrentTurn = 1 - g.CurrentTurn
+	g.HalfmoveClock = lastMove.HalfmoveClock
 
 	g.MoveHistory = g.MoveHistory[:len(g.MoveHistory)-1]
 
@@ -239,15 +409,20 @@ func (g *GameState) UndoLastMove() bool {
 }
 
 func (g *GameState) GetGameStatus() string {
+	termination := g.Termination
+	if termination == "" {
+		termination = terminationCheckmate
+	}
+
 	switch g.GameStatus {
 	case WhiteWon:
-		return "White won by checkmate"
+		return "White won by " + termination
 	case BlackWon:
-		return "Black won by checkmate"
+		return "Black won by " + termination
 	case GameDraw:
 		return "Game ended in a draw"
 	default:
-		if IsInCheck(g.Board, g.CurrentTurn) {
+		if g.InCheck(g.CurrentTurn) {
 			if g.CurrentTurn == WhitePlayer {
 				return "White is in check"
 			} else {
@@ -299,3 +474,34 @@ func (g *GameState) GetRemainingTime(player int) time.Duration {
 func (g *GameState) IsGameOver() bool {
 	return g.GameStatus != InProgress
 }
+
+// Clone returns a deep copy of the game state that can be modified
+// without affecting the original
+func (g *GameState) Clone() *GameState {
+	clone := *g
+	clone.MoveHistory = append([]Move{}, g.MoveHistory...)
+	if g.SelectedPosition != nil {
+		selected := *g.SelectedPosition
+		clone.SelectedPosition = &selected
+	}
+	return &clone
+}
+
+// PositionAt replays the first ply half-moves of the game and returns the
+// resulting position. The returned state has its timer stopped.
+func (g *GameState) PositionAt(ply int) *GameState {
+	if ply < 0 {
+		ply = 0
+	}
+	if ply > len(g.MoveHistory) {
+		ply = len(g.MoveHistory)
+	}
+
+	replay := g.newGameFromStart()
+	replay.WhitePlayerTime = g.WhitePlayerTime
+	replay.BlackPlayerTime = g.BlackPlayerTime
+	for _, move := range g.MoveHistory[:ply] {
+		replay.MakeMoveWithPromotion(move.From, move.To, move.Promotion)
+	}
+	return replay
+}
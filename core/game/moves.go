@@ -183,32 +183,5 @@ func getKingMoves(board [8][8]int, pos Position) []Position {
 
 func GetKingMovesWithCastling(board [8][8]int, pos Position, gameState *GameState) []Position {
 	moves := getKingMoves(board, pos)
-	piece := board[pos.Y][pos.X]
-	isWhite := IsPieceWhite(piece)
-
-	if isWhite {
-		if !gameState.WhiteKingMoved && !gameState.WhiteRookAMoved {
-			if board[0][1] == Empty && board[0][2] == Empty && board[0][3] == Empty {
-				moves = append(moves, Position{2, 0})
-			}
-		}
-		if !gameState.WhiteKingMoved && !gameState.WhiteRookHMoved {
-			if board[0][5] == Empty && board[0][6] == Empty {
-				moves = append(moves, Position{6, 0})
-			}
-		}
-	} else {
-		if !gameState.BlackKingMoved && !gameState.BlackRookAMoved {
-			if board[7][1] == Empty && board[7][2] == Empty && board[7][3] == Empty {
-				moves = append(moves, Position{2, 7})
-			}
-		}
-		if !gameState.BlackKingMoved && !gameState.BlackRookHMoved {
-			if board[7][5] == Empty && board[7][6] == Empty {
-				moves = append(moves, Position{6, 7})
-			}
-		}
-	}
-
-	return moves
+	return append(moves, castlingMoves(board, pos, gameState)...)
 }
@@ -0,0 +1,369 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// pieceLetters maps pieces to their SAN letters. Pawns have no letter.
+var pieceLetters = map[int]string{
+	WhiteKnight: "N",
+	BlackKnight: "N",
+	WhiteBishop: "B",
+	BlackBishop: "B",
+	WhiteRook:   "R",
+	BlackRook:   "R",
+	WhiteQueen:  "Q",
+	BlackQueen:  "Q",
+	WhiteKing:   "K",
+	BlackKing:   "K",
+}
+
+// SquareName returns the algebraic name of a square, e.g. "e4"
+func SquareName(pos Position) string {
+	return string(rune('a'+pos.X)) + string(rune('1'+pos.Y))
+}
+
+// ParseSquare converts an algebraic square name such as "e4" to a Position
+func ParseSquare(name string) (Position, bool) {
+	if len(name) != 2 {
+		return Position{}, false
+	}
+	pos := Position{X: int(name[0] - 'a'), Y: int(name[1] - '1')}
+	return pos, IsValidBoardPosition(pos)
+}
+
+// IsCapture reports whether moving the piece on from to to takes an
+// opponent's piece. Castling onto the own rook in Chess960 takes nothing.
+func (g *GameState) IsCapture(from, to Position) bool {
+	piece, target := g.GetPieceAtPosition(from), g.GetPieceAtPosition(to)
+	return piece != Empty && target != Empty && IsPieceWhite(piece) != IsPieceWhite(target)
+}
+
+// SAN returns the Standard Algebraic Notation of the move from→to in the
+// current position. Promotion is ignored unless a pawn reaches the last rank.
+func (g *GameState) SAN(from, to Position, promotion int) string {
+	if from == to {
+		if promotion == Empty {
+			return ""
+		}
+		drop := DropNotation(pocketPiece(pocketIndex(promotion), g.CurrentTurn), to, false)
+		return drop + g.checkSuffix(from, to, promotion)
+	}
+
+	piece := g.GetPieceAtPosition(from)
+	if piece == Empty {
+		return ""
+	}
+
+	var san strings.Builder
+	isPawn := piece == WhitePawn || piece == BlackPawn
+	isCapture := g.Board[to.Y][to.X] != Empty
+	castlingSide, isCastling := g.castlingSide(from, to)
+
+	switch {
+	case isCastling && castlingSide == Kingside:
+		san.WriteString("O-O")
+	case isCastling:
+		san.WriteString("O-O-O")
+	case isPawn:
+		if isCapture || from.X != to.X {
+			san.WriteByte(byte('a' + from.X))
+			san.WriteByte('x')
+		}
+		san.WriteString(SquareName(to))
+		if to.Y == 7 || to.Y == 0 {
+			san.WriteByte('=')
+			san.WriteString(pieceLetters[promotionPiece(piece, promotion)])
+		}
+	default:
+		san.WriteString(pieceLetters[piece])
+		san.WriteString(g.disambiguation(piece, from, to))
+		if isCapture {
+			san.WriteByte('x')
+		}
+		san.WriteString(SquareName(to))
+	}
+
+	san.WriteString(g.checkSuffix(from, to, promotion))
+	return san.String()
+}
+
+// checkSuffix returns "#" when the move mates, "+" when it checks and ""
+// otherwise
+func (g *GameState) checkSuffix(from, to Position, promotion int) string {
+	after := g.Clone()
+	after.TimerActive = false
+	if after.MakeMoveWithPromotion(from, to, promotion) != InvalidMove {
+		last := after.MoveHistory[len(after.MoveHistory)-1]
+		if last.Checkmate {
+			return "#"
+		} else if last.Check {
+			return "+"
+		}
+	}
+	return ""
+}
+
+// disambiguation returns the file, rank or square needed to tell the moving
+// piece apart from identical pieces that can reach the same square
+func (g *GameState) disambiguation(piece int, from, to Position) string {
+	sameFile, sameRank, ambiguous := false, false, false
+
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if g.Board[y][x] != piece || (x == from.X && y == from.Y) {
+				continue
+			}
+			for _, move := range g.GetPossibleMoves(Position{X: x, Y: y}) {
+				if move == to {
+					ambiguous = true
+					if x == from.X {
+						sameFile = true
+					}
+					if y == from.Y {
+						sameRank = true
+					}
+				}
+			}
+		}
+	}
+
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return string(rune('a' + from.X))
+	case !sameRank:
+		return string(rune('1' + from.Y))
+	default:
+		return SquareName(from)
+	}
+}
+
+// UCI returns the move in UCI coordinate notation, e.g. "e2e4" or "e7e8q".
+// A promotion letter is only added when a pawn reaches the last rank. Drops
+// are written like "N@f3".
+func (g *GameState) UCI(from, to Position, promotion int) string {
+	if from == to {
+		return DropNotation(pocketPiece(pocketIndex(promotion), g.CurrentTurn), to, true)
+	}
+	move := SquareName(from) + SquareName(to)
+	piece := g.GetPieceAtPosition(from)
+	if (piece == WhitePawn && to.Y == 7) || (piece == BlackPawn && to.Y == 0) {
+		move += strings.ToLower(pieceLetters[promotionPiece(piece, promotion)])
+	}
+	return move
+}
+
+// ParseUCI converts a move in UCI coordinate notation to its squares and
+// promotion piece. The promotion piece is in the color of the side to move.
+// A drop such as "N@f3" returns its square as both from and to.
+func (g *GameState) ParseUCI(move string) (Position, Position, int, error) {
+	if strings.Contains(move, "@") {
+		piece, to, err := g.parseDrop(move)
+		return to, to, piece, err
+	}
+	if len(move) < 4 || len(move) > 5 {
+		return Position{}, Position{}, Empty, fmt.Errorf("invalid UCI move %q", move)
+	}
+
+	from, okFrom := ParseSquare(move[0:2])
+	to, okTo := ParseSquare(move[2:4])
+	if !okFrom || !okTo {
+		return Position{}, Position{}, Empty, fmt.Errorf("invalid UCI move %q", move)
+	}
+
+	promotion := Empty
+	if len(move) == 5 {
+		promotion = pieceFromLetter(strings.ToUpper(move[4:5]), g.CurrentTurn)
+		if promotion == Empty {
+			return Position{}, Position{}, Empty, fmt.Errorf("invalid promotion in UCI move %q", move)
+		}
+	}
+
+	return from, to, promotion, nil
+}
+
+// ParseSAN finds the legal move described by a move in Standard Algebraic
+// Notation. Check marks and annotation symbols are ignored.
+func (g *GameState) ParseSAN(san string) (Position, Position, int, error) {
+	text := strings.TrimRight(san, "+#!?")
+
+	if strings.Contains(text, "@") {
+		piece, to, err := g.parseDrop(text)
+		if err != nil {
+			return Position{}, Position{}, Empty, err
+		}
+		for _, square := range g.GetPossibleDrops(piece) {
+			if square == to {
+				return to, to, piece, nil
+			}
+		}
+		return Position{}, Position{}, Empty, fmt.Errorf("illegal drop %q", san)
+	}
+
+	if text == "O-O" || text == "0-0" || text == "O-O-O" || text == "0-0-0" {
+		king := WhiteKing
+		if g.CurrentTurn == BlackPlayer {
+			king = BlackKing
+		}
+		side := Kingside
+		if len(text) > 3 {
+			side = Queenside
+		}
+		rank := backRank(g.CurrentTurn)
+		for x := 0; x < 8; x++ {
+			if g.Board[rank][x] != king {
+				continue
+			}
+			from := Position{X: x, Y: rank}
+			to := g.castlingTarget(g.CurrentTurn, side, from)
+			if IsValidBoardPosition(to) && g.isLegalMove(from, to) {
+				return from, to, Empty, nil
+			}
+		}
+		return Position{}, Position{}, Empty, fmt.Errorf("illegal castling %q", san)
+	}
+
+	promotion := Empty
+	if i := strings.IndexByte(text, '='); i >= 0 {
+		promotion = pieceFromLetter(text[i+1:], g.CurrentTurn)
+		text = text[:i]
+	} else if len(text) > 2 && strings.ContainsAny(text[len(text)-1:], "NBRQ") && text[0] >= 'a' && text[0] <= 'h' {
+		// Promotion written without "=", e.g. "e8Q"
+		promotion = pieceFromLetter(text[len(text)-1:], g.CurrentTurn)
+		text = text[:len(text)-1]
+	}
+
+	if len(text) < 2 {
+		return Position{}, Position{}, Empty, fmt.Errorf("invalid SAN move %q", san)
+	}
+
+	to, ok := ParseSquare(text[len(text)-2:])
+	if !ok {
+		return Position{}, Position{}, Empty, fmt.Errorf("invalid target square in SAN move %q", san)
+	}
+
+	pieceLetter := "P"
+	qualifier := strings.ReplaceAll(text[:len(text)-2], "x", "")
+	if len(qualifier) > 0 && strings.ContainsAny(qualifier[:1], "NBRQK") {
+		pieceLetter = qualifier[:1]
+		qualifier = qualifier[1:]
+	}
+	piece := pieceFromLetter(pieceLetter, g.CurrentTurn)
+
+	var candidates []Position
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if g.Board[y][x] != piece || !matchesQualifier(Position{X: x, Y: y}, qualifier) {
+				continue
+			}
+			from := Position{X: x, Y: y}
+			if g.isLegalMove(from, to) {
+				candidates = append(candidates, from)
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 1:
+		return candidates[0], to, promotion, nil
+	case 0:
+		if pieceLetter == "P" && strings.Contains(text, "x") && g.isEnPassant(to) {
+			return Position{}, Position{}, Empty, fmt.Errorf("%q: %w", san, ErrEnPassant)
+		}
+		return Position{}, Position{}, Empty, fmt.Errorf("illegal move %q", san)
+	default:
+		return Position{}, Position{}, Empty, fmt.Errorf("ambiguous move %q", san)
+	}
+}
+
+// ErrEnPassant is returned for an en passant capture, which the rules of
+// this package do not include
+var ErrEnPassant = errors.New("en passant is not supported")
+
+// isEnPassant reports whether a pawn of the side to move capturing on to
+// would take en passant: the square is empty and an opponent's pawn stands
+// behind it, where a two-square advance would have left it
+func (g *GameState) isEnPassant(to Position) bool {
+	rank, behind, pawn := 5, 4, BlackPawn
+	if g.CurrentTurn == BlackPlayer {
+		rank, behind, pawn = 2, 3, WhitePawn
+	}
+	return to.Y == rank && g.Board[to.Y][to.X] == Empty && g.Board[behind][to.X] == pawn
+}
+
+// IsLegal reports whether a move, or a drop given as from == to with the
+// piece in promotion, is legal for the side to move. Unlike
+// MakeMoveWithPromotion it never accepts a move that leaves the king in
+// check.
+func (g *GameState) IsLegal(from, to Position, promotion int) bool {
+	if from == to {
+		for _, square := range g.GetPossibleDrops(promotion) {
+			if square == to {
+				return true
+			}
+		}
+		return false
+	}
+	piece := g.GetPieceAtPosition(from)
+	if piece == Empty || !isOwnPiece(piece, g.CurrentTurn) {
+		return false
+	}
+	return g.isLegalMove(from, to)
+}
+
+func (g *GameState) isLegalMove(from, to Position) bool {
+	for _, move := range g.GetPossibleMoves(from) {
+		if move == to {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesQualifier checks a SAN disambiguation (file, rank or square)
+func matchesQualifier(pos Position, qualifier string) bool {
+	for _, c := range qualifier {
+		switch {
+		case c >= 'a' && c <= 'h':
+			if pos.X != int(c-'a') {
+				return false
+			}
+		case c >= '1' && c <= '8':
+			if pos.Y != int(c-'1') {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// pieceFromLetter returns the piece for a SAN letter in the given color
+func pieceFromLetter(letter string, player int) int {
+	var piece int
+	switch letter {
+	case "P":
+		piece = WhitePawn
+	case "N":
+		piece = WhiteKnight
+	case "B":
+		piece = WhiteBishop
+	case "R":
+		piece = WhiteRook
+	case "Q":
+		piece = WhiteQueen
+	case "K":
+		piece = WhiteKing
+	default:
+		return Empty
+	}
+	if player == BlackPlayer {
+		piece += BlackPawn - WhitePawn
+	}
+	return piece
+}
@@ -0,0 +1,402 @@
+package game
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sevenTagRoster lists the PGN tags that are always written, in order
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// PGNMove is a single move of a PGN game with its optional annotations
+type PGNMove struct {
+	SAN     string
+	NAG     int
+	Comment string
+}
+
+// PGNGame is a game in Portable Game Notation form
+type PGNGame struct {
+	Tags  map[string]string
+	Moves []PGNMove
+}
+
+// ResultString returns the PGN result token for the game status
+func (g *GameState) ResultString() string {
+	switch g.GameStatus {
+	case WhiteWon:
+		return "1-0"
+	case BlackWon:
+		return "0-1"
+	case GameDraw:
+		return "1/2-1/2"
+	default:
+		return "*"
+	}
+}
+
+// ToPGN converts the game's move history to PGN with default tags
+func (g *GameState) ToPGN() *PGNGame {
+	pgn := &PGNGame{
+		Tags: map[string]string{
+			"Event":  "Casual Game",
+			"Site":   "Go Chess",
+			"Date":   time.Now().Format("2006.01.02"),
+			"Round":  "-",
+			"White":  "White",
+			"Black":  "Black",
+			"Result": g.ResultString(),
+		},
+	}
+
+	if g.StartFEN != "" {
+		pgn.Tags["SetUp"] = "1"
+		pgn.Tags["FEN"] = g.StartFEN
+	}
+	if g.Chess960 {
+		pgn.Tags["Variant"] = "Chess960"
+	} else if g.Variant != nil {
+		pgn.Tags["Variant"] = g.Variant.Name()
+	}
+
+	if opening, ok := g.ClassifyOpening(); ok {
+		pgn.Tags["ECO"] = opening.ECO
+		pgn.Tags["Opening"] = opening.Name
+	}
+
+	replay := g.PositionAt(0)
+	for _, move := range g.MoveHistory {
+		san := replay.SAN(move.From, move.To, move.Promotion)
+		pgn.Moves = append(pgn.Moves, PGNMove{SAN: san})
+		replay.MakeMoveWithPromotion(move.From, move.To, move.Promotion)
+	}
+
+	return pgn
+}
+
+// String renders the game as PGN text
+func (p *PGNGame) String() string {
+	var sb strings.Builder
+
+	for _, tag := range sevenTagRoster {
+		value, ok := p.Tags[tag]
+		if !ok {
+			value = "?"
+			if tag == "Result" {
+				value = "*"
+			}
+		}
+		fmt.Fprintf(&sb, "[%s \"%s\"]\n", tag, escapeTagValue(value))
+	}
+
+	var extra []string
+	for tag := range p.Tags {
+		if !isSevenTagRoster(tag) {
+			extra = append(extra, tag)
+		}
+	}
+	sort.Strings(extra)
+	for _, tag := range extra {
+		fmt.Fprintf(&sb, "[%s \"%s\"]\n", tag, escapeTagValue(p.Tags[tag]))
+	}
+	sb.WriteString("\n")
+
+	startSide, startNumber := fenStart(p.Tags["FEN"])
+	offset := 0
+	if startSide == BlackPlayer {
+		offset = 1
+	}
+
+	var tokens []string
+	for i, move := range p.Moves {
+		ply := i + offset
+		number := startNumber + ply/2
+		if ply%2 == 0 {
+			tokens = append(tokens, fmt.Sprintf("%d.", number))
+		} else if i == 0 || p.Moves[i-1].Comment != "" {
+			// Black's move needs its number at the start and after a comment
+			tokens = append(tokens, fmt.Sprintf("%d...", number))
+		}
+		tokens = append(tokens, move.SAN)
+		if move.NAG > 0 {
+			tokens = append(tokens, fmt.Sprintf("$%d", move.NAG))
+		}
+		if move.Comment != "" {
+			tokens = append(tokens, "{"+strings.ReplaceAll(move.Comment, "}", ")")+"}")
+		}
+	}
+
+	result := p.Tags["Result"]
+	if result == "" {
+		result = "*"
+	}
+	tokens = append(tokens, result)
+
+	// Wrap movetext at 80 columns as the PGN export format recommends
+	lineLength := 0
+	for i, token := range tokens {
+		if i > 0 {
+			if lineLength+1+len(token) > 80 {
+				sb.WriteString("\n")
+				lineLength = 0
+			} else {
+				sb.WriteString(" ")
+				lineLength++
+			}
+		}
+		sb.WriteString(token)
+		lineLength += len(token)
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// Game replays the PGN moves and returns the resulting game
+func (p *PGNGame) Game() (*GameState, error) {
+	g := NewGame()
+	if fen, ok := p.Tags["FEN"]; ok {
+		var err error
+		g, err = NewGameFromFEN(fen)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if isChess960Variant(p.Tags["Variant"]) {
+		g.Chess960 = true
+	} else if variant, ok := VariantByName(p.Tags["Variant"]); ok {
+		if _, ok := p.Tags["FEN"]; !ok {
+			var err error
+			if g, err = NewGameFromFEN(variant.StartFEN()); err != nil {
+				return nil, err
+			}
+		}
+		g.SetVariant(variant)
+	}
+	g.TimerActive = false
+
+	for i, move := range p.Moves {
+		from, to, promotion, err := g.ParseSAN(move.SAN)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		g.MakeMoveWithPromotion(from, to, promotion)
+	}
+
+	switch p.Tags["Result"] {
+	case "1-0":
+		g.GameStatus = WhiteWon
+	case "0-1":
+		g.GameStatus = BlackWon
+	case "1/2-1/2":
+		g.GameStatus = GameDraw
+	}
+
+	return g, nil
+}
+
+// isChess960Variant reports whether a PGN Variant tag names Chess960
+func isChess960Variant(variant string) bool {
+	switch strings.ToLower(strings.ReplaceAll(variant, " ", "")) {
+	case "chess960", "fischerandom", "fischerrandom":
+		return true
+	}
+	return false
+}
+
+// ParsePGN reads every game from PGN text. Variations are skipped; comments
+// and NAGs are attached to the preceding move.
+func ParsePGN(r io.Reader) ([]*PGNGame, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := string(data)
+
+	var games []*PGNGame
+	var current *PGNGame
+	inMoves := false
+
+	finish := func() {
+		if current != nil {
+			games = append(games, current)
+		}
+		current = nil
+		inMoves = false
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '[':
+			if inMoves {
+				finish()
+			}
+			if current == nil {
+				current = &PGNGame{Tags: map[string]string{}}
+			}
+			end := tagEnd(text[i:])
+			if end < 0 {
+				return games, fmt.Errorf("unterminated tag at offset %d", i)
+			}
+			name, value := parseTag(text[i+1 : i+end])
+			if name != "" {
+				current.Tags[name] = value
+			}
+			i += end + 1
+		case c == '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return games, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			if current != nil && len(current.Moves) > 0 {
+				last := &current.Moves[len(current.Moves)-1]
+				comment := strings.TrimSpace(text[i+1 : i+end])
+				if last.Comment != "" {
+					comment = last.Comment + " " + comment
+				}
+				last.Comment = comment
+			}
+			i += end + 1
+		case c == ';':
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			i += end
+		case c == '(':
+			// Skip the variation, including nested ones
+			depth := 0
+			for ; i < len(text); i++ {
+				if text[i] == '(' {
+					depth++
+				} else if text[i] == ')' {
+					depth--
+					if depth == 0 {
+						i++
+						break
+					}
+				} else if text[i] == '{' {
+					if end := strings.IndexByte(text[i:], '}'); end >= 0 {
+						i += end
+					}
+				}
+			}
+		default:
+			end := i
+			for end < len(text) && !strings.ContainsRune(" \t\r\n{}()[];", rune(text[end])) {
+				end++
+			}
+			if end == i {
+				// Skip a stray closing bracket
+				i++
+				continue
+			}
+			token := text[i:end]
+			i = end
+
+			if current == nil {
+				current = &PGNGame{Tags: map[string]string{}}
+			}
+			inMoves = true
+
+			switch {
+			case token == "1-0" || token == "0-1" || token == "1/2-1/2" || token == "*":
+				if _, ok := current.Tags["Result"]; !ok {
+					current.Tags["Result"] = token
+				}
+				finish()
+			case strings.HasPrefix(token, "$"):
+				if nag, err := strconv.Atoi(token[1:]); err == nil && len(current.Moves) > 0 {
+					current.Moves[len(current.Moves)-1].NAG = nag
+				}
+			default:
+				// Strip a leading move number such as "12." or "12..."
+				move := token
+				if trimmed := strings.TrimLeft(token, "0123456789"); strings.HasPrefix(trimmed, ".") {
+					move = strings.TrimLeft(trimmed, ".")
+				}
+				if move != "" {
+					current.Moves = append(current.Moves, PGNMove{SAN: move})
+				}
+			}
+		}
+	}
+	finish()
+
+	return games, nil
+}
+
+// tagEnd returns the offset of the "]" closing the tag pair at the start of
+// text, skipping brackets inside the quoted value such as a Crazyhouse
+// pocket, or -1
+func tagEnd(text string) int {
+	quoted := false
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = !quoted
+		case ']':
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseTag splits a tag pair such as `White "Carlsen"` into name and value
+func parseTag(tag string) (string, string) {
+	tag = strings.TrimSpace(tag)
+	space := strings.IndexAny(tag, " \t")
+	if space < 0 {
+		return "", ""
+	}
+
+	name := tag[:space]
+	value := strings.TrimSpace(tag[space:])
+	value = strings.TrimPrefix(value, "\"")
+	value = strings.TrimSuffix(value, "\"")
+	value = strings.ReplaceAll(value, "\\\"", "\"")
+	value = strings.ReplaceAll(value, "\\\\", "\\")
+	return name, value
+}
+
+// fenStart returns the side to move and full move number of a FEN, or of
+// the standard starting position when fen is empty
+func fenStart(fen string) (int, int) {
+	fields := strings.Fields(fen)
+	side := WhitePlayer
+	if len(fields) > 1 && fields[1] == "b" {
+		side = BlackPlayer
+	}
+	number := 1
+	if len(fields) > 5 {
+		if n, err := strconv.Atoi(fields[5]); err == nil && n > 0 {
+			number = n
+		}
+	}
+	return side, number
+}
+
+func isSevenTagRoster(tag string) bool {
+	for _, t := range sevenTagRoster {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func escapeTagValue(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	return strings.ReplaceAll(value, "\"", "\\\"")
+}
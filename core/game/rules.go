@@ -61,6 +61,7 @@ func NewGame() *GameState {
 		BlackPlayerTime: 30 * time.Minute,
 		LastMoveTime:    time.Now(),
 		GameStatus:      InProgress,
+		CastlingRooks:   [2][2]int{{0, 7}, {0, 7}},
 	}
 }
 
@@ -169,3 +170,21 @@ func IsStalemate(board [8][8]int, playerTurn int) bool {
 
 	return true
 }
+
+// IsInsufficientMaterial reports whether neither side can possibly mate:
+// bare kings, or a king and a single minor piece against a bare king
+func IsInsufficientMaterial(board [8][8]int) bool {
+	minors := 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			switch board[y][x] {
+			case Empty, WhiteKing, BlackKing:
+			case WhiteKnight, BlackKnight, WhiteBishop, BlackBishop:
+				minors++
+			default:
+				return false
+			}
+		}
+	}
+	return minors <= 1
+}
@@ -53,7 +53,8 @@ const (
 	GameDraw
 )
 
-// Move represents a chess move
+// Move represents a chess move. A Crazyhouse drop has Drop set, From equal
+// to To and the dropped piece as both Piece and Promotion.
 type Move struct {
 	From      Position
 	To        Position
@@ -63,6 +64,23 @@ type Move struct {
 	Check     bool
 	Checkmate bool
 	Castling  bool
+	// HalfmoveClock is the game's halfmove clock before the move was made
+	HalfmoveClock int
+	// CastlingFlags are the game's king and rook moved flags before the move
+	CastlingFlags [6]bool
+	// Exploded lists the pieces an Atomic capture removed besides the
+	// captured piece and the capturing piece
+	Exploded []PlacedPiece
+	// Drop marks a piece dropped from the pocket
+	Drop bool
+	// PromotedSquares is the game's PromotedSquares before the move
+	PromotedSquares uint64
+}
+
+// PlacedPiece is a piece on a square
+type PlacedPiece struct {
+	Position Position
+	Piece    int
 }
 
 // GameState represents the current state of a chess game
@@ -82,4 +100,25 @@ type GameState struct {
 	LastMoveTime     time.Time
 	TimerActive      bool
 	SelectedPosition *Position
+	// StartFEN is the starting position when the game did not begin from
+	// the standard setup
+	StartFEN string
+	// HalfmoveClock counts half-moves since the last capture or pawn move
+	HalfmoveClock int
+	// Chess960 selects Fischer Random castling, where the king castles by
+	// moving onto its own rook
+	Chess960 bool
+	// CastlingRooks holds the starting files of the queenside and kingside
+	// castling rooks, indexed by player and side
+	CastlingRooks [2][2]int
+	// Variant overrides the rules of the game; nil plays standard chess
+	Variant Variant
+	// Termination says how a variant game was won, e.g. "king of the hill"
+	Termination string
+	// Pockets counts the pieces each player holds for dropping in
+	// Crazyhouse, indexed by player and by piece from pawn to queen
+	Pockets [2][5]int
+	// PromotedSquares has a bit set, at y*8+x, for each square holding a
+	// promoted piece, which returns to the pocket as a pawn when captured
+	PromotedSquares uint64
 }
@@ -0,0 +1,153 @@
+package game
+
+import "strings"
+
+// Variant changes the rules of a game. A game with a nil Variant follows
+// the standard rules.
+type Variant interface {
+	// Name is the variant's name, as used in the PGN Variant tag
+	Name() string
+	// StartFEN is the position a new game starts from
+	StartFEN() string
+	// LegalMoves returns the squares the piece on pos may move to. The
+	// piece belongs to the side to move.
+	LegalMoves(g *GameState, pos Position) []Position
+	// AfterCapture is called once a capturing piece has landed on to and
+	// returns any pieces it removed besides the captured piece
+	AfterCapture(g *GameState, to Position) []PlacedPiece
+	// InCheck reports whether the player's king is in check
+	InCheck(g *GameState, player int) bool
+	// Outcome decides the game after a move. It returns InProgress while
+	// the game goes on, and otherwise the result and how it was reached.
+	Outcome(g *GameState) (GameStatus, string)
+}
+
+// Terminations reported by Outcome
+const (
+	terminationCheckmate = "checkmate"
+	terminationStalemate = "stalemate"
+)
+
+// Variants lists the rule variants that can be played besides standard
+// chess and Chess960
+var Variants = []Variant{KingOfTheHill{}, ThreeCheck{}, Atomic{}, Antichess{}, Crazyhouse{}}
+
+// VariantByName finds a variant by name, ignoring case, spaces and dashes
+func VariantByName(name string) (Variant, bool) {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(s))
+	}
+	for _, variant := range Variants {
+		if normalize(variant.Name()) == normalize(name) {
+			return variant, true
+		}
+	}
+	return nil, false
+}
+
+// NewVariantGame creates a game of the variant from its start position
+func NewVariantGame(variant Variant) (*GameState, error) {
+	g, err := NewGameFromFEN(variant.StartFEN())
+	if err != nil {
+		return nil, err
+	}
+	g.SetVariant(variant)
+	return g, nil
+}
+
+// SetVariant makes the game follow the variant's rules, or the standard
+// rules for nil, and decides the position again under them. A game loaded
+// from a FEN is judged by the standard rules until its variant is set.
+func (g *GameState) SetVariant(variant Variant) {
+	g.Variant = variant
+	g.GameStatus, g.Termination = g.setUpStatus()
+}
+
+// setUpStatus decides a position that was set up rather than reached by a
+// move. Under the standard rules only checkmate ends it.
+func (g *GameState) setUpStatus() (GameStatus, string) {
+	if g.Variant != nil {
+		return g.Variant.Outcome(g)
+	}
+	if IsCheckmate(g.Board, g.CurrentTurn) {
+		return winFor(1 - g.CurrentTurn), ""
+	}
+	return InProgress, ""
+}
+
+// InCheck reports whether the player's king is in check under the game's
+// rules
+func (g *GameState) InCheck(player int) bool {
+	if g.Variant != nil {
+		return g.Variant.InCheck(g, player)
+	}
+	return IsInCheck(g.Board, player)
+}
+
+// standardRules implements the standard rules. Variants embed it and
+// override what they change.
+type standardRules struct{}
+
+func (standardRules) StartFEN() string {
+	return StandardFEN
+}
+
+func (standardRules) LegalMoves(g *GameState, pos Position) []Position {
+	return g.standardMoves(pos)
+}
+
+func (standardRules) AfterCapture(g *GameState, to Position) []PlacedPiece {
+	return nil
+}
+
+func (standardRules) InCheck(g *GameState, player int) bool {
+	return IsInCheck(g.Board, player)
+}
+
+func (standardRules) Outcome(g *GameState) (GameStatus, string) {
+	return standardOutcome(g)
+}
+
+// standardOutcome ends the game by checkmate or stalemate, using the
+// game's own move generation and notion of check
+func standardOutcome(g *GameState) (GameStatus, string) {
+	if g.HasLegalMoves() {
+		return InProgress, ""
+	}
+	if g.InCheck(g.CurrentTurn) {
+		return winFor(1 - g.CurrentTurn), terminationCheckmate
+	}
+	return GameDraw, terminationStalemate
+}
+
+// winFor returns the status of a game won by player
+func winFor(player int) GameStatus {
+	if player == WhitePlayer {
+		return WhiteWon
+	}
+	return BlackWon
+}
+
+// findKing returns the square of the player's king
+func findKing(board [8][8]int, player int) (Position, bool) {
+	king := WhiteKing
+	if player == BlackPlayer {
+		king = BlackKing
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if board[y][x] == king {
+				return Position{X: x, Y: y}, true
+			}
+		}
+	}
+	return Position{}, false
+}
+
+// isOwnPiece reports whether piece belongs to player
+func isOwnPiece(piece, player int) bool {
+	if player == WhitePlayer {
+		return IsPieceWhite(piece)
+	}
+	return IsPieceBlack(piece)
+}
@@ -0,0 +1,213 @@
+package game
+
+// KingOfTheHill is won by checkmate or by bringing the king to one of the
+// four center squares
+type KingOfTheHill struct{ standardRules }
+
+func (KingOfTheHill) Name() string { return "King of the Hill" }
+
+func (KingOfTheHill) Outcome(g *GameState) (GameStatus, string) {
+	mover := 1 - g.CurrentTurn
+	if king, ok := findKing(g.Board, mover); ok && IsOnHill(king) {
+		return winFor(mover), "king of the hill"
+	}
+	return standardOutcome(g)
+}
+
+// IsOnHill reports whether pos is one of the center squares d4, e4, d5
+// and e5
+func IsOnHill(pos Position) bool {
+	return pos.X >= 3 && pos.X <= 4 && pos.Y >= 3 && pos.Y <= 4
+}
+
+// ThreeCheck is won by checkmate or by giving check three times
+type ThreeCheck struct{ standardRules }
+
+func (ThreeCheck) Name() string { return "Three-check" }
+
+func (ThreeCheck) Outcome(g *GameState) (GameStatus, string) {
+	mover := 1 - g.CurrentTurn
+	if g.ChecksGiven(mover) >= 3 {
+		return winFor(mover), "three checks"
+	}
+	return standardOutcome(g)
+}
+
+// ChecksGiven counts the checks the player has given in the game
+func (g *GameState) ChecksGiven(player int) int {
+	checks := 0
+	for _, move := range g.MoveHistory {
+		if move.Check && isOwnPiece(move.Piece, player) {
+			checks++
+		}
+	}
+	return checks
+}
+
+// Atomic makes every capture explode: the capturing piece and every piece
+// other than a pawn next to the capture square are removed. Exploding the
+// enemy king wins. Kings cannot capture, may not be exploded by their own
+// side, and cannot be checked while the two kings stand side by side.
+type Atomic struct{ standardRules }
+
+func (Atomic) Name() string { return "Atomic" }
+
+func (Atomic) LegalMoves(g *GameState, pos Position) []Position {
+	piece := g.Board[pos.Y][pos.X]
+	player := playerOf(piece)
+	isKing := piece == WhiteKing || piece == BlackKing
+
+	var candidates []Position
+	if isKing {
+		candidates = GetPieceMovesWithGameState(g.Board, pos, g)
+	} else {
+		candidates = GetPieceMoves(g.Board, pos)
+	}
+
+	var moves []Position
+	for _, move := range candidates {
+		if _, castling := g.castlingSide(pos, move); castling {
+			moves = append(moves, move)
+			continue
+		}
+		capture := g.Board[move.Y][move.X] != Empty
+		if isKing && capture {
+			continue
+		}
+
+		board := g.Board
+		board[pos.Y][pos.X] = Empty
+		board[move.Y][move.X] = piece
+		if capture {
+			explode(&board, move)
+		}
+
+		if _, ok := findKing(board, player); !ok {
+			continue
+		}
+		if _, ok := findKing(board, 1-player); !ok || !atomicInCheck(board, player) {
+			moves = append(moves, move)
+		}
+	}
+	return moves
+}
+
+func (Atomic) AfterCapture(g *GameState, to Position) []PlacedPiece {
+	return explode(&g.Board, to)
+}
+
+func (Atomic) InCheck(g *GameState, player int) bool {
+	return atomicInCheck(g.Board, player)
+}
+
+func (Atomic) Outcome(g *GameState) (GameStatus, string) {
+	mover := 1 - g.CurrentTurn
+	if _, ok := findKing(g.Board, g.CurrentTurn); !ok {
+		return winFor(mover), "explosion"
+	}
+	return standardOutcome(g)
+}
+
+// explode removes the capturing piece on to and every piece but pawns on
+// the squares around it. The pieces removed around it are returned.
+func explode(board *[8][8]int, to Position) []PlacedPiece {
+	board[to.Y][to.X] = Empty
+
+	var removed []PlacedPiece
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			pos := Position{X: to.X + dx, Y: to.Y + dy}
+			if pos == to || !IsValidBoardPosition(pos) {
+				continue
+			}
+			piece := board[pos.Y][pos.X]
+			if piece == Empty || piece == WhitePawn || piece == BlackPawn {
+				continue
+			}
+			removed = append(removed, PlacedPiece{Position: pos, Piece: piece})
+			board[pos.Y][pos.X] = Empty
+		}
+	}
+	return removed
+}
+
+// atomicInCheck reports whether the player's king is attacked while not
+// standing next to the enemy king
+func atomicInCheck(board [8][8]int, player int) bool {
+	king, ok := findKing(board, player)
+	if !ok {
+		return false
+	}
+	if enemy, ok := findKing(board, 1-player); ok && absInt(enemy.X-king.X) <= 1 && absInt(enemy.Y-king.Y) <= 1 {
+		return false
+	}
+	return IsInCheck(board, player)
+}
+
+// Antichess is won by losing every piece or being stalemated. Captures are
+// compulsory, the king is an ordinary piece and there is no check or
+// castling.
+type Antichess struct{ standardRules }
+
+func (Antichess) Name() string { return "Antichess" }
+
+func (Antichess) StartFEN() string {
+	return "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1"
+}
+
+func (Antichess) LegalMoves(g *GameState, pos Position) []Position {
+	moves := GetPieceMoves(g.Board, pos)
+	if !hasCapture(g.Board, playerOf(g.Board[pos.Y][pos.X])) {
+		return moves
+	}
+
+	var captures []Position
+	for _, move := range moves {
+		if g.Board[move.Y][move.X] != Empty {
+			captures = append(captures, move)
+		}
+	}
+	return captures
+}
+
+func (Antichess) InCheck(g *GameState, player int) bool {
+	return false
+}
+
+func (Antichess) Outcome(g *GameState) (GameStatus, string) {
+	if g.HasLegalMoves() {
+		return InProgress, ""
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if isOwnPiece(g.Board[y][x], g.CurrentTurn) {
+				return winFor(g.CurrentTurn), terminationStalemate
+			}
+		}
+	}
+	return winFor(g.CurrentTurn), "losing all pieces"
+}
+
+// hasCapture reports whether the player can capture anything
+func hasCapture(board [8][8]int, player int) bool {
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if !isOwnPiece(board[y][x], player) {
+				continue
+			}
+			for _, move := range GetPieceMoves(board, Position{X: x, Y: y}) {
+				if board[move.Y][move.X] != Empty {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,124 @@
+package gamedb
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/h3bzzz/go-chess/core/game"
+	"github.com/h3bzzz/go-chess/core/store"
+)
+
+// gameMove is a move index entry: the move a game played from a position
+type gameMove struct {
+	Move     string `json:"move"`
+	SAN      string `json:"san"`
+	Result   string `json:"result"`
+	WhiteElo int    `json:"white_elo,omitempty"`
+	BlackElo int    `json:"black_elo,omitempty"`
+}
+
+// MoveStats sums up the games that played a move from a position
+type MoveStats struct {
+	// Move is in UCI notation, SAN in Standard Algebraic Notation
+	Move string
+	SAN  string
+	// Games counts the games, split by result into WhiteWins, Draws and
+	// BlackWins. Unfinished games count in no result.
+	Games     int
+	WhiteWins int
+	Draws     int
+	BlackWins int
+	// AverageWhite and AverageBlack are the average ratings of the games
+	// with a rating, or 0 when none has one
+	AverageWhite int
+	AverageBlack int
+
+	whiteRated, whiteTotal int
+	blackRated, blackTotal int
+}
+
+// WhitePercent, DrawPercent and BlackPercent share the games by result
+func (s MoveStats) WhitePercent() float64 { return percent(s.WhiteWins, s.Games) }
+func (s MoveStats) DrawPercent() float64  { return percent(s.Draws, s.Games) }
+func (s MoveStats) BlackPercent() float64 { return percent(s.BlackWins, s.Games) }
+
+func percent(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(count) / float64(total)
+}
+
+// Explore returns the moves the games of the database played from the
+// position, most played first. Move counters are ignored, so a position is
+// found however it was reached.
+func (db *DB) Explore(position *game.GameState) ([]MoveStats, error) {
+	moves := map[string]*MoveStats{}
+	err := db.db.View(func(tx *store.Tx) error {
+		return tx.ForEachPrefix(movesIndex, explorerKey(position)+indexSeparator, func(key string, _ []byte) error {
+			var entry gameMove
+			if err := tx.GetJSON(movesIndex, key, &entry); err != nil {
+				return err
+			}
+			stats, ok := moves[entry.Move]
+			if !ok {
+				stats = &MoveStats{Move: entry.Move, SAN: entry.SAN}
+				moves[entry.Move] = stats
+			}
+			stats.add(entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]MoveStats, 0, len(moves))
+	for _, stats := range moves {
+		if stats.whiteRated > 0 {
+			stats.AverageWhite = stats.whiteTotal / stats.whiteRated
+		}
+		if stats.blackRated > 0 {
+			stats.AverageBlack = stats.blackTotal / stats.blackRated
+		}
+		list = append(list, *stats)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Games != list[j].Games {
+			return list[i].Games > list[j].Games
+		}
+		return list[i].SAN < list[j].SAN
+	})
+	return list, nil
+}
+
+// add counts a game in the statistics
+func (s *MoveStats) add(entry gameMove) {
+	s.Games++
+	switch entry.Result {
+	case "1-0":
+		s.WhiteWins++
+	case "0-1":
+		s.BlackWins++
+	case "1/2-1/2":
+		s.Draws++
+	}
+	if entry.WhiteElo > 0 {
+		s.whiteRated++
+		s.whiteTotal += entry.WhiteElo
+	}
+	if entry.BlackElo > 0 {
+		s.blackRated++
+		s.blackTotal += entry.BlackElo
+	}
+}
+
+// explorerKey identifies a position in the move index. The variant is part
+// of the key, as the same position leads to other moves under other rules.
+func explorerKey(position *game.GameState) string {
+	variant := ""
+	if position.Variant != nil {
+		variant = strings.ToLower(position.Variant.Name())
+	}
+	return variant + indexSeparator + position.PositionKey()
+}
@@ -0,0 +1,411 @@
+// Package gamedb is a database of games imported from PGN files, indexed
+// for searching by player, date, result, opening and position.
+package gamedb
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/h3bzzz/go-chess/core/game"
+	"github.com/h3bzzz/go-chess/core/store"
+)
+
+// Database buckets. Index keys are the indexed value, a NUL and the game
+// key, so a prefix scan finds the games with a value.
+const (
+	gamesBucket    = "games"
+	hashesBucket   = "hashes"
+	playerIndex    = "index:player"
+	dateIndex      = "index:date"
+	resultIndex    = "index:result"
+	ecoIndex       = "index:eco"
+	positionIndex  = "index:position"
+	materialIndex  = "index:material"
+	movesIndex     = "index:moves"
+	metaBucket     = "meta"
+	indexSeparator = "\x00"
+)
+
+// schemaVersion is the version of the indexes. Opening a database written
+// with an older version builds the indexes it lacks.
+const schemaVersion = 2
+
+// Game is an imported game
+type Game struct {
+	ID       int    `json:"id"`
+	White    string `json:"white"`
+	Black    string `json:"black"`
+	Event    string `json:"event,omitempty"`
+	Site     string `json:"site,omitempty"`
+	Date     string `json:"date,omitempty"`
+	Round    string `json:"round,omitempty"`
+	Result   string `json:"result"`
+	ECO      string `json:"eco,omitempty"`
+	Opening  string `json:"opening,omitempty"`
+	Variant  string `json:"variant,omitempty"`
+	WhiteElo int    `json:"white_elo,omitempty"`
+	BlackElo int    `json:"black_elo,omitempty"`
+	Plies    int    `json:"plies"`
+	// PGN is the game as imported
+	PGN string `json:"pgn"`
+}
+
+// ImportResult counts the games of an import
+type ImportResult struct {
+	Imported   int
+	Duplicates int
+	// Failed lists the games that could not be replayed
+	Failed []error
+}
+
+// DB is an open game database
+type DB struct {
+	db *store.DB
+}
+
+// DefaultPath returns where the game database is stored for the current
+// user
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-chess", "games.db"), nil
+}
+
+// Open opens the database at path, creating it if needed
+func Open(path string) (*DB, error) {
+	db, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	gdb := &DB{db: db}
+	if err := gdb.upgrade(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return gdb, nil
+}
+
+// upgrade builds the indexes added since the database was written. Version
+// 1 databases lack the move index of the opening explorer.
+func (db *DB) upgrade() error {
+	return db.db.Update(func(tx *store.Tx) error {
+		version := 1
+		if err := tx.GetJSON(metaBucket, "version", &version); err != nil && err != store.ErrNotFound {
+			return err
+		}
+		if version >= schemaVersion {
+			return nil
+		}
+
+		if version < 2 {
+			var games []*Game
+			err := tx.ForEach(gamesBucket, func(key string, value []byte) error {
+				var g Game
+				if err := tx.GetJSON(gamesBucket, key, &g); err != nil {
+					return err
+				}
+				games = append(games, &g)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, g := range games {
+				pgns, err := game.ParsePGN(strings.NewReader(g.PGN))
+				if err != nil || len(pgns) != 1 {
+					continue
+				}
+				positions, err := replay(pgns[0])
+				if err != nil {
+					continue
+				}
+				if err := indexMoves(tx, g, positions); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.PutJSON(metaBucket, "version", schemaVersion)
+	})
+}
+
+// Close closes the database
+func (db *DB) Close() error {
+	return db.db.Close()
+}
+
+// ImportFile imports every game of a PGN file
+func (db *DB) ImportFile(path string) (ImportResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	defer file.Close()
+	return db.Import(file)
+}
+
+// Import adds the games of PGN text. Games already in the database are
+// skipped, as are games whose moves cannot be replayed.
+func (db *DB) Import(r io.Reader) (ImportResult, error) {
+	var result ImportResult
+	pgns, err := game.ParsePGN(r)
+	if err != nil {
+		return result, err
+	}
+
+	err = db.db.Update(func(tx *store.Tx) error {
+		for i, pgn := range pgns {
+			positions, err := replay(pgn)
+			if err != nil {
+				result.Failed = append(result.Failed, fmt.Errorf("game %d (%s - %s): %v", i+1, pgn.Tags["White"], pgn.Tags["Black"], err))
+				continue
+			}
+
+			hash := gameHash(pgn)
+			if _, err := tx.Get(hashesBucket, hash); err == nil {
+				result.Duplicates++
+				continue
+			}
+
+			id, err := tx.NextSequence(gamesBucket)
+			if err != nil {
+				return err
+			}
+			g := newGame(int(id), pgn, len(positions)-1)
+			if err := index(tx, g, positions); err != nil {
+				return err
+			}
+			if err := tx.Put(hashesBucket, hash, []byte(gameKey(g.ID))); err != nil {
+				return err
+			}
+			result.Imported++
+		}
+		return nil
+	})
+	return result, err
+}
+
+// replay plays a PGN game and returns its positions from the start
+func replay(pgn *game.PGNGame) ([]*game.GameState, error) {
+	final, err := pgn.Game()
+	if err != nil {
+		return nil, err
+	}
+	if len(final.MoveHistory) != len(pgn.Moves) {
+		return nil, fmt.Errorf("only %d of %d moves could be played", len(final.MoveHistory), len(pgn.Moves))
+	}
+
+	position := final.PositionAt(0)
+	positions := []*game.GameState{position.Clone()}
+	for _, move := range final.MoveHistory {
+		position.MakeMoveWithPromotion(move.From, move.To, move.Promotion)
+		positions = append(positions, position.Clone())
+	}
+	return positions, nil
+}
+
+// newGame fills a game record from the tags of a PGN game
+func newGame(id int, pgn *game.PGNGame, plies int) *Game {
+	tag := func(name string) string {
+		if value := pgn.Tags[name]; value != "?" {
+			return value
+		}
+		return ""
+	}
+	elo := func(name string) int {
+		rating, _ := strconv.Atoi(pgn.Tags[name])
+		return rating
+	}
+	return &Game{
+		ID:       id,
+		White:    tag("White"),
+		Black:    tag("Black"),
+		Event:    tag("Event"),
+		Site:     tag("Site"),
+		Date:     tag("Date"),
+		Round:    tag("Round"),
+		Result:   pgn.Tags["Result"],
+		ECO:      tag("ECO"),
+		Opening:  tag("Opening"),
+		Variant:  tag("Variant"),
+		WhiteElo: elo("WhiteElo"),
+		BlackElo: elo("BlackElo"),
+		Plies:    plies,
+		PGN:      pgn.String(),
+	}
+}
+
+// index stores a game and its index entries
+func index(tx *store.Tx, g *Game, positions []*game.GameState) error {
+	key := gameKey(g.ID)
+	if err := tx.PutJSON(gamesBucket, key, g); err != nil {
+		return err
+	}
+
+	entries := map[string]map[string]bool{
+		playerIndex:   {},
+		dateIndex:     {},
+		resultIndex:   {},
+		ecoIndex:      {},
+		positionIndex: {},
+		materialIndex: {},
+	}
+	for _, name := range []string{g.White, g.Black} {
+		if name != "" {
+			entries[playerIndex][strings.ToLower(name)] = true
+		}
+	}
+	if g.Date != "" {
+		entries[dateIndex][g.Date] = true
+	}
+	entries[resultIndex][g.Result] = true
+	if g.ECO != "" {
+		entries[ecoIndex][strings.ToUpper(g.ECO)] = true
+	}
+	for _, position := range positions {
+		entries[positionIndex][position.PositionKey()] = true
+		entries[materialIndex][MaterialSignature(position.Board)] = true
+	}
+
+	for bucket, values := range entries {
+		for value := range values {
+			if err := tx.Put(bucket, value+indexSeparator+key, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return indexMoves(tx, g, positions)
+}
+
+// indexMoves stores the move the game played from each position it reached,
+// the first time it reached the position
+func indexMoves(tx *store.Tx, g *Game, positions []*game.GameState) error {
+	key := gameKey(g.ID)
+	final := positions[len(positions)-1]
+	seen := map[string]bool{}
+	for ply, position := range positions[:len(positions)-1] {
+		prefix := explorerKey(position)
+		if seen[prefix] {
+			continue
+		}
+		seen[prefix] = true
+
+		move := final.MoveHistory[ply]
+		err := tx.PutJSON(movesIndex, prefix+indexSeparator+key, gameMove{
+			Move:     position.UCI(move.From, move.To, move.Promotion),
+			SAN:      position.SAN(move.From, move.To, move.Promotion),
+			Result:   g.Result,
+			WhiteElo: g.WhiteElo,
+			BlackElo: g.BlackElo,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Game returns a game by ID
+func (db *DB) Game(id int) (*Game, error) {
+	var g Game
+	err := db.db.View(func(tx *store.Tx) error {
+		return tx.GetJSON(gamesBucket, gameKey(id), &g)
+	})
+	if err == store.ErrNotFound {
+		return nil, fmt.Errorf("no game %d in the database", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// Count returns the number of games
+func (db *DB) Count() (int, error) {
+	count := 0
+	err := db.db.View(func(tx *store.Tx) error {
+		return tx.ForEach(gamesBucket, func(string, []byte) error {
+			count++
+			return nil
+		})
+	})
+	return count, err
+}
+
+// State replays the game
+func (g *Game) State() (*game.GameState, error) {
+	pgns, err := game.ParsePGN(strings.NewReader(g.PGN))
+	if err != nil {
+		return nil, err
+	}
+	if len(pgns) != 1 {
+		return nil, fmt.Errorf("game %d holds %d games", g.ID, len(pgns))
+	}
+	return pgns[0].Game()
+}
+
+// MaterialSignature describes the material on a board, such as "KRPPvKR":
+// White's pieces, then Black's, each from king to pawns
+func MaterialSignature(board [8][8]int) string {
+	order := []int{game.WhiteKing, game.WhiteQueen, game.WhiteRook, game.WhiteBishop, game.WhiteKnight, game.WhitePawn}
+	var counts [2][6]int
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			piece := board[y][x]
+			for i, white := range order {
+				if piece == white {
+					counts[game.WhitePlayer][i]++
+				} else if piece == white+6 {
+					counts[game.BlackPlayer][i]++
+				}
+			}
+		}
+	}
+
+	var sb strings.Builder
+	for player, side := range counts {
+		if player == game.BlackPlayer {
+			sb.WriteByte('v')
+		}
+		for i, count := range side {
+			sb.WriteString(strings.Repeat(string("KQRBNP"[i]), count))
+		}
+	}
+	return sb.String()
+}
+
+// gameKey keeps games in ID order
+func gameKey(id int) string {
+	return fmt.Sprintf("%010d", id)
+}
+
+// gameHash identifies a game by its players, date, round and moves, to skip
+// it when imported again
+func gameHash(pgn *game.PGNGame) string {
+	h := sha1.New()
+	for _, tag := range []string{"White", "Black", "Date", "Round", "FEN", "Variant"} {
+		fmt.Fprintf(h, "%s\x00", pgn.Tags[tag])
+	}
+	for _, move := range pgn.Moves {
+		fmt.Fprintf(h, "%s ", move.SAN)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sortGames orders games by date, most recent first, then by ID
+func sortGames(games []*Game) {
+	sort.SliceStable(games, func(i, j int) bool {
+		if games[i].Date != games[j].Date {
+			return games[i].Date > games[j].Date
+		}
+		return games[i].ID > games[j].ID
+	})
+}
@@ -0,0 +1,175 @@
+package gamedb
+
+import (
+	"strings"
+
+	"github.com/h3bzzz/go-chess/core/game"
+	"github.com/h3bzzz/go-chess/core/store"
+)
+
+// Query selects games. Empty fields match every game; the games returned
+// match all the others.
+type Query struct {
+	// Player is part of the name of either player, in any case
+	Player string
+	// DateFrom and DateTo bound the PGN date, e.g. "2023" or "2023.06.01".
+	// A partial date covers its whole year or month.
+	DateFrom string
+	DateTo   string
+	// Result is "1-0", "0-1", "1/2-1/2" or "*"
+	Result string
+	// ECO is an ECO code or its start, e.g. "B90" or "B"
+	ECO string
+	// Opening is part of the opening name, in any case
+	Opening string
+	// FEN finds the games that reached a position, ignoring move counters
+	FEN string
+	// Material finds the games that reached a material balance, written as
+	// by MaterialSignature
+	Material string
+	// Limit caps the number of games returned when positive
+	Limit int
+}
+
+// Search returns the games matching the query, most recent first
+func (db *DB) Search(q Query) ([]*Game, error) {
+	var games []*Game
+	err := db.db.View(func(tx *store.Tx) error {
+		ids, err := q.candidates(tx)
+		if err != nil {
+			return err
+		}
+
+		visit := func(key string, value []byte) error {
+			var g Game
+			if err := tx.GetJSON(gamesBucket, key, &g); err != nil {
+				return err
+			}
+			if q.matches(&g) {
+				games = append(games, &g)
+			}
+			return nil
+		}
+		if ids == nil {
+			return tx.ForEach(gamesBucket, visit)
+		}
+		for key := range ids {
+			if err := visit(key, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortGames(games)
+	if q.Limit > 0 && len(games) > q.Limit {
+		games = games[:q.Limit]
+	}
+	return games, nil
+}
+
+// candidates looks up the indexed fields of the query and returns the keys
+// of the games matching all of them, or nil when none is set
+func (q Query) candidates(tx *store.Tx) (map[string]bool, error) {
+	var lookups []func() (map[string]bool, error)
+
+	if q.Player != "" {
+		player := strings.ToLower(q.Player)
+		lookups = append(lookups, func() (map[string]bool, error) {
+			return scan(tx, playerIndex, "", func(name string) bool {
+				return strings.Contains(name, player)
+			})
+		})
+	}
+	if q.DateFrom != "" || q.DateTo != "" {
+		lookups = append(lookups, func() (map[string]bool, error) {
+			return scan(tx, dateIndex, "", q.inDateRange)
+		})
+	}
+	if q.Result != "" {
+		lookups = append(lookups, func() (map[string]bool, error) {
+			return lookup(tx, resultIndex, q.Result+indexSeparator)
+		})
+	}
+	if q.ECO != "" {
+		lookups = append(lookups, func() (map[string]bool, error) {
+			return lookup(tx, ecoIndex, strings.ToUpper(q.ECO))
+		})
+	}
+	if q.FEN != "" {
+		lookups = append(lookups, func() (map[string]bool, error) {
+			position, err := game.NewGameFromFEN(q.FEN)
+			if err != nil {
+				return nil, err
+			}
+			return lookup(tx, positionIndex, position.PositionKey()+indexSeparator)
+		})
+	}
+	if q.Material != "" {
+		lookups = append(lookups, func() (map[string]bool, error) {
+			return lookup(tx, materialIndex, q.Material+indexSeparator)
+		})
+	}
+
+	var ids map[string]bool
+	for _, lookup := range lookups {
+		found, err := lookup()
+		if err != nil {
+			return nil, err
+		}
+		if ids == nil {
+			ids = found
+			continue
+		}
+		for key := range ids {
+			if !found[key] {
+				delete(ids, key)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// matches applies the fields of the query that have no index
+func (q Query) matches(g *Game) bool {
+	return q.Opening == "" || strings.Contains(strings.ToLower(g.Opening), strings.ToLower(q.Opening))
+}
+
+// inDateRange compares the date with the bounds, cutting it to the length
+// of DateTo so that "2023" includes every date of that year
+func (q Query) inDateRange(date string) bool {
+	if q.DateFrom != "" && date < q.DateFrom {
+		return false
+	}
+	if q.DateTo != "" {
+		if len(date) > len(q.DateTo) {
+			date = date[:len(q.DateTo)]
+		}
+		if date > q.DateTo {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup returns the games of the index entries starting with prefix
+func lookup(tx *store.Tx, bucket, prefix string) (map[string]bool, error) {
+	return scan(tx, bucket, prefix, func(string) bool { return true })
+}
+
+// scan returns the games of the index entries starting with prefix whose
+// value matches
+func scan(tx *store.Tx, bucket, prefix string, match func(value string) bool) (map[string]bool, error) {
+	ids := map[string]bool{}
+	err := tx.ForEachPrefix(bucket, prefix, func(key string, _ []byte) error {
+		i := strings.LastIndex(key, indexSeparator)
+		if i >= 0 && match(key[:i]) {
+			ids[key[i+1:]] = true
+		}
+		return nil
+	})
+	return ids, err
+}
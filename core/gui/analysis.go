@@ -0,0 +1,126 @@
+package gui
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// analysisDepth is the fixed search depth used for post-game review
+const analysisDepth = 3
+
+// analyzeGame runs the engine over every position of the current game in the
+// background and shows a summary when it is done
+func (ui *ChessUI) analyzeGame() {
+	if len(ui.game.MoveHistory) == 0 {
+		dialog.ShowInformation("Game Review", "There are no moves to analyze yet.", ui.window)
+		return
+	}
+
+	snapshot := ui.game.Clone()
+
+	progress := widget.NewProgressBar()
+	progressDialog := dialog.NewCustomWithoutButtons("Analyzing game...", progress, ui.window)
+	progressDialog.Show()
+
+	go func() {
+		analysis := ai.AnalyzeGame(snapshot, ai.AnalysisOptions{Depth: analysisDepth}, func(done, total int) {
+			progress.SetValue(float64(done) / float64(total))
+		})
+		progressDialog.Hide()
+
+		ui.evalMutex.Lock()
+		if len(ui.game.MoveHistory) == len(snapshot.MoveHistory) {
+			ui.evals = append([]int{}, analysis.Evals...)
+			ui.evalGraph.SetScores(ui.evals)
+		}
+		ui.evalMutex.Unlock()
+
+		ui.showAnalysisSummary(snapshot, analysis)
+	}()
+}
+
+// showAnalysisSummary displays accuracy figures for both sides and the
+// flagged moves, with an option to save the annotated PGN
+func (ui *ChessUI) showAnalysisSummary(analyzed *game.GameState, analysis *ai.GameAnalysis) {
+	summary := widget.NewForm(
+		widget.NewFormItem("", container.NewGridWithColumns(2,
+			widget.NewLabelWithStyle("White", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle("Black", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		)),
+		summaryRow("Accuracy", analysis, func(s ai.SideSummary) string { return fmt.Sprintf("%.1f%%", s.Accuracy) }),
+		summaryRow("Avg. centipawn loss", analysis, func(s ai.SideSummary) string { return fmt.Sprintf("%.0f", s.AverageLoss) }),
+		summaryRow("Inaccuracies", analysis, func(s ai.SideSummary) string { return fmt.Sprint(s.Inaccuracies) }),
+		summaryRow("Mistakes", analysis, func(s ai.SideSummary) string { return fmt.Sprint(s.Mistakes) }),
+		summaryRow("Blunders", analysis, func(s ai.SideSummary) string { return fmt.Sprint(s.Blunders) }),
+	)
+
+	var flagged []ai.MoveAnalysis
+	for _, move := range analysis.Moves {
+		if move.Class != ai.GoodMove {
+			flagged = append(flagged, move)
+		}
+	}
+
+	flaggedList := widget.NewList(
+		func() int { return len(flagged) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			move := flagged[id]
+			number := fmt.Sprintf("%d.", move.Ply/2+1)
+			if move.Player == game.BlackPlayer {
+				number = fmt.Sprintf("%d...", move.Ply/2+1)
+			}
+			item.(*widget.Label).SetText(fmt.Sprintf("%s %s  %s (%s → %s), best was %s",
+				number, move.SAN, move.Class, ai.FormatScore(move.EvalBefore),
+				ai.FormatScore(move.EvalAfter), move.BestSAN))
+		},
+	)
+	flaggedList.OnSelected = func(id widget.ListItemID) {
+		ui.showPly(flagged[id].Ply + 1)
+	}
+
+	saveBtn := widget.NewButton("Save annotated PGN", func() {
+		pgn := analyzed.ToPGN()
+		analysis.Annotate(pgn)
+		ui.savePGN(pgn)
+	})
+
+	content := container.NewBorder(summary, saveBtn, nil, nil, flaggedList)
+	summaryDialog := dialog.NewCustom("Game Review", "Close", content, ui.window)
+	summaryDialog.Resize(fyne.NewSize(520, 480))
+	summaryDialog.Show()
+}
+
+func summaryRow(label string, analysis *ai.GameAnalysis, value func(ai.SideSummary) string) *widget.FormItem {
+	return widget.NewFormItem(label, container.NewGridWithColumns(2,
+		widget.NewLabelWithStyle(value(analysis.White), fyne.TextAlignCenter, fyne.TextStyle{}),
+		widget.NewLabelWithStyle(value(analysis.Black), fyne.TextAlignCenter, fyne.TextStyle{}),
+	))
+}
+
+// savePGN asks for a file name and writes the game to it
+func (ui *ChessUI) savePGN(pgn *game.PGNGame) {
+	dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if _, err := writer.Write([]byte(pgn.String())); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving PGN: %v\n", err)
+			dialog.ShowError(err, ui.window)
+		}
+	}, ui.window)
+}
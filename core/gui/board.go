@@ -27,12 +27,50 @@ type ChessBoard struct {
 	isDragging        bool
 	dragObj           *DraggablePiece
 	overlay           *fyne.Container
+
+	// viewGame is a read-only past position shown instead of the live game
+	viewGame       *game.GameState
+	onMoveCallback func()
+	// playerColor is the only side the user may move, or AnyPlayer
+	playerColor int
+	// watching makes the board read-only while following someone else's game
+	watching bool
+
+	// Crazyhouse pockets, shown above and below the board
+	view        fyne.CanvasObject
+	pocketBars  [2]*fyne.Container
+	pocketSlots [2][]*pocketSlot
+	dropPiece   int
+
+	// grid holds the squares in display order. flipped draws the board
+	// from Black's side, with the coordinates and pockets to match.
+	grid         *fyne.Container
+	flipped      bool
+	rankLabels   [8]*canvas.Text
+	fileLabels   [8]*canvas.Text
+	topPocket    *fyne.Container
+	bottomPocket *fyne.Container
+
+	// colors are the square and highlight colors of the theme
+	colors BoardColors
+	// premoveFrom is the piece picked for a premove, and premove the move
+	// queued to be played once it is the user's turn
+	premoveFrom *game.Position
+	premove     *queuedMove
 }
 
+// AnyPlayer lets the user move the pieces of both sides
+const AnyPlayer = -1
+
+// coordinateSize is the width of the rank labels and the height of the file
+// labels around the board
+const coordinateSize = 20
+
 type ChessSquare struct {
 	widget.Button
 	board    *ChessBoard
 	position game.Position
+	style    squareStyle
 }
 
 func NewChessSquare(board *ChessBoard, pos game.Position) *ChessSquare {
@@ -41,6 +79,8 @@ func NewChessSquare(board *ChessBoard, pos game.Position) *ChessSquare {
 		position: pos,
 	}
 	square.ExtendBaseWidget(square)
+	// The square draws its own background, so the button stays clear
+	square.Importance = widget.LowImportance
 
 	square.OnTapped = func() {
 		if board.isDragging {
@@ -61,6 +101,8 @@ type DraggablePiece struct {
 	widget.BaseWidget
 	resource fyne.Resource
 	board    *ChessBoard
+
+	lastDragPosition fyne.Position
 }
 
 func NewDraggablePiece(res fyne.Resource, board *ChessBoard) *DraggablePiece {
@@ -81,10 +123,18 @@ func (d *DraggablePiece) CreateRenderer() fyne.WidgetRenderer {
 
 func (d *DraggablePiece) Dragged(e *fyne.DragEvent) {
 	d.Move(fyne.NewPos(e.Position.X-30, e.Position.Y-30))
+	d.lastDragPosition = e.AbsolutePosition
 }
 
+// DragEnd drops the piece on the square under the pointer, or puts it back
+// when released off the board
 func (d *DraggablePiece) DragEnd() {
-	if d.board != nil {
+	if d.board == nil {
+		return
+	}
+	if pos, ok := d.board.squareAt(d.lastDragPosition); ok {
+		d.board.handleDrop(pos)
+	} else {
 		d.board.handleDragEnd()
 	}
 }
@@ -93,41 +143,20 @@ func (d *DraggablePiece) Cursor() desktop.Cursor {
 	return desktop.DefaultCursor
 }
 
-var (
-	lightSquareColor = color.RGBA{240, 217, 181, 255} // Light brown
-	darkSquareColor  = color.RGBA{181, 136, 99, 255}  // Dark brown
-
-	selectedSquareColor    = color.RGBA{186, 202, 68, 255}  // Highlighted green
-	possibleMoveColor      = color.RGBA{106, 135, 77, 255}  // Darker green
-	lastMoveHighlightColor = color.RGBA{206, 210, 107, 255} // Light yellow
-)
-
-// Create a custom style method to apply square colors
-func (b *ChessBoard) applySquareStyle(square *ChessSquare, isSelected bool, isPossibleMove bool) {
-	isLightSquare := (square.position.X+square.position.Y)%2 == 0
-
-	if isSelected {
-		square.Importance = widget.HighImportance
-	} else if isPossibleMove {
-		square.Importance = widget.WarningImportance
-	} else if isLightSquare {
-		square.Importance = widget.LowImportance
-	} else {
-		square.Importance = widget.MediumImportance
-	}
-}
+var coordinateColor = color.RGBA{140, 140, 140, 255} // Grey
 
 func NewChessBoard(chessGame *game.GameState, theme string) *ChessBoard {
 	board := &ChessBoard{
-		game:  chessGame,
-		theme: theme,
+		game:        chessGame,
+		theme:       theme,
+		playerColor: AnyPlayer,
 	}
 
 	board.container = container.NewWithoutLayout()
-	mainBoard := container.NewGridWithColumns(8)
+	board.grid = container.NewGridWithColumns(8)
 	board.overlay = container.NewWithoutLayout()
 
-	board.container.Add(mainBoard)
+	board.container.Add(board.grid)
 	board.container.Add(board.overlay)
 
 	board.pieceManager = NewPieceManager(theme)
@@ -141,6 +170,7 @@ func NewChessBoard(chessGame *game.GameState, theme string) *ChessBoard {
 	} else if theme == "pink" {
 		folder = "chess_pink"
 	}
+	board.colors = boardColors(folder)
 
 	assetDir := "assets/" + folder
 	fmt.Printf("Checking asset directory: %s\n", assetDir)
@@ -157,47 +187,72 @@ func NewChessBoard(chessGame *game.GameState, theme string) *ChessBoard {
 	for y := 7; y >= 0; y-- {
 		for x := 0; x < 8; x++ {
 			square := NewChessSquare(board, game.Position{X: x, Y: y})
-
-			isLightSquare := (x+y)%2 == 0
-			if isLightSquare {
-				square.Importance = widget.LowImportance
-			} else {
-				square.Importance = widget.MediumImportance
-			}
-
 			square.Resize(fyne.NewSize(60, 60))
 
 			board.squares[y][x] = square
-			mainBoard.Add(square)
 		}
 	}
+	board.placeSquares()
 
 	boardSize := fyne.NewSize(480, 480) // 8 squares * 60px
-	mainBoard.Resize(boardSize)
+	board.grid.Resize(boardSize)
 	board.container.Resize(boardSize)
 
+	// Rank labels on the left and file labels below, lined up with the
+	// squares
+	ranks := container.NewGridWithRows(8)
+	fileNames := container.NewGridWithColumns(8)
+	for i := 0; i < 8; i++ {
+		board.rankLabels[i] = canvas.NewText("", coordinateColor)
+		board.fileLabels[i] = canvas.NewText("", coordinateColor)
+		ranks.Add(container.NewCenter(board.rankLabels[i]))
+		fileNames.Add(container.NewCenter(board.fileLabels[i]))
+	}
+	board.labelCoordinates()
+	ranks.Resize(fyne.NewSize(coordinateSize, boardSize.Height))
+	board.container.Move(fyne.NewPos(coordinateSize, 0))
+	fileNames.Resize(fyne.NewSize(boardSize.Width, coordinateSize))
+	fileNames.Move(fyne.NewPos(coordinateSize, boardSize.Height))
+	frameSize := canvas.NewRectangle(color.Transparent)
+	frameSize.SetMinSize(boardSize.AddWidthHeight(coordinateSize, coordinateSize))
+	frame := container.NewWithoutLayout(frameSize, ranks, board.container, fileNames)
+
+	for _, player := range []int{game.WhitePlayer, game.BlackPlayer} {
+		board.pocketBars[player], board.pocketSlots[player] = board.newPocket(player)
+	}
+	board.topPocket = container.NewStack(board.pocketBars[game.BlackPlayer])
+	board.bottomPocket = container.NewStack(board.pocketBars[game.WhitePlayer])
+	board.view = container.NewBorder(board.topPocket, board.bottomPocket, nil, nil, frame)
+
 	board.UpdateDisplay()
 
 	return board
 }
 
+// handleMouseDown picks up a piece of the side to move, or of the user's
+// side to premove it during the opponent's turn
 func (b *ChessBoard) handleMouseDown(pos game.Position) {
 	piece := b.game.GetPieceAtPosition(pos)
 	if piece == game.Empty {
 		return
 	}
 
-	isWhitePiece := game.IsPieceWhite(piece)
-	if (isWhitePiece && b.game.CurrentTurn != game.WhitePlayer) ||
-		(!isWhitePiece && b.game.CurrentTurn != game.BlackPlayer) {
+	var moves []game.Position
+	switch {
+	case b.canMove() && belongsTo(piece, b.game.CurrentTurn):
+		moves = b.game.GetPossibleMoves(pos)
+	case b.canPremove() && belongsTo(piece, b.playerColor):
+		// The opponent's reply decides which moves are legal
+	default:
 		return
 	}
 
+	b.dropPiece = game.Empty
 	b.draggedPiece = piece
 	b.dragStartPosition = pos
 	b.isDragging = true
 
-	b.highlightedMoves = b.game.GetPossibleMoves(pos)
+	b.highlightedMoves = moves
 	b.highlightPossibleMoves()
 
 	resource := b.pieceManager.GetResource(piece)
@@ -226,48 +281,50 @@ func (b *ChessBoard) handleDragEnd() {
 	b.UpdateDisplay()
 }
 
+// handleDrop finishes a drag on targetPos. Releasing the piece where it
+// was picked up counts as a click on that square, so clicking and dragging
+// select, move and premove alike.
 func (b *ChessBoard) handleDrop(targetPos game.Position) {
 	if !b.isDragging {
 		return
 	}
+	from := b.dragStartPosition
+	b.handleDragEnd()
 
-	isValidMove := false
-	for _, move := range b.highlightedMoves {
-		if move.X == targetPos.X && move.Y == targetPos.Y {
-			isValidMove = true
-			break
+	switch {
+	case targetPos == from:
+		b.handleSquareClick(targetPos)
+	case b.canMove():
+		b.game.SelectedPosition = nil
+		if b.game.IsLegal(from, targetPos, game.Empty) && b.game.MakeMove(from, targetPos) != game.InvalidMove {
+			b.UpdateDisplay()
+			b.notifyMove()
 		}
+	case b.canPremove():
+		b.setPremove(from, targetPos)
 	}
-
-	if isValidMove {
-		b.game.MakeMove(b.dragStartPosition, targetPos)
-	}
-
-	b.handleDragEnd()
 }
 
 func (b *ChessBoard) highlightPossibleMoves() {
-	for _, move := range b.highlightedMoves {
-		b.applySquareStyle(b.squares[move.Y][move.X], false, true)
-	}
+	b.refreshHighlights()
 }
 
 func (b *ChessBoard) clearHighlightedMoves() {
-	for _, move := range b.highlightedMoves {
-		b.applySquareStyle(b.squares[move.Y][move.X], false, false)
-	}
 	b.highlightedMoves = nil
+	b.refreshHighlights()
 }
 
 func (b *ChessBoard) GetContainer() fyne.CanvasObject {
-	return b.container
+	return b.view
 }
 
 func (b *ChessBoard) UpdateDisplay() {
+	displayed := b.displayedGame()
+
 	for y := 0; y < 8; y++ {
 		for x := 0; x < 8; x++ {
 			pos := game.Position{X: x, Y: y}
-			piece := b.game.GetPieceAtPosition(pos)
+			piece := displayed.GetPieceAtPosition(pos)
 
 			resource := b.pieceManager.GetResource(piece)
 			if resource != nil {
@@ -275,31 +332,158 @@ func (b *ChessBoard) UpdateDisplay() {
 			} else {
 				b.squares[y][x].SetIcon(nil)
 			}
-
-			isSelected := b.game.SelectedPosition != nil &&
-				b.game.SelectedPosition.X == x &&
-				b.game.SelectedPosition.Y == y
-
-			isPossibleMove := false
-			if b.game.SelectedPosition != nil {
-				moves := b.game.GetPossibleMoves(*b.game.SelectedPosition)
-				for _, move := range moves {
-					if move.X == x && move.Y == y {
-						isPossibleMove = true
-						break
-					}
-				}
-			}
-
-			b.applySquareStyle(b.squares[y][x], isSelected, isPossibleMove)
 		}
 	}
+
+	b.refreshHighlights()
+	b.updatePockets(displayed)
 }
 
 func (b *ChessBoard) handleSquareClick(pos game.Position) {
+	if !b.canMove() {
+		if b.canPremove() {
+			b.selectPremove(pos)
+		}
+		return
+	}
+	if b.dropPiece != game.Empty {
+		b.dropAt(pos)
+		return
+	}
+
+	movesBefore := len(b.game.MoveHistory)
 	b.game.SelectPosition(pos)
 
 	b.UpdateDisplay()
+
+	if len(b.game.MoveHistory) != movesBefore {
+		b.notifyMove()
+	}
+}
+
+// SetMoveCallback registers a function called after the user makes a move on the board
+func (b *ChessBoard) SetMoveCallback(callback func()) {
+	b.onMoveCallback = callback
+}
+
+func (b *ChessBoard) notifyMove() {
+	if b.onMoveCallback != nil {
+		b.onMoveCallback()
+	}
+}
+
+// SetViewPosition shows a read-only position instead of the live game.
+// Passing nil returns the board to the live game.
+func (b *ChessBoard) SetViewPosition(position *game.GameState) {
+	b.viewGame = position
+	b.UpdateDisplay()
+}
+
+// SetPlayerColor limits the user to moving one side's pieces, as in a game
+// against a remote opponent, and turns that side to the bottom. AnyPlayer
+// lifts the limit and leaves the board as it is.
+func (b *ChessBoard) SetPlayerColor(player int) {
+	b.playerColor = player
+	if player != AnyPlayer {
+		b.SetFlipped(player == game.BlackPlayer)
+	}
+	b.clearPremove()
+	b.game.SelectedPosition = nil
+	b.dropPiece = game.Empty
+	b.clearHighlightedMoves()
+	b.UpdateDisplay()
+}
+
+// SetWatchMode makes the board read-only, for following a game played by
+// others. Past positions can still be shown with SetViewPosition.
+func (b *ChessBoard) SetWatchMode(watching bool) {
+	b.watching = watching
+	b.clearPremove()
+	b.game.SelectedPosition = nil
+	b.dropPiece = game.Empty
+	b.clearHighlightedMoves()
+	b.UpdateDisplay()
+}
+
+// SetFlipped draws the board from Black's side when flipped is set, and
+// from White's otherwise
+func (b *ChessBoard) SetFlipped(flipped bool) {
+	if flipped == b.flipped {
+		return
+	}
+	b.flipped = flipped
+	b.placeSquares()
+	b.labelCoordinates()
+
+	top, bottom := b.pocketBars[game.BlackPlayer], b.pocketBars[game.WhitePlayer]
+	if flipped {
+		top, bottom = bottom, top
+	}
+	b.topPocket.Objects = []fyne.CanvasObject{top}
+	b.bottomPocket.Objects = []fyne.CanvasObject{bottom}
+	b.topPocket.Refresh()
+	b.bottomPocket.Refresh()
+}
+
+// Flip turns the board around
+func (b *ChessBoard) Flip() {
+	b.SetFlipped(!b.flipped)
+}
+
+// IsFlipped reports whether the board is drawn from Black's side
+func (b *ChessBoard) IsFlipped() bool {
+	return b.flipped
+}
+
+// placeSquares fills the grid in display order: from the eighth rank down
+// and the a-file across, or the reverse when flipped
+func (b *ChessBoard) placeSquares() {
+	b.grid.Objects = nil
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			pos := b.squareForCell(row, col)
+			b.grid.Add(b.squares[pos.Y][pos.X])
+		}
+	}
+	b.grid.Refresh()
+}
+
+// squareForCell returns the board square shown at a row and column of the
+// grid, counted from the top left
+func (b *ChessBoard) squareForCell(row, col int) game.Position {
+	if b.flipped {
+		return game.Position{X: 7 - col, Y: row}
+	}
+	return game.Position{X: col, Y: 7 - row}
+}
+
+// labelCoordinates writes the rank and file names next to the squares
+func (b *ChessBoard) labelCoordinates() {
+	for i := 0; i < 8; i++ {
+		pos := b.squareForCell(i, i)
+		b.rankLabels[i].Text = string(rune('1' + pos.Y))
+		b.fileLabels[i].Text = string(rune('a' + pos.X))
+		b.rankLabels[i].Refresh()
+		b.fileLabels[i].Refresh()
+	}
+}
+
+// canMove reports whether the user may move on the board right now
+func (b *ChessBoard) canMove() bool {
+	return b.viewGame == nil && !b.watching &&
+		(b.playerColor == AnyPlayer || b.playerColor == b.game.CurrentTurn)
+}
+
+// IsViewingHistory reports whether the board is showing a past position
+func (b *ChessBoard) IsViewingHistory() bool {
+	return b.viewGame != nil
+}
+
+func (b *ChessBoard) displayedGame() *game.GameState {
+	if b.viewGame != nil {
+		return b.viewGame
+	}
+	return b.game
 }
 
 func (b *ChessBoard) ChangeTheme(theme string) error {
@@ -310,6 +494,7 @@ func (b *ChessBoard) ChangeTheme(theme string) error {
 	fmt.Printf("ChessBoard: Changing theme from '%s' to '%s'\n", b.theme, theme)
 
 	b.theme = theme
+	b.SetColors(boardColors(theme))
 
 	err := b.pieceManager.ChangeTheme(theme)
 	if err != nil {
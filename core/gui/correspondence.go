@@ -0,0 +1,346 @@
+package gui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/correspondence"
+	"github.com/h3bzzz/go-chess/core/game"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// correspondenceDays are the time controls offered for new correspondence
+// games, in days per move
+var correspondenceDays = []string{"1", "2", "3", "5", "7", "14"}
+
+// correspondenceDesk is the game picker window of the correspondence games
+// and the game open on the board
+type correspondenceDesk struct {
+	manager  *correspondence.Manager
+	window   fyne.Window
+	status   *widget.Label
+	games    []*correspondence.Game
+	list     *widget.List
+	selected int
+
+	// gameID is the game on the board, 0 when there is none
+	gameID int
+}
+
+// showingCorrespondence reports whether the board shows a correspondence
+// game
+func (ui *ChessUI) showingCorrespondence() bool {
+	return ui.correspondence != nil && ui.correspondence.gameID != 0
+}
+
+// showCorrespondence opens the game picker, opening the database first
+func (ui *ChessUI) showCorrespondence() {
+	if ui.correspondence != nil {
+		ui.refreshCorrespondence()
+		ui.correspondence.window.Show()
+		return
+	}
+
+	path, err := correspondence.DefaultPath()
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	manager, err := correspondence.Open(path)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("opening correspondence games: %w", err), ui.window)
+		return
+	}
+
+	d := &correspondenceDesk{
+		manager:  manager,
+		window:   fyne.CurrentApp().NewWindow("Correspondence"),
+		status:   widget.NewLabel(""),
+		selected: -1,
+	}
+	ui.correspondence = d
+
+	d.list = widget.NewList(
+		func() int { return len(d.games) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(d.describe(d.games[id]))
+		},
+	)
+	d.list.OnSelected = func(id widget.ListItemID) { d.selected = id }
+
+	buttons := container.NewHBox(
+		widget.NewButton("New Game", ui.newCorrespondenceGame),
+		widget.NewButton("Open", func() {
+			if g, ok := d.selectedGame(); ok {
+				ui.openCorrespondenceGame(g.ID)
+			}
+		}),
+		widget.NewButton("Resign", ui.resignCorrespondence),
+		layout.NewSpacer(),
+		widget.NewButton("Vacation", ui.takeVacation),
+		widget.NewButton("Refresh", ui.refreshCorrespondence),
+	)
+
+	d.window.SetContent(container.NewBorder(d.status, buttons, nil, nil, d.list))
+	d.window.SetCloseIntercept(d.window.Hide)
+	d.window.Resize(fyne.NewSize(560, 420))
+	d.window.Show()
+
+	ui.refreshCorrespondence()
+}
+
+// refreshCorrespondence forfeits the games whose deadline has passed and
+// lists the games again
+func (ui *ChessUI) refreshCorrespondence() {
+	d := ui.correspondence
+	timedOut, err := d.manager.CheckTimeouts()
+	if err != nil {
+		d.status.SetText(err.Error())
+	}
+	games, err := d.manager.Games()
+	if err != nil {
+		d.status.SetText(err.Error())
+		return
+	}
+
+	d.games = games
+	d.selected = -1
+	d.list.UnselectAll()
+	d.list.Refresh()
+
+	if len(timedOut) > 0 {
+		d.status.SetText(fmt.Sprintf("%d game(s) lost on time", len(timedOut)))
+	} else if err == nil {
+		d.status.SetText(fmt.Sprintf("%d game(s)", len(games)))
+	}
+	for _, g := range timedOut {
+		if g.ID == d.gameID {
+			ui.openCorrespondenceGame(g.ID)
+		}
+	}
+}
+
+// newCorrespondenceGame asks for the players and time control of a new game
+// of the variant chosen in the main window
+func (ui *ChessUI) newCorrespondenceGame() {
+	d := ui.correspondence
+	white := widget.NewEntry()
+	white.SetPlaceHolder("White player")
+	black := widget.NewEntry()
+	black.SetPlaceHolder("Black player")
+	days := widget.NewSelect(correspondenceDays, nil)
+	days.SetSelected("3")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("White", white),
+		widget.NewFormItem("Black", black),
+		widget.NewFormItem("Days per move", days),
+	}
+	dialog.ShowForm("New Correspondence Game", "Start", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		perMove, _ := strconv.Atoi(days.Selected)
+		g, err := d.manager.NewGame(white.Text, black.Text, ui.variantSelect.Selected, perMove)
+		if err != nil {
+			dialog.ShowError(err, d.window)
+			return
+		}
+		ui.refreshCorrespondence()
+		ui.openCorrespondenceGame(g.ID)
+	}, d.window)
+}
+
+// openCorrespondenceGame puts a stored game on the main board
+func (ui *ChessUI) openCorrespondenceGame(id int) {
+	d := ui.correspondence
+	if ui.showingOnline() {
+		dialog.ShowInformation("Correspondence", "Leave the online game first.", d.window)
+		return
+	}
+
+	g, err := d.manager.Game(id)
+	if err == nil {
+		var position *game.GameState
+		if position, err = g.State(); err == nil {
+			ui.aiEnabledCheck.SetChecked(false)
+			ui.loadGame(position)
+			ui.stopTimer()
+			ui.board.SetPlayerColor(AnyPlayer)
+			d.gameID = id
+			ui.updateStatus()
+			return
+		}
+	}
+	dialog.ShowError(err, d.window)
+}
+
+// saveCorrespondenceMove stores the move just made on the board. A move the
+// store refuses is taken back by reloading the game.
+func (ui *ChessUI) saveCorrespondenceMove() {
+	if !ui.showingCorrespondence() {
+		return
+	}
+	d := ui.correspondence
+	ply := len(ui.game.MoveHistory) - 1
+	last := ui.game.MoveHistory[ply]
+	move := ui.game.PositionAt(ply).UCI(last.From, last.To, last.Promotion)
+
+	g, err := d.manager.Move(d.gameID, move)
+	if err != nil {
+		// After a time forfeit this shows the lost game
+		ui.openCorrespondenceGame(d.gameID)
+		dialog.ShowError(err, ui.window)
+	} else if g.Over() {
+		ui.game.Termination = g.Termination
+		ui.updateStatus()
+	}
+	ui.refreshCorrespondence()
+}
+
+// resignCorrespondence asks which player of the selected game resigns
+func (ui *ChessUI) resignCorrespondence() {
+	d := ui.correspondence
+	g, ok := d.selectedGame()
+	if !ok || g.Over() {
+		return
+	}
+
+	player := widget.NewRadioGroup([]string{g.White, g.Black}, nil)
+	items := []*widget.FormItem{widget.NewFormItem("Resigning", player)}
+	dialog.ShowForm("Resign", "Resign", "Cancel", items, func(ok bool) {
+		if !ok || player.Selected == "" {
+			return
+		}
+		color := game.WhitePlayer
+		if player.Selected == g.Black {
+			color = game.BlackPlayer
+		}
+		if _, err := d.manager.Resign(g.ID, color); err != nil {
+			dialog.ShowError(err, d.window)
+		}
+		if g.ID == d.gameID {
+			ui.openCorrespondenceGame(g.ID)
+		}
+		ui.refreshCorrespondence()
+	}, d.window)
+}
+
+// takeVacation asks for a player's vacation, which stops the clocks of
+// their games
+func (ui *ChessUI) takeVacation() {
+	d := ui.correspondence
+	player := widget.NewEntry()
+	if g, ok := d.selectedGame(); ok {
+		turn, _ := g.Turn()
+		player.SetText(g.Player(turn))
+	}
+	from := widget.NewEntry()
+	from.SetText(time.Now().Format("2006-01-02"))
+	days := widget.NewEntry()
+	days.SetText("7")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Player", player),
+		widget.NewFormItem("From", from),
+		widget.NewFormItem("Days", days),
+	}
+	dialog.ShowForm("Vacation", "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		start, err := time.ParseInLocation("2006-01-02", from.Text, time.Local)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("the date must look like 2024-07-31"), d.window)
+			return
+		}
+		n, err := strconv.Atoi(days.Text)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("days must be a number"), d.window)
+			return
+		}
+		if err := d.manager.TakeVacation(player.Text, start, n); err != nil {
+			dialog.ShowError(err, d.window)
+			return
+		}
+		ui.refreshCorrespondence()
+		ui.updateStatus()
+	}, d.window)
+}
+
+// closeCorrespondenceGame leaves the correspondence game on the board
+func (ui *ChessUI) closeCorrespondenceGame() {
+	if ui.correspondence != nil {
+		ui.correspondence.gameID = 0
+	}
+}
+
+// showDeadline puts the time left for the move in the clock of the side to
+// move
+func (ui *ChessUI) showDeadline() {
+	d := ui.correspondence
+	g, err := d.manager.Game(d.gameID)
+	if err != nil || g.Over() {
+		return
+	}
+	deadline, err := d.manager.Deadline(g)
+	if err != nil {
+		return
+	}
+
+	left := formatDays(time.Until(deadline))
+	if ui.game.CurrentTurn == game.WhitePlayer {
+		ui.whiteTime.SetText(left)
+		ui.blackTime.SetText("")
+	} else {
+		ui.whiteTime.SetText("")
+		ui.blackTime.SetText(left)
+	}
+}
+
+// selectedGame returns the game selected in the picker
+func (d *correspondenceDesk) selectedGame() (*correspondence.Game, bool) {
+	if d.selected < 0 || d.selected >= len(d.games) {
+		return nil, false
+	}
+	return d.games[d.selected], true
+}
+
+// describe summarizes a game for the picker
+func (d *correspondenceDesk) describe(g *correspondence.Game) string {
+	text := fmt.Sprintf("#%d %s - %s, %s, %d days/move, move %d", g.ID, g.White, g.Black, g.Variant, g.DaysPerMove, len(g.Moves)/2+1)
+	if g.Over() {
+		return fmt.Sprintf("%s: %s (%s)", text, g.Result, g.Termination)
+	}
+
+	turn, err := g.Turn()
+	if err != nil {
+		return text + ": " + err.Error()
+	}
+	deadline, err := d.manager.Deadline(g)
+	if err != nil {
+		return text + ": " + err.Error()
+	}
+	text += fmt.Sprintf(": %s to move, %s left", g.Player(turn), formatDays(time.Until(deadline)))
+	if away, _ := d.manager.OnVacation(g.Player(turn), time.Now()); away {
+		text += " (on vacation)"
+	}
+	return text
+}
+
+// formatDays writes a correspondence time limit in days and hours
+func formatDays(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	days := int(d / correspondence.Day)
+	hours := int(d%correspondence.Day) / int(time.Hour)
+	return fmt.Sprintf("%dd %02dh", days, hours)
+}
@@ -0,0 +1,252 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/h3bzzz/go-chess/core/gamedb"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// searchLimit caps the games listed by the game browser
+const searchLimit = 500
+
+// gameBrowser is the window searching the game database
+type gameBrowser struct {
+	db       *gamedb.DB
+	window   fyne.Window
+	status   *widget.Label
+	games    []*gamedb.Game
+	list     *widget.List
+	selected int
+
+	player   *widget.Entry
+	dateFrom *widget.Entry
+	dateTo   *widget.Entry
+	result   *widget.Select
+	eco      *widget.Entry
+	opening  *widget.Entry
+	fen      *widget.Entry
+	material *widget.Entry
+}
+
+// openGameDB returns the game database, opening it the first time
+func (ui *ChessUI) openGameDB() (*gamedb.DB, error) {
+	if ui.gameDB != nil {
+		return ui.gameDB, nil
+	}
+	path, err := gamedb.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	db, err := gamedb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening the game database: %w", err)
+	}
+	ui.gameDB = db
+	return db, nil
+}
+
+// showGameDatabase opens the game browser, opening the database first
+func (ui *ChessUI) showGameDatabase() {
+	if ui.database != nil {
+		ui.database.window.Show()
+		return
+	}
+
+	db, err := ui.openGameDB()
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+
+	b := &gameBrowser{
+		db:       db,
+		window:   fyne.CurrentApp().NewWindow("Game Database"),
+		status:   widget.NewLabel(""),
+		selected: -1,
+		player:   widget.NewEntry(),
+		dateFrom: widget.NewEntry(),
+		dateTo:   widget.NewEntry(),
+		result:   widget.NewSelect([]string{"Any", "1-0", "0-1", "1/2-1/2", "*"}, nil),
+		eco:      widget.NewEntry(),
+		opening:  widget.NewEntry(),
+		fen:      widget.NewEntry(),
+		material: widget.NewEntry(),
+	}
+	ui.database = b
+
+	b.dateFrom.SetPlaceHolder("2020.01.01")
+	b.dateTo.SetPlaceHolder("2024")
+	b.result.SetSelected("Any")
+	b.eco.SetPlaceHolder("B90")
+	b.fen.SetPlaceHolder("FEN")
+	b.material.SetPlaceHolder("KRPvKR")
+
+	b.list = widget.NewList(
+		func() int { return len(b.games) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(describeDatabaseGame(b.games[id]))
+		},
+	)
+	b.list.OnSelected = func(id widget.ListItemID) { b.selected = id }
+
+	filters := container.NewGridWithColumns(4,
+		widget.NewLabel("Player"), b.player,
+		widget.NewLabel("Result"), b.result,
+		widget.NewLabel("From date"), b.dateFrom,
+		widget.NewLabel("To date"), b.dateTo,
+		widget.NewLabel("ECO"), b.eco,
+		widget.NewLabel("Opening"), b.opening,
+		widget.NewLabel("Position"), b.fen,
+		widget.NewLabel("Material"), b.material,
+	)
+	searchButtons := container.NewHBox(
+		widget.NewButton("Search", ui.searchGames),
+		widget.NewButton("Board Position", func() {
+			b.fen.SetText(ui.board.displayedGame().FEN())
+		}),
+		widget.NewButton("Board Material", func() {
+			b.material.SetText(gamedb.MaterialSignature(ui.board.displayedGame().Board))
+		}),
+		widget.NewButton("Clear", func() {
+			for _, entry := range []*widget.Entry{b.player, b.dateFrom, b.dateTo, b.eco, b.opening, b.fen, b.material} {
+				entry.SetText("")
+			}
+			b.result.SetSelected("Any")
+			ui.searchGames()
+		}),
+	)
+	buttons := container.NewHBox(
+		widget.NewButton("Import PGN", ui.importPGN),
+		layout.NewSpacer(),
+		widget.NewButton("Open", func() {
+			if b.selected >= 0 && b.selected < len(b.games) {
+				ui.openDatabaseGame(b.games[b.selected])
+			}
+		}),
+	)
+
+	b.window.SetContent(container.NewBorder(
+		container.NewVBox(filters, searchButtons),
+		container.NewVBox(b.status, buttons),
+		nil, nil, b.list,
+	))
+	b.window.SetCloseIntercept(b.window.Hide)
+	b.window.Resize(fyne.NewSize(640, 560))
+	b.window.Show()
+
+	ui.searchGames()
+}
+
+// searchGames lists the games matching the filters
+func (ui *ChessUI) searchGames() {
+	b := ui.database
+	query := gamedb.Query{
+		Player:   b.player.Text,
+		DateFrom: b.dateFrom.Text,
+		DateTo:   b.dateTo.Text,
+		ECO:      b.eco.Text,
+		Opening:  b.opening.Text,
+		FEN:      b.fen.Text,
+		Material: b.material.Text,
+		Limit:    searchLimit,
+	}
+	if b.result.Selected != "Any" {
+		query.Result = b.result.Selected
+	}
+
+	games, err := b.db.Search(query)
+	if err != nil {
+		b.status.SetText("Search failed: " + err.Error())
+		return
+	}
+	b.games = games
+	b.selected = -1
+	b.list.UnselectAll()
+	b.list.Refresh()
+
+	if len(games) == searchLimit {
+		b.status.SetText(fmt.Sprintf("Showing the first %d games", searchLimit))
+	} else {
+		b.status.SetText(fmt.Sprintf("%d game(s) found", len(games)))
+	}
+}
+
+// importPGN asks for a PGN file and adds its games to the database
+func (ui *ChessUI) importPGN() {
+	b := ui.database
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, b.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+
+		b.status.SetText("Importing " + path + "...")
+		go func() {
+			result, err := b.db.ImportFile(path)
+			if err != nil {
+				b.status.SetText("Import failed: " + err.Error())
+				return
+			}
+			for _, failure := range result.Failed {
+				fmt.Printf("Skipped %v\n", failure)
+			}
+			ui.searchGames()
+			ui.updateExplorer()
+			b.status.SetText(fmt.Sprintf("Imported %d game(s), %d already in the database, %d unreadable",
+				result.Imported, result.Duplicates, len(result.Failed)))
+		}()
+	}, b.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".pgn"}))
+	open.Show()
+}
+
+// openDatabaseGame puts a game from the database on the board
+func (ui *ChessUI) openDatabaseGame(g *gamedb.Game) {
+	if ui.showingOnline() {
+		dialog.ShowInformation("Game Database", "Leave the online game first.", ui.database.window)
+		return
+	}
+	position, err := g.State()
+	if err != nil {
+		dialog.ShowError(err, ui.database.window)
+		return
+	}
+	ui.aiEnabledCheck.SetChecked(false)
+	ui.stopWatching()
+	ui.loadGame(position)
+}
+
+// describeDatabaseGame summarizes a game for the browser list
+func describeDatabaseGame(g *gamedb.Game) string {
+	white, black := g.White, g.Black
+	if g.WhiteElo > 0 {
+		white += fmt.Sprintf(" (%d)", g.WhiteElo)
+	}
+	if g.BlackElo > 0 {
+		black += fmt.Sprintf(" (%d)", g.BlackElo)
+	}
+	text := fmt.Sprintf("%s - %s  %s", white, black, g.Result)
+	if g.Date != "" {
+		text += "  " + g.Date
+	}
+	if g.ECO != "" {
+		text += "  " + g.ECO
+	}
+	if g.Opening != "" {
+		text += " " + g.Opening
+	}
+	return text + fmt.Sprintf(", %d moves", (g.Plies+1)/2)
+}
@@ -0,0 +1,215 @@
+package gui
+
+import (
+	"image/color"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+)
+
+var (
+	evalWhiteColor    = color.RGBA{240, 240, 240, 255}
+	evalBlackColor    = color.RGBA{50, 50, 50, 255}
+	evalLineColor     = color.RGBA{186, 202, 68, 255}
+	evalMidlineColor  = color.RGBA{128, 128, 128, 255}
+	evalSelectedColor = color.RGBA{206, 110, 60, 255}
+)
+
+// EvalBar is a vertical bar showing who is better, filled with White from
+// the bottom in proportion to White's winning chances
+type EvalBar struct {
+	widget.BaseWidget
+	score int
+}
+
+// NewEvalBar creates an evaluation bar for an equal position
+func NewEvalBar() *EvalBar {
+	bar := &EvalBar{}
+	bar.ExtendBaseWidget(bar)
+	return bar
+}
+
+// SetScore updates the bar with a score in centipawns from White's point of view
+func (e *EvalBar) SetScore(score int) {
+	e.score = score
+	e.Refresh()
+}
+
+func (e *EvalBar) CreateRenderer() fyne.WidgetRenderer {
+	r := &evalBarRenderer{
+		bar:   e,
+		black: canvas.NewRectangle(evalBlackColor),
+		white: canvas.NewRectangle(evalWhiteColor),
+		label: canvas.NewText("", evalMidlineColor),
+	}
+	r.label.TextSize = 10
+	r.Refresh()
+	return r
+}
+
+type evalBarRenderer struct {
+	bar   *EvalBar
+	black *canvas.Rectangle
+	white *canvas.Rectangle
+	label *canvas.Text
+}
+
+func (r *evalBarRenderer) Layout(size fyne.Size) {
+	whiteHeight := size.Height * float32(ai.WinProbability(r.bar.score))
+
+	r.black.Move(fyne.NewPos(0, 0))
+	r.black.Resize(size)
+	r.white.Move(fyne.NewPos(0, size.Height-whiteHeight))
+	r.white.Resize(fyne.NewSize(size.Width, whiteHeight))
+
+	labelSize := r.label.MinSize()
+	labelY := size.Height - labelSize.Height
+	if r.bar.score < 0 {
+		labelY = 0
+	}
+	r.label.Move(fyne.NewPos((size.Width-labelSize.Width)/2, labelY))
+}
+
+func (r *evalBarRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(28, 480)
+}
+
+func (r *evalBarRenderer) Refresh() {
+	r.label.Text = ai.FormatScore(r.bar.score)
+	r.Layout(r.bar.Size())
+	canvas.Refresh(r.bar)
+}
+
+func (r *evalBarRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.black, r.white, r.label}
+}
+
+func (r *evalBarRenderer) Destroy() {}
+
+// EvalGraph plots the evaluation after every move of the game. Tapping the
+// graph selects the move closest to the tap.
+type EvalGraph struct {
+	widget.BaseWidget
+	scores   []int
+	selected int
+
+	OnSelected func(ply int)
+}
+
+// NewEvalGraph creates an empty evaluation graph
+func NewEvalGraph() *EvalGraph {
+	graph := &EvalGraph{selected: -1}
+	graph.ExtendBaseWidget(graph)
+	return graph
+}
+
+// SetScores replaces the plotted scores. scores[i] is the evaluation from
+// White's point of view after i half-moves.
+func (g *EvalGraph) SetScores(scores []int) {
+	g.scores = append([]int{}, scores...)
+	g.Refresh()
+}
+
+// SetSelected marks the given ply on the graph, or clears the mark if ply is negative
+func (g *EvalGraph) SetSelected(ply int) {
+	g.selected = ply
+	g.Refresh()
+}
+
+func (g *EvalGraph) Tapped(e *fyne.PointEvent) {
+	if len(g.scores) == 0 || g.OnSelected == nil {
+		return
+	}
+
+	ply := 0
+	if len(g.scores) > 1 {
+		step := g.Size().Width / float32(len(g.scores)-1)
+		ply = int(e.Position.X/step + 0.5)
+	}
+	if ply >= len(g.scores) {
+		ply = len(g.scores) - 1
+	}
+	g.OnSelected(ply)
+}
+
+func (g *EvalGraph) CreateRenderer() fyne.WidgetRenderer {
+	r := &evalGraphRenderer{
+		graph:      g,
+		background: canvas.NewRectangle(evalBlackColor),
+		midline:    canvas.NewLine(evalMidlineColor),
+		marker:     canvas.NewLine(evalSelectedColor),
+	}
+	r.marker.StrokeWidth = 2
+	r.Refresh()
+	return r
+}
+
+type evalGraphRenderer struct {
+	graph      *EvalGraph
+	background *canvas.Rectangle
+	midline    *canvas.Line
+	marker     *canvas.Line
+	segments   []*canvas.Line
+}
+
+func (r *evalGraphRenderer) Layout(size fyne.Size) {
+	r.background.Resize(size)
+	r.midline.Position1 = fyne.NewPos(0, size.Height/2)
+	r.midline.Position2 = fyne.NewPos(size.Width, size.Height/2)
+
+	scores := r.graph.scores
+	step := float32(0)
+	if len(scores) > 1 {
+		step = size.Width / float32(len(scores)-1)
+	}
+	point := func(ply int) fyne.Position {
+		y := size.Height * (1 - float32(ai.WinProbability(scores[ply])))
+		return fyne.NewPos(float32(ply)*step, y)
+	}
+
+	for i, segment := range r.segments {
+		segment.Position1 = point(i)
+		segment.Position2 = point(i + 1)
+	}
+
+	selected := r.graph.selected
+	r.marker.Hidden = selected < 0 || selected >= len(scores)
+	if !r.marker.Hidden {
+		x := float32(selected) * step
+		r.marker.Position1 = fyne.NewPos(x, 0)
+		r.marker.Position2 = fyne.NewPos(x, size.Height)
+	}
+}
+
+func (r *evalGraphRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(200, 80)
+}
+
+func (r *evalGraphRenderer) Refresh() {
+	needed := len(r.graph.scores) - 1
+	if needed < 0 {
+		needed = 0
+	}
+	for len(r.segments) < needed {
+		segment := canvas.NewLine(evalLineColor)
+		segment.StrokeWidth = 2
+		r.segments = append(r.segments, segment)
+	}
+	r.segments = r.segments[:needed]
+
+	r.Layout(r.graph.Size())
+	canvas.Refresh(r.graph)
+}
+
+func (r *evalGraphRenderer) Objects() []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{r.background, r.midline}
+	for _, segment := range r.segments {
+		objects = append(objects, segment)
+	}
+	return append(objects, r.marker)
+}
+
+func (r *evalGraphRenderer) Destroy() {}
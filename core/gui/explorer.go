@@ -0,0 +1,134 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/h3bzzz/go-chess/core/gamedb"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// openingExplorer is the panel listing the moves the database games played
+// from the position on the board
+type openingExplorer struct {
+	enabled *widget.Check
+	status  *widget.Label
+	list    *widget.List
+	moves   []gamedb.MoveStats
+}
+
+// newOpeningExplorer builds the explorer panel, off until its check is set
+func (ui *ChessUI) newOpeningExplorer() fyne.CanvasObject {
+	e := &openingExplorer{status: widget.NewLabel("")}
+	ui.explorer = e
+
+	e.list = widget.NewList(
+		func() int { return len(e.moves) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("Nxe4   9999  100/100/100%  2800-2800")
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(describeMoveStats(e.moves[id]))
+		},
+	)
+	e.list.OnSelected = func(id widget.ListItemID) {
+		e.list.Unselect(id)
+		if id < len(e.moves) {
+			ui.playExplorerMove(e.moves[id].Move)
+		}
+	}
+	e.list.Hide()
+
+	e.enabled = widget.NewCheck("Opening explorer", func(enabled bool) {
+		if enabled {
+			e.list.Show()
+		} else {
+			e.list.Hide()
+		}
+		ui.updateExplorer()
+	})
+
+	return container.NewBorder(container.NewVBox(e.enabled, e.status), nil, nil, nil, e.list)
+}
+
+// updateExplorer lists the database moves from the displayed position
+func (ui *ChessUI) updateExplorer() {
+	e := ui.explorer
+	if e == nil {
+		return
+	}
+	e.moves = nil
+	if !e.enabled.Checked {
+		e.status.SetText("")
+		e.list.Refresh()
+		return
+	}
+
+	db, err := ui.openGameDB()
+	if err != nil {
+		e.enabled.SetChecked(false)
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	moves, err := db.Explore(ui.board.displayedGame())
+	if err != nil {
+		e.status.SetText("Explorer failed: " + err.Error())
+		e.list.Refresh()
+		return
+	}
+	e.moves = moves
+
+	games := 0
+	for _, move := range moves {
+		games += move.Games
+	}
+	switch {
+	case games > 0:
+		e.status.SetText(fmt.Sprintf("%d game(s) reached this position", games))
+	case ui.board.displayedGame().IsGameOver():
+		e.status.SetText("The game is over")
+	default:
+		e.status.SetText("No database games from here")
+	}
+	e.list.Refresh()
+}
+
+// playExplorerMove plays a move picked in the explorer, as if made on the
+// board
+func (ui *ChessUI) playExplorerMove(move string) {
+	if !ui.board.canMove() || ui.game.IsGameOver() {
+		return
+	}
+	if ui.aiManager.IsEnabled() && ui.aiManager.GetAIColor() == ui.game.CurrentTurn {
+		return
+	}
+	from, to, promotion, err := ui.game.ParseUCI(move)
+	if err != nil || !ui.game.IsLegal(from, to, promotion) {
+		return
+	}
+	ui.game.MakeMoveWithPromotion(from, to, promotion)
+	ui.board.UpdateDisplay()
+	ui.onBoardMove()
+}
+
+// describeMoveStats summarizes a move for the explorer list: the number of
+// games, the white win, draw and black win percentages and the average
+// ratings
+func describeMoveStats(s gamedb.MoveStats) string {
+	text := fmt.Sprintf("%-6s %5d  %.0f/%.0f/%.0f%%", s.SAN, s.Games, s.WhitePercent(), s.DrawPercent(), s.BlackPercent())
+	if s.AverageWhite > 0 || s.AverageBlack > 0 {
+		text += fmt.Sprintf("  %s-%s", formatRating(s.AverageWhite), formatRating(s.AverageBlack))
+	}
+	return text
+}
+
+// formatRating writes a rating, or "?" when unknown
+func formatRating(rating int) string {
+	if rating == 0 {
+		return "?"
+	}
+	return fmt.Sprint(rating)
+}
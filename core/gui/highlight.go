@@ -0,0 +1,287 @@
+package gui
+
+import (
+	"image/color"
+
+	"github.com/h3bzzz/go-chess/core/game"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+)
+
+// BoardColors are the colors a board theme draws squares and highlights
+// with. The highlight colors are laid over the square color, so they are
+// usually translucent.
+type BoardColors struct {
+	Light color.Color
+	Dark  color.Color
+	// Selected marks the piece about to move
+	Selected color.Color
+	// LastMove marks the squares the last move left and reached
+	LastMove color.Color
+	// Check glows under the king in check
+	Check color.Color
+	// MoveHint draws the dots on empty target squares and the rings around
+	// pieces that can be captured
+	MoveHint color.Color
+	// Premove marks a move queued during the opponent's turn
+	Premove color.Color
+}
+
+// BoardThemes holds the colors of each board theme, by piece folder name.
+// Change an entry before the theme is applied to use other colors.
+var BoardThemes = map[string]BoardColors{
+	"chess": {
+		Light:    color.RGBA{240, 217, 181, 255},
+		Dark:     color.RGBA{181, 136, 99, 255},
+		Selected: color.RGBA{20, 85, 30, 130},
+		LastMove: color.RGBA{205, 210, 60, 130},
+		Check:    color.RGBA{230, 30, 30, 220},
+		MoveHint: color.RGBA{20, 85, 30, 110},
+		Premove:  color.RGBA{40, 90, 200, 130},
+	},
+	"chess_green": {
+		Light:    color.RGBA{238, 238, 210, 255},
+		Dark:     color.RGBA{118, 150, 86, 255},
+		Selected: color.RGBA{250, 250, 80, 140},
+		LastMove: color.RGBA{250, 250, 80, 100},
+		Check:    color.RGBA{230, 30, 30, 220},
+		MoveHint: color.RGBA{30, 50, 20, 100},
+		Premove:  color.RGBA{60, 110, 220, 130},
+	},
+	"chess_pink": {
+		Light:    color.RGBA{252, 228, 236, 255},
+		Dark:     color.RGBA{222, 140, 170, 255},
+		Selected: color.RGBA{120, 40, 140, 110},
+		LastMove: color.RGBA{255, 190, 90, 120},
+		Check:    color.RGBA{200, 0, 40, 220},
+		MoveHint: color.RGBA{110, 30, 90, 110},
+		Premove:  color.RGBA{70, 90, 200, 130},
+	},
+}
+
+// boardColors returns the colors of a theme, falling back to the classic
+// ones
+func boardColors(theme string) BoardColors {
+	if colors, ok := BoardThemes[theme]; ok {
+		return colors
+	}
+	return BoardThemes["chess"]
+}
+
+// SetColors redraws the board with other square and highlight colors
+func (b *ChessBoard) SetColors(colors BoardColors) {
+	b.colors = colors
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			b.squares[y][x].Refresh()
+		}
+	}
+}
+
+// moveHint is how a square the selected piece can move to is marked
+type moveHint int
+
+const (
+	noHint moveHint = iota
+	// dotHint marks an empty square
+	dotHint
+	// ringHint marks a capture
+	ringHint
+)
+
+// squareStyle is what a square shows besides its piece
+type squareStyle struct {
+	selected bool
+	lastMove bool
+	check    bool
+	premove  bool
+	hint     moveHint
+}
+
+// refreshHighlights restyles every square from the displayed position, the
+// selection or drag, the targets of the selected piece and the premove
+func (b *ChessBoard) refreshHighlights() {
+	displayed := b.displayedGame()
+	styles := [8][8]squareStyle{}
+
+	if n := len(displayed.MoveHistory); n > 0 {
+		last := displayed.MoveHistory[n-1]
+		styles[last.From.Y][last.From.X].lastMove = true
+		styles[last.To.Y][last.To.X].lastMove = true
+	}
+	if king, ok := kingInCheck(displayed); ok {
+		styles[king.Y][king.X].check = true
+	}
+
+	// The piece picked up by a click or a drag, and where it can go
+	var from *game.Position
+	targets := b.highlightedMoves
+	switch {
+	case b.isDragging:
+		from = &b.dragStartPosition
+	case b.premoveFrom != nil:
+		from = b.premoveFrom
+	case displayed.SelectedPosition != nil:
+		from = displayed.SelectedPosition
+		targets = displayed.GetPossibleMoves(*from)
+	}
+	if from != nil {
+		styles[from.Y][from.X].selected = true
+	}
+	for _, to := range targets {
+		styles[to.Y][to.X].hint = dotHint
+		if isCapture(displayed, from, to) {
+			styles[to.Y][to.X].hint = ringHint
+		}
+	}
+
+	if b.premove != nil {
+		for _, pos := range []game.Position{b.premove.from, b.premove.to} {
+			styles[pos.Y][pos.X].premove = true
+		}
+	}
+
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			b.applySquareStyle(b.squares[y][x], styles[y][x])
+		}
+	}
+}
+
+// applySquareStyle redraws a square with its highlights
+func (b *ChessBoard) applySquareStyle(square *ChessSquare, style squareStyle) {
+	if square.style == style {
+		return
+	}
+	square.style = style
+	square.Refresh()
+}
+
+// kingInCheck finds the king of the side to move when it is in check
+func kingInCheck(g *game.GameState) (game.Position, bool) {
+	if !g.InCheck(g.CurrentTurn) {
+		return game.Position{}, false
+	}
+	king := game.WhiteKing
+	if g.CurrentTurn == game.BlackPlayer {
+		king = game.BlackKing
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if g.Board[y][x] == king {
+				return game.Position{X: x, Y: y}, true
+			}
+		}
+	}
+	return game.Position{}, false
+}
+
+// isCapture reports whether moving from the selected square to to takes an
+// opponent's piece. Drops never capture.
+func isCapture(g *game.GameState, from *game.Position, to game.Position) bool {
+	return from != nil && g.IsCapture(*from, to)
+}
+
+// squareRenderer draws a square's color and highlights around the button
+// showing its piece
+type squareRenderer struct {
+	square *ChessSquare
+	button fyne.WidgetRenderer
+
+	background *canvas.Rectangle
+	tint       *canvas.Rectangle
+	check      *canvas.RadialGradient
+	dot        *canvas.Circle
+	ring       *canvas.Circle
+}
+
+func (s *ChessSquare) CreateRenderer() fyne.WidgetRenderer {
+	r := &squareRenderer{
+		square:     s,
+		button:     s.Button.CreateRenderer(),
+		background: canvas.NewRectangle(color.Transparent),
+		tint:       canvas.NewRectangle(color.Transparent),
+		check:      canvas.NewRadialGradient(color.Transparent, color.Transparent),
+		dot:        canvas.NewCircle(color.Transparent),
+		ring:       canvas.NewCircle(color.Transparent),
+	}
+	r.applyStyle()
+	return r
+}
+
+func (r *squareRenderer) Layout(size fyne.Size) {
+	r.background.Resize(size)
+	r.tint.Resize(size)
+	r.check.Resize(size)
+	r.button.Layout(size)
+
+	dot := size.Width * 0.3
+	r.dot.Resize(fyne.NewSize(dot, dot))
+	r.dot.Move(fyne.NewPos((size.Width-dot)/2, (size.Height-dot)/2))
+	inset := size.Width * 0.04
+	r.ring.Resize(fyne.NewSize(size.Width-2*inset, size.Height-2*inset))
+	r.ring.Move(fyne.NewPos(inset, inset))
+	r.ring.StrokeWidth = size.Width * 0.08
+}
+
+func (r *squareRenderer) MinSize() fyne.Size {
+	return r.button.MinSize()
+}
+
+func (r *squareRenderer) Refresh() {
+	r.applyStyle()
+	r.button.Refresh()
+	for _, object := range []fyne.CanvasObject{r.background, r.tint, r.check, r.dot, r.ring} {
+		object.Refresh()
+	}
+}
+
+func (r *squareRenderer) Objects() []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{r.background, r.tint, r.check}
+	objects = append(objects, r.button.Objects()...)
+	return append(objects, r.dot, r.ring)
+}
+
+func (r *squareRenderer) Destroy() {
+	r.button.Destroy()
+}
+
+// applyStyle colors the layers of the square from its style and the
+// board's theme
+func (r *squareRenderer) applyStyle() {
+	square := r.square
+	colors := square.board.colors
+	style := square.style
+
+	if (square.position.X+square.position.Y)%2 == 0 {
+		r.background.FillColor = colors.Dark
+	} else {
+		r.background.FillColor = colors.Light
+	}
+
+	r.tint.FillColor = color.Transparent
+	switch {
+	case style.premove:
+		r.tint.FillColor = colors.Premove
+	case style.selected:
+		r.tint.FillColor = colors.Selected
+	case style.lastMove:
+		r.tint.FillColor = colors.LastMove
+	}
+
+	r.check.StartColor, r.check.EndColor = color.Transparent, color.Transparent
+	if style.check {
+		r.check.StartColor = colors.Check
+	}
+
+	r.dot.FillColor = color.Transparent
+	r.ring.FillColor = color.Transparent
+	r.ring.StrokeColor = color.Transparent
+	switch style.hint {
+	case dotHint:
+		r.dot.FillColor = colors.MoveHint
+	case ringHint:
+		r.ring.StrokeColor = colors.MoveHint
+	}
+}
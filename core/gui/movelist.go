@@ -0,0 +1,174 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/h3bzzz/go-chess/core/game"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// moveList is the panel listing the moves of the live game in SAN. Clicking
+// a move shows the position after it; the live game goes on meanwhile.
+type moveList struct {
+	list   *widget.List
+	status *widget.Label
+	// sans holds the moves in SAN, in game order
+	sans []string
+	// blackFirst is set when the game started with Black to move, so the
+	// first row has no White move
+	blackFirst bool
+}
+
+// newMoveList builds the move list panel with its navigation buttons
+func (ui *ChessUI) newMoveList() fyne.CanvasObject {
+	m := &moveList{status: widget.NewLabel("")}
+	ui.moves = m
+
+	m.list = widget.NewList(
+		func() int { return (len(m.sans) + m.offset() + 1) / 2 },
+		func() fyne.CanvasObject {
+			number := widget.NewLabel("999.")
+			white := widget.NewButton("Qxe8+", nil)
+			black := widget.NewButton("Qxe8+", nil)
+			white.Importance = widget.LowImportance
+			black.Importance = widget.LowImportance
+			return container.NewGridWithColumns(3, number, white, black)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			cells := item.(*fyne.Container).Objects
+			cells[0].(*widget.Label).SetText(fmt.Sprintf("%d.", id+1))
+			for side, cell := range cells[1:] {
+				ui.showMoveEntry(cell.(*widget.Button), 2*id+side-m.offset())
+			}
+		},
+	)
+
+	navigation := container.NewGridWithColumns(4,
+		widget.NewButtonWithIcon("", theme.MediaSkipPreviousIcon(), func() { ui.showPly(0) }),
+		widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() { ui.stepPly(-1) }),
+		widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() { ui.stepPly(1) }),
+		widget.NewButtonWithIcon("", theme.MediaSkipNextIcon(), func() { ui.showPly(len(ui.game.MoveHistory)) }),
+	)
+
+	return container.NewBorder(
+		container.NewVBox(widget.NewLabel("Moves"), m.status), navigation, nil, nil, m.list,
+	)
+}
+
+// offset is 1 when the first row starts with an empty White move
+func (m *moveList) offset() int {
+	if m.blackFirst {
+		return 1
+	}
+	return 0
+}
+
+// showMoveEntry sets a button of the list to the move at index i of the
+// game, highlighting the move whose position is on the board
+func (ui *ChessUI) showMoveEntry(button *widget.Button, i int) {
+	m := ui.moves
+	if i < 0 || i >= len(m.sans) {
+		button.SetText("")
+		button.OnTapped = nil
+		button.Importance = widget.LowImportance
+		button.Disable()
+		return
+	}
+	button.Enable()
+	button.Text = m.sans[i]
+	button.OnTapped = func() { ui.showPly(i + 1) }
+	if i+1 == ui.shownPly() {
+		button.Importance = widget.HighImportance
+	} else {
+		button.Importance = widget.LowImportance
+	}
+	button.Refresh()
+}
+
+// describePly names the position after ply half-moves by its last move,
+// e.g. "12. Nf3" or "12... Nc6"
+func (m *moveList) describePly(ply int) string {
+	if ply == 0 || ply > len(m.sans) {
+		return "the starting position"
+	}
+	index := ply - 1 + m.offset()
+	number := fmt.Sprintf("%d.", index/2+1)
+	if index%2 == 1 {
+		number += ".."
+	}
+	return number + " " + m.sans[ply-1]
+}
+
+// updateMoveList rewrites the move list from the live game
+func (ui *ChessUI) updateMoveList() {
+	m := ui.moves
+	if m == nil {
+		return
+	}
+	replay := ui.game.PositionAt(0)
+	m.blackFirst = replay.CurrentTurn == game.BlackPlayer
+	sans := make([]string, 0, len(ui.game.MoveHistory))
+	for _, move := range ui.game.MoveHistory {
+		sans = append(sans, replay.SAN(move.From, move.To, move.Promotion))
+		replay.MakeMoveWithPromotion(move.From, move.To, move.Promotion)
+	}
+	m.sans = sans
+	ui.refreshMoveList()
+	if !ui.board.IsViewingHistory() {
+		m.list.ScrollToBottom()
+	}
+}
+
+// refreshMoveList redraws the highlight and says whether the board shows
+// the live game or an earlier position
+func (ui *ChessUI) refreshMoveList() {
+	m := ui.moves
+	if m == nil {
+		return
+	}
+	if ui.board.IsViewingHistory() {
+		m.status.SetText(fmt.Sprintf("Viewing %s, End returns", m.describePly(ui.viewedPly)))
+		m.status.Importance = widget.WarningImportance
+	} else {
+		m.status.SetText("Live position")
+		m.status.Importance = widget.MediumImportance
+	}
+	m.status.Refresh()
+	m.list.Refresh()
+}
+
+// shownPly returns the number of half-moves of the position on the board
+func (ui *ChessUI) shownPly() int {
+	if ui.board.IsViewingHistory() {
+		return ui.viewedPly
+	}
+	return len(ui.game.MoveHistory)
+}
+
+// stepPly moves through the game by delta half-moves from the position on
+// the board
+func (ui *ChessUI) stepPly(delta int) {
+	ply := ui.shownPly() + delta
+	if ply < 0 || ply > len(ui.game.MoveHistory) {
+		return
+	}
+	ui.showPly(ply)
+}
+
+// navigateMoves handles the arrow keys, Home and End on the main window
+func (ui *ChessUI) navigateMoves(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyLeft:
+		ui.stepPly(-1)
+	case fyne.KeyRight:
+		ui.stepPly(1)
+	case fyne.KeyHome, fyne.KeyUp:
+		ui.showPly(0)
+	case fyne.KeyEnd, fyne.KeyDown:
+		ui.showPly(len(ui.game.MoveHistory))
+	}
+}
@@ -0,0 +1,433 @@
+package gui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/game"
+	"github.com/h3bzzz/go-chess/core/network"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// defaultServerAddress is offered when connecting to a server
+const defaultServerAddress = "localhost:7878"
+
+// onlineTimeControls are the time controls offered for seeks
+var onlineTimeControls = map[string]network.TimeControl{
+	"1+0":   {Base: time.Minute},
+	"3+2":   {Base: 3 * time.Minute, Increment: 2 * time.Second},
+	"5+0":   {Base: 5 * time.Minute},
+	"10+5":  {Base: 10 * time.Minute, Increment: 5 * time.Second},
+	"15+10": {Base: 15 * time.Minute, Increment: 10 * time.Second},
+	"30+0":  {Base: 30 * time.Minute},
+}
+
+// onlineSession is the lobby window of a connection to a chess server and
+// the game being played through it
+type onlineSession struct {
+	client *network.Client
+	window fyne.Window
+	status *widget.Label
+
+	seeks        []network.Seek
+	players      []string
+	games        []network.GameInfo
+	seekList     *widget.List
+	playerList   *widget.List
+	gameList     *widget.List
+	selectedSeek int
+	selectedName string
+	selectedGame int
+	timeSelect   *widget.Select
+	colorSelect  *widget.Select
+
+	// gameID is the game in progress on the board, 0 when there is none.
+	// It is either played as color or, when watching, followed read-only.
+	gameID   int
+	color    int
+	watching bool
+}
+
+// showingOnline reports whether the board shows a game in progress on a
+// server, played or watched
+func (ui *ChessUI) showingOnline() bool {
+	return ui.online != nil && ui.online.gameID != 0
+}
+
+// playingOnline reports whether the user is playing a game on a server
+func (ui *ChessUI) playingOnline() bool {
+	return ui.showingOnline() && !ui.online.watching
+}
+
+// showOnline opens the lobby, asking for a server and name first when not
+// connected
+func (ui *ChessUI) showOnline() {
+	if ui.online != nil {
+		ui.online.window.Show()
+		return
+	}
+
+	address := widget.NewEntry()
+	address.SetText(defaultServerAddress)
+	name := widget.NewEntry()
+	name.SetPlaceHolder("Your name")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Server", address),
+		widget.NewFormItem("Name", name),
+	}
+	dialog.ShowForm("Play Online", "Connect", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		client, err := network.Dial(address.Text, name.Text)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("connecting to %s: %w", address.Text, err), ui.window)
+			return
+		}
+		ui.openLobby(client, address.Text)
+	}, ui.window)
+}
+
+// openLobby shows the seeks and players of the server and starts handling
+// its messages
+func (ui *ChessUI) openLobby(client *network.Client, address string) {
+	s := &onlineSession{
+		client:       client,
+		window:       fyne.CurrentApp().NewWindow("Online Lobby"),
+		status:       widget.NewLabel(fmt.Sprintf("Connected to %s as %s", address, client.Name())),
+		selectedSeek: -1,
+		selectedGame: -1,
+	}
+	ui.online = s
+
+	s.seekList = widget.NewList(
+		func() int { return len(s.seeks) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(describeSeek(s.seeks[id]))
+		},
+	)
+	s.seekList.OnSelected = func(id widget.ListItemID) { s.selectedSeek = id }
+
+	s.playerList = widget.NewList(
+		func() int { return len(s.players) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(s.players[id])
+		},
+	)
+	s.playerList.OnSelected = func(id widget.ListItemID) { s.selectedName = s.players[id] }
+
+	s.gameList = widget.NewList(
+		func() int { return len(s.games) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(describeGame(s.games[id]))
+		},
+	)
+	s.gameList.OnSelected = func(id widget.ListItemID) { s.selectedGame = id }
+
+	s.timeSelect = widget.NewSelect([]string{"1+0", "3+2", "5+0", "10+5", "15+10", "30+0"}, nil)
+	s.timeSelect.SetSelected("5+0")
+	s.colorSelect = widget.NewSelect([]string{"Random", "White", "Black"}, nil)
+	s.colorSelect.SetSelected("Random")
+
+	seekControls := container.NewHBox(
+		widget.NewLabel("Time:"), s.timeSelect,
+		widget.NewLabel("Color:"), s.colorSelect,
+		widget.NewButton("Seek", func() { ui.sendSeek("") }),
+		widget.NewButton("Challenge", func() {
+			if s.selectedName != "" && s.selectedName != client.Name() {
+				ui.sendSeek(s.selectedName)
+			}
+		}),
+	)
+	seekButtons := container.NewHBox(
+		widget.NewButton("Accept", func() {
+			if seek, ok := s.selected(); ok && seek.Player != client.Name() {
+				ui.reportOnlineError(client.Accept(seek.ID))
+			}
+		}),
+		widget.NewButton("Cancel Seek", func() {
+			if seek, ok := s.selected(); ok && seek.Player == client.Name() {
+				ui.reportOnlineError(client.Cancel(seek.ID))
+			}
+		}),
+		layout.NewSpacer(),
+		widget.NewButton("Resign", func() {
+			if ui.playingOnline() {
+				ui.reportOnlineError(client.Resign(s.gameID))
+			}
+		}),
+		widget.NewButton("Disconnect", ui.disconnectOnline),
+	)
+	gameButtons := container.NewHBox(
+		widget.NewButton("Watch", func() {
+			if s.selectedGame >= 0 && s.selectedGame < len(s.games) && !ui.playingOnline() {
+				ui.reportOnlineError(client.Watch(s.games[s.selectedGame].ID))
+			}
+		}),
+		widget.NewButton("Stop Watching", ui.stopWatching),
+	)
+
+	lobby := container.NewHSplit(
+		container.NewBorder(widget.NewLabel("Seeks (variant from the main window)"), nil, nil, nil, s.seekList),
+		container.NewBorder(widget.NewLabel("Players"), nil, nil, nil, s.playerList),
+	)
+	lobby.SetOffset(0.65)
+	lists := container.NewVSplit(
+		lobby,
+		container.NewBorder(widget.NewLabel("Games in progress"), gameButtons, nil, nil, s.gameList),
+	)
+
+	s.window.SetContent(container.NewBorder(
+		container.NewVBox(s.status, seekControls), seekButtons, nil, nil, lists,
+	))
+	s.window.SetCloseIntercept(s.window.Hide)
+	s.window.Resize(fyne.NewSize(560, 560))
+	s.window.Show()
+
+	go ui.receiveOnline(s)
+}
+
+// sendSeek posts a seek with the lobby's settings and the variant chosen in
+// the main window, addressed to opponent when it is not empty
+func (ui *ChessUI) sendSeek(opponent string) {
+	s := ui.online
+	seek := network.Seek{
+		Opponent:    opponent,
+		Variant:     ui.variantSelect.Selected,
+		TimeControl: onlineTimeControls[s.timeSelect.Selected],
+	}
+	switch s.colorSelect.Selected {
+	case "White":
+		seek.Color = network.White
+	case "Black":
+		seek.Color = network.Black
+	}
+	ui.reportOnlineError(s.client.Seek(seek))
+}
+
+// disconnectOnline leaves the server. A game in progress is left to the
+// server's clock.
+func (ui *ChessUI) disconnectOnline() {
+	s := ui.online
+	if s == nil {
+		return
+	}
+	ui.online = nil
+	s.client.Close()
+	s.window.Close()
+	ui.board.SetPlayerColor(AnyPlayer)
+	ui.board.SetWatchMode(false)
+	ui.updateStatus()
+}
+
+// stopWatching stops following the watched game and leaves it on the board
+// as a local game
+func (ui *ChessUI) stopWatching() {
+	s := ui.online
+	if s == nil || !s.watching || s.gameID == 0 {
+		return
+	}
+	ui.reportOnlineError(s.client.Unwatch(s.gameID))
+	s.gameID = 0
+	s.watching = false
+	ui.board.SetWatchMode(false)
+	s.status.SetText("Stopped watching")
+}
+
+// receiveOnline handles the server's messages until the session is closed
+func (ui *ChessUI) receiveOnline(s *onlineSession) {
+	for msg := range s.client.Messages() {
+		switch msg.Type {
+		case network.MsgLobby:
+			s.seeks, s.players, s.games = msg.Seeks, msg.Players, msg.Games
+			s.selectedSeek, s.selectedGame = -1, -1
+			s.seekList.UnselectAll()
+			s.gameList.UnselectAll()
+			s.seekList.Refresh()
+			s.playerList.Refresh()
+			s.gameList.Refresh()
+		case network.MsgChallenge:
+			ui.showChallenge(s, *msg.Seek)
+		case network.MsgDecline:
+			s.status.SetText(fmt.Sprintf("%s declined your challenge", msg.Name))
+		case network.MsgGame:
+			ui.startOnlineGame(s, msg)
+		case network.MsgMove:
+			ui.applyOnlineMove(s, msg)
+		case network.MsgOpponent:
+			if msg.Online {
+				s.status.SetText(fmt.Sprintf("%s is back", msg.Name))
+			} else {
+				s.status.SetText(fmt.Sprintf("%s disconnected; waiting for them to return", msg.Name))
+			}
+		case network.MsgEnd:
+			ui.endOnlineGame(s, msg)
+		case network.MsgError:
+			s.status.SetText("Server: " + msg.Error)
+		case network.MsgDisconnected:
+			s.status.SetText("Connection lost, reconnecting...")
+			ui.status.SetText("Connection lost, reconnecting...")
+		case network.MsgReconnected:
+			s.status.SetText("Reconnected as " + s.client.Name())
+		}
+	}
+}
+
+// showChallenge asks whether to play a player who challenged us
+func (ui *ChessUI) showChallenge(s *onlineSession, seek network.Seek) {
+	message := fmt.Sprintf("%s challenges you: %s", seek.Player, describeSeek(seek))
+	dialog.ShowConfirm("Challenge", message, func(accept bool) {
+		if accept {
+			ui.reportOnlineError(s.client.Accept(seek.ID))
+		} else {
+			ui.reportOnlineError(s.client.Decline(seek.ID))
+		}
+	}, ui.window)
+}
+
+// startOnlineGame puts a game from the server on the board, to play or,
+// without a color, to watch. It is also used to resume a game after
+// reconnecting and to resynchronize after a refused move.
+func (ui *ChessUI) startOnlineGame(s *onlineSession, msg network.Message) {
+	position, err := network.ReplayGame(msg)
+	if err != nil {
+		s.status.SetText(fmt.Sprintf("Cannot show game %d: %v", msg.Game, err))
+		return
+	}
+
+	// Only one game fits on the board
+	if s.watching && s.gameID != msg.Game {
+		s.client.Unwatch(s.gameID)
+	}
+	s.gameID = msg.Game
+	s.watching = msg.Color == ""
+	s.color = game.WhitePlayer
+	if msg.Color == network.Black {
+		s.color = game.BlackPlayer
+	}
+
+	ui.aiEnabledCheck.SetChecked(false)
+	ui.loadGame(position)
+	ui.board.SetWatchMode(s.watching)
+	if s.watching {
+		ui.board.SetPlayerColor(AnyPlayer)
+		s.status.SetText(fmt.Sprintf("Watching %s - %s, %s %v", msg.White, msg.Black, msg.Variant, msg.TimeControl))
+		return
+	}
+	ui.board.SetPlayerColor(s.color)
+
+	opponent := msg.Black
+	if s.color == game.BlackPlayer {
+		opponent = msg.White
+	}
+	s.status.SetText(fmt.Sprintf("Playing %s as %s, %s %v", opponent, colorName(s.color), msg.Variant, msg.TimeControl))
+}
+
+// applyOnlineMove plays the opponent's move and sets the clocks. Our own
+// moves come back too and only update the clocks.
+func (ui *ChessUI) applyOnlineMove(s *onlineSession, msg network.Message) {
+	if msg.Game != s.gameID {
+		return
+	}
+
+	if msg.Ply == len(ui.game.MoveHistory)+1 {
+		from, to, promotion, err := ui.game.ParseUCI(msg.Move)
+		if err != nil || ui.game.MakeMoveWithPromotion(from, to, promotion) == game.InvalidMove {
+			s.status.SetText(fmt.Sprintf("Cannot play %s from the server", msg.Move))
+			return
+		}
+		ui.board.UpdateDisplay()
+	}
+
+	ui.game.WhitePlayerTime = msg.WhiteClock
+	ui.game.BlackPlayerTime = msg.BlackClock
+	ui.game.LastMoveTime = time.Now()
+	ui.onMovePlayed()
+}
+
+// endOnlineGame shows the result the server decided
+func (ui *ChessUI) endOnlineGame(s *onlineSession, msg network.Message) {
+	if msg.Game != s.gameID {
+		return
+	}
+	s.gameID = 0
+	s.watching = false
+
+	switch msg.Result {
+	case "1-0":
+		ui.game.GameStatus = game.WhiteWon
+	case "0-1":
+		ui.game.GameStatus = game.BlackWon
+	default:
+		ui.game.GameStatus = game.GameDraw
+	}
+	ui.game.Termination = msg.Termination
+	ui.game.WhitePlayerTime = msg.WhiteClock
+	ui.game.BlackPlayerTime = msg.BlackClock
+	ui.board.SetPlayerColor(AnyPlayer)
+	ui.board.SetWatchMode(false)
+	ui.updateStatus()
+
+	text := fmt.Sprintf("%s (%s)", msg.Result, msg.Termination)
+	s.status.SetText("Game over: " + text)
+	dialog.ShowInformation("Game over", text, ui.window)
+}
+
+// sendOnlineMove sends the user's last move to the server
+func (ui *ChessUI) sendOnlineMove() {
+	if !ui.playingOnline() {
+		return
+	}
+	ply := len(ui.game.MoveHistory) - 1
+	last := ui.game.MoveHistory[ply]
+	move := ui.game.PositionAt(ply).UCI(last.From, last.To, last.Promotion)
+	if err := ui.online.client.Move(ui.online.gameID, move); err != nil {
+		// The server sends the game again once we are reconnected
+		ui.online.status.SetText(fmt.Sprintf("%s was not sent: %v", move, err))
+	}
+}
+
+// reportOnlineError shows a request that could not be sent in the lobby
+func (ui *ChessUI) reportOnlineError(err error) {
+	if err != nil && ui.online != nil {
+		ui.online.status.SetText(err.Error())
+	}
+}
+
+// selected returns the seek selected in the lobby
+func (s *onlineSession) selected() (network.Seek, bool) {
+	if s.selectedSeek < 0 || s.selectedSeek >= len(s.seeks) {
+		return network.Seek{}, false
+	}
+	return s.seeks[s.selectedSeek], true
+}
+
+// describeGame summarizes a game in progress for the lobby list
+func describeGame(info network.GameInfo) string {
+	text := fmt.Sprintf("%s - %s, %s %v, move %d", info.White, info.Black, info.Variant, info.TimeControl, info.Ply/2+1)
+	if info.Spectators > 0 {
+		text += fmt.Sprintf(", %d watching", info.Spectators)
+	}
+	return text
+}
+
+// describeSeek summarizes a seek for the lobby list
+func describeSeek(seek network.Seek) string {
+	text := fmt.Sprintf("%s - %s %v", seek.Player, seek.Variant, seek.TimeControl)
+	if seek.Color != "" {
+		text += " as " + seek.Color
+	}
+	if seek.Opponent != "" {
+		text += " (challenge to " + seek.Opponent + ")"
+	}
+	return text
+}
@@ -0,0 +1,148 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/h3bzzz/go-chess/core/game"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// pocketSlot shows how many of one piece a player can drop in Crazyhouse.
+// Tap it and then a square, or drag it onto the board, to drop the piece.
+type pocketSlot struct {
+	widget.BaseWidget
+	board *ChessBoard
+	piece int
+	image *canvas.Image
+	count *widget.Label
+
+	lastDragPosition fyne.Position
+}
+
+func newPocketSlot(board *ChessBoard, piece int) *pocketSlot {
+	slot := &pocketSlot{
+		board: board,
+		piece: piece,
+		image: canvas.NewImageFromResource(board.pieceManager.GetResource(piece)),
+		count: widget.NewLabel("0"),
+	}
+	slot.image.FillMode = canvas.ImageFillContain
+	slot.image.SetMinSize(fyne.NewSize(40, 40))
+	slot.ExtendBaseWidget(slot)
+	return slot
+}
+
+func (s *pocketSlot) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewHBox(s.image, s.count))
+}
+
+func (s *pocketSlot) Tapped(*fyne.PointEvent) {
+	s.board.selectDrop(s.piece)
+}
+
+func (s *pocketSlot) Dragged(e *fyne.DragEvent) {
+	if s.board.dropPiece != s.piece {
+		s.board.selectDrop(s.piece)
+	}
+	s.lastDragPosition = e.AbsolutePosition
+}
+
+func (s *pocketSlot) DragEnd() {
+	if pos, ok := s.board.squareAt(s.lastDragPosition); ok {
+		s.board.dropAt(pos)
+	}
+}
+
+// update shows the slot's count and current piece image
+func (s *pocketSlot) update(displayed *game.GameState) {
+	player := game.WhitePlayer
+	if game.IsPieceBlack(s.piece) {
+		player = game.BlackPlayer
+	}
+	s.image.Resource = s.board.pieceManager.GetResource(s.piece)
+	s.image.Refresh()
+	s.count.SetText(fmt.Sprintf("%d", displayed.PocketCount(player, s.piece)))
+}
+
+// newPocket builds the pocket bar of a player
+func (b *ChessBoard) newPocket(player int) (*fyne.Container, []*pocketSlot) {
+	bar := container.NewHBox()
+	var slots []*pocketSlot
+	for index := 0; index < 5; index++ {
+		piece := game.WhitePawn + index
+		if player == game.BlackPlayer {
+			piece = game.BlackPawn + index
+		}
+		slot := newPocketSlot(b, piece)
+		slots = append(slots, slot)
+		bar.Add(slot)
+	}
+	return bar, slots
+}
+
+// updatePockets shows the pockets of a Crazyhouse game and hides them
+// otherwise
+func (b *ChessBoard) updatePockets(displayed *game.GameState) {
+	for player, bar := range b.pocketBars {
+		if !displayed.Drops() {
+			bar.Hide()
+			continue
+		}
+		bar.Show()
+		for _, slot := range b.pocketSlots[player] {
+			slot.update(displayed)
+		}
+	}
+}
+
+// selectDrop starts dropping a piece from the side to move's pocket and
+// highlights where it can go
+func (b *ChessBoard) selectDrop(piece int) {
+	if !b.canMove() || game.IsPieceWhite(piece) != (b.game.CurrentTurn == game.WhitePlayer) {
+		return
+	}
+
+	b.clearHighlightedMoves()
+	b.game.SelectedPosition = nil
+	b.dropPiece = piece
+	b.highlightedMoves = b.game.GetPossibleDrops(piece)
+	b.UpdateDisplay()
+	b.highlightPossibleMoves()
+}
+
+// dropAt drops the selected pocket piece on pos if that is legal
+func (b *ChessBoard) dropAt(pos game.Position) {
+	piece := b.dropPiece
+	b.dropPiece = game.Empty
+	b.clearHighlightedMoves()
+
+	moveMade := piece != game.Empty && b.game.MakeDrop(piece, pos) != game.InvalidMove
+	b.UpdateDisplay()
+	if moveMade {
+		b.notifyMove()
+	}
+}
+
+// squareAt returns the board square under an absolute window position
+func (b *ChessBoard) squareAt(position fyne.Position) (game.Position, bool) {
+	driver := fyne.CurrentApp().Driver()
+	origin := driver.AbsolutePositionForObject(b.container)
+	size := b.container.Size()
+	if size.Width == 0 || size.Height == 0 {
+		return game.Position{}, false
+	}
+
+	if position.X < origin.X || position.Y < origin.Y {
+		return game.Position{}, false
+	}
+	col := int((position.X - origin.X) / (size.Width / 8))
+	row := int((position.Y - origin.Y) / (size.Height / 8))
+	if col > 7 || row > 7 {
+		return game.Position{}, false
+	}
+	return b.squareForCell(row, col), true
+}
@@ -0,0 +1,80 @@
+package gui
+
+import "github.com/h3bzzz/go-chess/core/game"
+
+// queuedMove is a premove: a move picked during the opponent's turn and
+// played as soon as it is the user's turn, if it is legal then
+type queuedMove struct {
+	from game.Position
+	to   game.Position
+}
+
+// canPremove reports whether the user may queue a move right now, which is
+// while the opponent of a side-limited game is to move
+func (b *ChessBoard) canPremove() bool {
+	return b.viewGame == nil && !b.watching && b.playerColor != AnyPlayer &&
+		b.playerColor != b.game.CurrentTurn && !b.game.IsGameOver()
+}
+
+// selectPremove handles a click during the opponent's turn. A click on one
+// of the user's pieces picks it and the next click queues the move to that
+// square. Clicking again cancels a queued premove.
+func (b *ChessBoard) selectPremove(pos game.Position) {
+	switch {
+	case b.premove != nil:
+		b.premove = nil
+	case belongsTo(b.game.GetPieceAtPosition(pos), b.playerColor):
+		if b.premoveFrom != nil && *b.premoveFrom == pos {
+			b.premoveFrom = nil
+		} else {
+			b.premoveFrom = &pos
+		}
+	case b.premoveFrom != nil:
+		b.setPremove(*b.premoveFrom, pos)
+		return
+	}
+	b.refreshHighlights()
+}
+
+// setPremove queues the move from from to to
+func (b *ChessBoard) setPremove(from, to game.Position) {
+	b.premoveFrom = nil
+	b.premove = &queuedMove{from: from, to: to}
+	b.refreshHighlights()
+}
+
+// clearPremove drops the queued premove and the piece picked for one
+func (b *ChessBoard) clearPremove() {
+	b.premoveFrom = nil
+	b.premove = nil
+}
+
+// playPremove plays the queued premove once it is the user's turn. A
+// premove the opponent's reply made illegal is dropped. Pawns reaching the
+// last rank become queens.
+func (b *ChessBoard) playPremove() {
+	queued := b.premove
+	if queued == nil || !b.canMove() {
+		return
+	}
+	b.clearPremove()
+
+	moveMade := b.game.IsLegal(queued.from, queued.to, game.Empty) &&
+		b.game.MakeMove(queued.from, queued.to) != game.InvalidMove
+	b.UpdateDisplay()
+	if moveMade {
+		b.notifyMove()
+	}
+}
+
+// belongsTo reports whether piece is one of player's pieces
+func belongsTo(piece, player int) bool {
+	switch {
+	case piece == game.Empty:
+		return false
+	case player == game.WhitePlayer:
+		return game.IsPieceWhite(piece)
+	default:
+		return game.IsPieceBlack(piece)
+	}
+}
@@ -0,0 +1,265 @@
+package gui
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/game"
+	"github.com/h3bzzz/go-chess/core/puzzle"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// replayDelay is the pause before the opponent's reply in a puzzle
+const replayDelay = 600 * time.Millisecond
+
+// puzzleTrainer is a window for solving tactics puzzles on its own board
+type puzzleTrainer struct {
+	window       fyne.Window
+	board        *ChessBoard
+	puzzles      []*puzzle.Puzzle
+	progress     *puzzle.Progress
+	progressPath string
+	attempt      *puzzle.Attempt
+	random       *rand.Rand
+	busy         bool
+
+	status *widget.Label
+	info   *widget.Label
+	rating *widget.Label
+}
+
+// showPuzzles asks for a puzzle file and opens the puzzle trainer with it
+func (ui *ChessUI) showPuzzles() {
+	progressPath, err := puzzle.DefaultProgressPath()
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	progress, err := puzzle.LoadProgress(progressPath)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("reading puzzle progress: %w", err), ui.window)
+		return
+	}
+
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+
+		puzzles, err := puzzle.Load(path)
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if len(puzzles) == 0 {
+			dialog.ShowInformation("Puzzles", "The file contains no puzzles.", ui.window)
+			return
+		}
+
+		progress.LastFile = path
+		ui.openPuzzleTrainer(puzzles, progress, progressPath)
+	}, ui.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".pgn", ".epd"}))
+	open.Show()
+}
+
+func (ui *ChessUI) openPuzzleTrainer(puzzles []*puzzle.Puzzle, progress *puzzle.Progress, progressPath string) {
+	trainer := &puzzleTrainer{
+		window:       fyne.CurrentApp().NewWindow("Puzzles"),
+		puzzles:      puzzles,
+		progress:     progress,
+		progressPath: progressPath,
+		random:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		status:       widget.NewLabel(""),
+		info:         widget.NewLabel(""),
+		rating:       widget.NewLabel(""),
+	}
+	trainer.board = NewChessBoard(game.NewGame(), ui.board.theme)
+	trainer.board.SetMoveCallback(trainer.onMove)
+
+	controls := container.NewHBox(
+		trainer.rating,
+		layout.NewSpacer(),
+		widget.NewButton("Hint", trainer.hint),
+		widget.NewButton("Show Solution", trainer.showSolution),
+		widget.NewButton("Retry", trainer.retry),
+		widget.NewButton("Next", trainer.next),
+	)
+	trainer.window.SetContent(container.NewBorder(
+		container.NewVBox(trainer.status, trainer.info), controls, nil, nil,
+		trainer.board.GetContainer(),
+	))
+	trainer.window.Resize(fyne.NewSize(520, 640))
+	trainer.window.Show()
+
+	trainer.next()
+}
+
+// start sets up a puzzle on the board
+func (t *puzzleTrainer) start(p *puzzle.Puzzle) {
+	attempt, err := puzzle.NewAttempt(p)
+	if err != nil {
+		dialog.ShowError(err, t.window)
+		return
+	}
+	t.attempt = attempt
+	t.busy = false
+	t.board.game = attempt.Game
+	t.board.UpdateDisplay()
+
+	themes := strings.Join(p.Themes, ", ")
+	if themes == "" {
+		themes = "none"
+	}
+	t.info.SetText(fmt.Sprintf("Puzzle %s - rating %d - themes: %s", p.ID, p.Rating, themes))
+	t.status.SetText(fmt.Sprintf("Find the best move for %s", colorName(attempt.Solver())))
+	t.updateRating(0)
+}
+
+func (t *puzzleTrainer) next() {
+	p := t.progress.Next(t.puzzles, t.random)
+	if p == nil {
+		dialog.ShowInformation("Puzzles", "You have played every puzzle in this file.", t.window)
+		return
+	}
+	t.start(p)
+}
+
+func (t *puzzleTrainer) retry() {
+	if t.attempt != nil {
+		t.start(t.attempt.Puzzle)
+	}
+}
+
+// onMove judges a move the user made on the board
+func (t *puzzleTrainer) onMove() {
+	gs := t.attempt.Game
+	last := gs.MoveHistory[len(gs.MoveHistory)-1]
+	gs.UndoLastMove()
+
+	if t.busy || t.attempt.Done() {
+		t.board.UpdateDisplay()
+		return
+	}
+
+	outcome, err := t.attempt.Play(gs.UCI(last.From, last.To, last.Promotion))
+	t.board.UpdateDisplay()
+	if err != nil {
+		t.status.SetText(err.Error())
+		return
+	}
+
+	switch outcome {
+	case puzzle.Wrong:
+		t.record(false)
+		t.status.SetText("That's not it. Try again, or show the solution.")
+	case puzzle.Correct:
+		t.status.SetText("Correct! Keep going.")
+		t.playReply()
+	case puzzle.Solved:
+		t.record(!t.attempt.Failed)
+		t.status.SetText("Solved!")
+	}
+}
+
+// playReply plays the opponent's answer after a short pause
+func (t *puzzleTrainer) playReply() {
+	attempt := t.attempt
+	t.busy = true
+	go func() {
+		time.Sleep(replayDelay)
+		if attempt != t.attempt {
+			return
+		}
+		if _, err := attempt.Reply(); err != nil {
+			t.status.SetText(err.Error())
+		}
+		t.busy = false
+		t.board.UpdateDisplay()
+	}()
+}
+
+func (t *puzzleTrainer) hint() {
+	if t.attempt == nil || t.busy {
+		return
+	}
+	move, ok := t.attempt.Hint()
+	if !ok {
+		return
+	}
+	from, _, _, err := t.attempt.Game.ParseUCI(move)
+	if err != nil {
+		return
+	}
+	// Selecting the piece shows its moves
+	t.attempt.Game.SelectedPosition = &from
+	t.attempt.Failed = true
+	t.board.UpdateDisplay()
+}
+
+// showSolution plays out the rest of the solution, counting the puzzle as
+// failed
+func (t *puzzleTrainer) showSolution() {
+	if t.attempt == nil || t.busy || t.attempt.Done() {
+		return
+	}
+	t.record(false)
+
+	attempt := t.attempt
+	t.busy = true
+	go func() {
+		for !attempt.Done() && attempt == t.attempt {
+			var err error
+			if move, ok := attempt.Hint(); ok {
+				_, err = attempt.Play(move)
+			} else {
+				_, err = attempt.Reply()
+			}
+			if err != nil {
+				break
+			}
+			t.board.UpdateDisplay()
+			time.Sleep(replayDelay)
+		}
+		t.busy = false
+		t.status.SetText("Solution shown.")
+	}()
+}
+
+// record rates the first result of the current puzzle and saves progress
+func (t *puzzleTrainer) record(solved bool) {
+	change := t.progress.Record(t.attempt.Puzzle, solved)
+	t.updateRating(change)
+	if err := t.progress.Save(t.progressPath); err != nil {
+		fmt.Printf("Error saving puzzle progress: %v\n", err)
+	}
+}
+
+func (t *puzzleTrainer) updateRating(change int) {
+	text := fmt.Sprintf("Rating: %d  (%d/%d solved)", t.progress.Rating, t.progress.Solved, t.progress.Attempts)
+	if change != 0 {
+		text += fmt.Sprintf("  %+d", change)
+	}
+	t.rating.SetText(text)
+}
+
+func colorName(player int) string {
+	if player == game.WhitePlayer {
+		return "White"
+	}
+	return "Black"
+}
@@ -0,0 +1,123 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/h3bzzz/go-chess/core/savegame"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+)
+
+// SetAutosave saves the local game to path after every move and whenever a
+// new game starts. An empty path turns autosaving off.
+func (ui *ChessUI) SetAutosave(path string) {
+	ui.autosavePath = path
+}
+
+// autosave writes the local game to the autosave file. Online and
+// correspondence games are kept elsewhere.
+func (ui *ChessUI) autosave() {
+	if ui.autosavePath == "" || ui.showingOnline() || ui.showingCorrespondence() {
+		return
+	}
+	if err := ui.currentSave().Write(ui.autosavePath); err != nil {
+		fmt.Printf("Error autosaving game: %v\n", err)
+	}
+}
+
+// currentSave records the live game with the AI and theme settings
+func (ui *ChessUI) currentSave() *savegame.Save {
+	s := savegame.FromGame(ui.game)
+	s.AI = savegame.AISettings{
+		Enabled: ui.aiEnabledCheck.Checked,
+		Color:   "black",
+		Skill:   int(ui.aiSkillSlider.Value),
+		Ponder:  ui.aiPonderCheck.Checked,
+	}
+	if ui.aiColorSelect.Selected == "White" {
+		s.AI.Color = "white"
+	}
+	s.Theme = ui.themeSelect.Selected
+	return s
+}
+
+// ResumeGame puts a saved game on the board and restores its settings
+func (ui *ChessUI) ResumeGame(s *savegame.Save) error {
+	if ui.showingOnline() {
+		return fmt.Errorf("resign the online game first")
+	}
+	position, err := s.Game()
+	if err != nil {
+		return err
+	}
+
+	if s.Theme != "" {
+		ui.themeSelect.SetSelected(s.Theme)
+	}
+	ui.aiEnabledCheck.SetChecked(false)
+	if s.AI.Skill != 0 {
+		ui.aiSkillSlider.SetValue(float64(s.AI.Skill))
+	}
+	ui.aiPonderCheck.SetChecked(s.AI.Ponder)
+	if s.AI.Color == "white" {
+		ui.aiColorSelect.SetSelected("White")
+	} else {
+		ui.aiColorSelect.SetSelected("Black")
+	}
+
+	ui.stopWatching()
+	ui.loadGame(position)
+	ui.aiEnabledCheck.SetChecked(s.AI.Enabled)
+	return nil
+}
+
+// saveGameAs asks for a file and saves the live game to it
+func (ui *ChessUI) saveGameAs() {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		path := writer.URI().Path()
+		writer.Close()
+
+		if err := ui.currentSave().Write(path); err != nil {
+			dialog.ShowError(err, ui.window)
+		}
+	}, ui.window)
+	save.SetFileName("game.json")
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	save.Show()
+}
+
+// openSavedGame asks for a saved game and resumes it
+func (ui *ChessUI) openSavedGame() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+
+		s, err := savegame.Load(path)
+		if err == nil {
+			err = ui.ResumeGame(s)
+		}
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		ui.autosave()
+	}, ui.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	open.Show()
+}
@@ -2,14 +2,18 @@ package gui
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/h3bzzz/go-chess/core/ai"
 	"github.com/h3bzzz/go-chess/core/game"
+	"github.com/h3bzzz/go-chess/core/gamedb"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 )
@@ -19,6 +23,7 @@ type ChessUI struct {
 	window         fyne.Window
 	board          *ChessBoard
 	status         *widget.Label
+	opening        *widget.Label
 	whiteTime      *widget.Label
 	blackTime      *widget.Label
 	content        *fyne.Container
@@ -27,33 +32,65 @@ type ChessUI struct {
 	aiManager      *ai.AIManager
 	aiEnabledCheck *widget.Check
 	aiColorSelect  *widget.Select
-	aiDiffSelect   *widget.Select
+	aiSkillSlider  *widget.Slider
+	aiSkillLabel   *widget.Label
+	aiPonderCheck  *widget.Check
+	variantSelect  *widget.Select
+	themeSelect    *widget.Select
+
+	// online is the connection to a chess server, nil when playing locally
+	online *onlineSession
+	// correspondence is the correspondence game picker, nil until opened
+	correspondence *correspondenceDesk
+	// gameDB is the game database, nil until opened
+	gameDB *gamedb.DB
+	// database is the game database browser, nil until opened
+	database *gameBrowser
+	// explorer lists the database moves from the position on the board
+	explorer *openingExplorer
+	// moves lists the moves of the live game
+	moves *moveList
+	// viewedPly is the number of half-moves of the past position on the
+	// board while viewing history
+	viewedPly int
+	// autosavePath is where the local game is saved after every move
+	autosavePath string
+
+	evalBar        *EvalBar
+	evalGraph      *EvalGraph
+	evalMutex      sync.Mutex
+	evals          []int
+	evalGeneration int
 }
 
+// evalDepth is the search depth used for the evaluation bar and graph
+const evalDepth = 2
+
 func NewChessUI(chessGame *game.GameState, window fyne.Window) *ChessUI {
 	ui := &ChessUI{
 		game:      chessGame,
 		window:    window,
 		status:    widget.NewLabel("White to move"),
+		opening:   widget.NewLabel(""),
 		whiteTime: widget.NewLabel("30:00"),
 		blackTime: widget.NewLabel("30:00"),
-		timerChan: make(chan bool),
+		timerChan: make(chan bool, 1),
 	}
 
 	ui.board = NewChessBoard(chessGame, "classic")
+	ui.board.SetMoveCallback(ui.onBoardMove)
 	ui.aiManager = ai.NewAIManager(chessGame)
+	ui.registerAICallback()
 
-	// Register callback for AI moves
-	ui.aiManager.SetMoveCallback(func() {
-		// Queue an update to run on the main thread
-		ui.window.Canvas().Refresh(ui.board.GetContainer())
-		ui.board.UpdateDisplay()
-		ui.updateStatus()
-		fmt.Println("Board display updated after AI move")
-	})
+	ui.evalBar = NewEvalBar()
+	ui.evalGraph = NewEvalGraph()
+	ui.evalGraph.OnSelected = ui.showPly
 
 	ui.createLayout()
+	ui.updateMoveList()
+	ui.registerShortcuts()
 	ui.startTimer()
+	ui.updateEvaluation()
 
 	return ui
 }
@@ -62,31 +99,98 @@ func (ui *ChessUI) GetContent() fyne.CanvasObject {
 	return ui.content
 }
 
+// registerAICallback refreshes the board whenever the AI manager makes a move
+func (ui *ChessUI) registerAICallback() {
+	ui.aiManager.SetMoveCallback(func() {
+		// Queue an update to run on the main thread
+		ui.window.Canvas().Refresh(ui.board.GetContainer())
+		ui.board.UpdateDisplay()
+		ui.onMovePlayed()
+		fmt.Println("Board display updated after AI move")
+	})
+}
+
+// onBoardMove is called after the user has made a move on the board
+func (ui *ChessUI) onBoardMove() {
+	ui.sendOnlineMove()
+	ui.saveCorrespondenceMove()
+	ui.onMovePlayed()
+}
+
+// onMovePlayed is called after either side has made a move on the live game
+func (ui *ChessUI) onMovePlayed() {
+	ui.updateStatus()
+	ui.updateMoveList()
+	ui.updateOpening()
+	ui.updateExplorer()
+	ui.updateEvaluation()
+	ui.autosave()
+	ui.board.playPremove()
+}
+
 func (ui *ChessUI) createLayout() {
 	newGameBtn := widget.NewButton("New Game", func() {
 		ui.newGame()
 	})
 
+	variants := []string{"Standard", "Chess960"}
+	for _, variant := range game.Variants {
+		variants = append(variants, variant.Name())
+	}
+	ui.variantSelect = widget.NewSelect(variants, nil)
+	ui.variantSelect.SetSelected("Standard")
+
 	undoBtn := widget.NewButton("Undo Move", func() {
 		ui.undoMove()
 	})
 
+	flipBtn := widget.NewButton("Flip Board", ui.board.Flip)
+
+	analyzeBtn := widget.NewButton("Analyze Game", func() {
+		ui.analyzeGame()
+	})
+
+	puzzlesBtn := widget.NewButton("Puzzles", func() {
+		ui.showPuzzles()
+	})
+
+	onlineBtn := widget.NewButton("Play Online", func() {
+		ui.showOnline()
+	})
+
+	correspondenceBtn := widget.NewButton("Correspondence", func() {
+		ui.showCorrespondence()
+	})
+
+	databaseBtn := widget.NewButton("Games", func() {
+		ui.showGameDatabase()
+	})
+
+	saveBtn := widget.NewButton("Save", func() {
+		ui.saveGameAs()
+	})
+
+	loadBtn := widget.NewButton("Load", func() {
+		ui.openSavedGame()
+	})
+
 	testImageBtn := widget.NewButton("Test Images", func() {
 		ui.testImages()
 	})
 
 	fmt.Println("Initializing theme selector...")
 	themes := []string{"Classic", "Green", "Pink"}
-	themeSelector := widget.NewSelect(themes, func(selectedTheme string) {
+	ui.themeSelect = widget.NewSelect(themes, func(selectedTheme string) {
 		fmt.Printf("Theme selected from dropdown: %s\n", selectedTheme)
 		ui.changeTheme(selectedTheme)
 	})
-	themeSelector.SetSelected("Classic")
+	ui.themeSelect.SetSelected("Classic")
 	fmt.Println("Theme selector initialized with Classic theme")
 
 	// AI Controls
 	ui.aiEnabledCheck = widget.NewCheck("Enable AI", func(enabled bool) {
 		ui.aiManager.SetEnabled(enabled)
+		ui.orientBoard()
 	})
 
 	ui.aiColorSelect = widget.NewSelect([]string{"White", "Black"}, func(color string) {
@@ -95,40 +199,49 @@ func (ui *ChessUI) createLayout() {
 		} else {
 			ui.aiManager.SetAIColor(game.BlackPlayer)
 		}
+		ui.orientBoard()
 	})
 	ui.aiColorSelect.SetSelected("Black")
 
-	ui.aiDiffSelect = widget.NewSelect([]string{"Easy", "Medium", "Hard"}, func(level string) {
-		var difficulty int
-		switch level {
-		case "Easy":
-			difficulty = 1
-		case "Medium":
-			difficulty = 2
-		case "Hard":
-			difficulty = 3
-		default:
-			difficulty = 2
-		}
-		ui.aiManager.SetDifficulty(difficulty)
+	ui.aiSkillLabel = widget.NewLabel("")
+	ui.aiSkillSlider = widget.NewSlider(ai.MinSkillLevel, ai.MaxSkillLevel)
+	ui.aiSkillSlider.Step = 1
+	ui.aiSkillSlider.OnChanged = func(value float64) {
+		ui.setSkillLevel(int(value))
+	}
+	ui.aiSkillSlider.SetValue(ai.DefaultSkillLevel)
+
+	ui.aiPonderCheck = widget.NewCheck("Think on my time", func(enabled bool) {
+		ui.aiManager.SetPondering(enabled)
 	})
-	ui.aiDiffSelect.SetSelected("Medium")
 
 	aiControls := container.NewVBox(
 		widget.NewLabel("AI Settings"),
 		ui.aiEnabledCheck,
 		container.NewHBox(widget.NewLabel("AI plays:"), ui.aiColorSelect),
-		container.NewHBox(widget.NewLabel("Difficulty:"), ui.aiDiffSelect),
+		ui.aiSkillLabel,
+		ui.aiSkillSlider,
+		ui.aiPonderCheck,
 	)
 
 	// Layout components
 	header := container.NewHBox(
 		ui.status,
+		ui.opening,
 		layout.NewSpacer(),
 		widget.NewLabel("Theme:"),
-		themeSelector,
+		ui.themeSelect,
 		undoBtn,
+		flipBtn,
+		ui.variantSelect,
 		newGameBtn,
+		saveBtn,
+		loadBtn,
+		analyzeBtn,
+		puzzlesBtn,
+		onlineBtn,
+		correspondenceBtn,
+		databaseBtn,
 		testImageBtn,
 	)
 
@@ -143,14 +256,17 @@ func (ui *ChessUI) createLayout() {
 		ui.blackTime,
 	)
 
-	// Create a right panel for AI controls
-	rightPanel := container.NewVBox(
-		aiControls,
+	// Create a right panel for AI controls, the move list and the opening
+	// explorer
+	rightPanel := container.NewBorder(
+		aiControls, nil, nil, nil,
+		container.NewVSplit(ui.newMoveList(), ui.newOpeningExplorer()),
 	)
 
-	// Main layout with board in center and AI controls on right
+	// Main layout with board in center, eval bar on the left, the
+	// evaluation graph below and AI controls on right
 	mainContainer := container.NewBorder(
-		nil, nil, nil, rightPanel, ui.board.GetContainer(),
+		nil, ui.evalGraph, ui.evalBar, rightPanel, ui.board.GetContainer(),
 	)
 
 	// Overall layout with header and footer
@@ -163,6 +279,38 @@ func (ui *ChessUI) createLayout() {
 	)
 }
 
+// registerShortcuts binds the keyboard shortcuts of the main window
+func (ui *ChessUI) registerShortcuts() {
+	// Ctrl+F (Cmd+F on macOS) flips the board
+	ui.window.Canvas().AddShortcut(
+		&desktop.CustomShortcut{KeyName: fyne.KeyF, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { ui.board.Flip() },
+	)
+	// The arrow keys, Home and End step through the moves
+	ui.window.Canvas().SetOnTypedKey(ui.navigateMoves)
+}
+
+// orientBoard limits the user to their side and turns it to the bottom when
+// playing the AI, so moves made during the AI's turn are premoves
+func (ui *ChessUI) orientBoard() {
+	if ui.aiEnabledCheck.Checked {
+		human := game.WhitePlayer
+		if ui.aiColorSelect.Selected == "White" {
+			human = game.BlackPlayer
+		}
+		ui.board.SetPlayerColor(human)
+	} else if !ui.showingOnline() && !ui.showingCorrespondence() {
+		ui.board.SetPlayerColor(AnyPlayer)
+	}
+}
+
+// setSkillLevel applies the skill slider value to the AI and its label
+func (ui *ChessUI) setSkillLevel(level int) {
+	skill := ai.NewSkill(level)
+	ui.aiManager.SetSkillLevel(skill.Level)
+	ui.aiSkillLabel.SetText(fmt.Sprintf("Skill: %d", skill.Level))
+}
+
 func (ui *ChessUI) updateStatus() {
 	ui.status.SetText(ui.game.GetGameStatus())
 
@@ -175,6 +323,18 @@ func (ui *ChessUI) updateStatus() {
 	if ui.game.IsGameOver() {
 		ui.stopTimer()
 	}
+	if ui.showingCorrespondence() {
+		ui.showDeadline()
+	}
+}
+
+// updateOpening names the opening of the live game in the header
+func (ui *ChessUI) updateOpening() {
+	if opening, ok := ui.game.ClassifyOpening(); ok {
+		ui.opening.SetText(fmt.Sprintf("%s %s", opening.ECO, opening.Name))
+	} else {
+		ui.opening.SetText("")
+	}
 }
 
 func formatTime(d time.Duration) string {
@@ -185,12 +345,13 @@ func formatTime(d time.Duration) string {
 
 func (ui *ChessUI) startTimer() {
 	ui.game.StartTimer()
-	ui.timer = time.NewTicker(1 * time.Second)
+	ticker := time.NewTicker(1 * time.Second)
+	ui.timer = ticker
 
 	go func() {
 		for {
 			select {
-			case <-ui.timer.C:
+			case <-ticker.C:
 				ui.updateStatus()
 			case <-ui.timerChan:
 				return
@@ -203,34 +364,156 @@ func (ui *ChessUI) stopTimer() {
 	ui.game.StopTimer()
 	if ui.timer != nil {
 		ui.timer.Stop()
+		ui.timer = nil
 		ui.timerChan <- true
 	}
 }
 
 func (ui *ChessUI) newGame() {
+	if ui.playingOnline() {
+		dialog.ShowInformation("New Game", "Resign the online game first.", ui.window)
+		return
+	}
+	ui.stopWatching()
+	ui.loadGame(ui.newVariantGame(ui.variantSelect.Selected))
+	ui.autosave()
+}
+
+// loadGame replaces the live game and restarts the AI, evaluation and clock
+// for it. A correspondence game on the board is left.
+func (ui *ChessUI) loadGame(chessGame *game.GameState) {
 	ui.stopTimer()
+	ui.closeCorrespondenceGame()
 
-	ui.game = game.NewGame()
+	ui.game = chessGame
 
 	ui.board.game = ui.game
-	ui.board.UpdateDisplay()
+	ui.board.clearPremove()
+	ui.board.SetViewPosition(nil)
 
 	ui.aiManager.Stop()
 	ui.aiManager = ai.NewAIManager(ui.game)
+	ui.registerAICallback()
+	ui.aiManager.SetSkillLevel(int(ui.aiSkillSlider.Value))
+	ui.aiManager.SetPondering(ui.aiPonderCheck.Checked)
+	if ui.aiColorSelect.Selected == "White" {
+		ui.aiManager.SetAIColor(game.WhitePlayer)
+	}
 	if ui.aiEnabledCheck.Checked {
 		ui.aiManager.SetEnabled(true)
 	}
 
+	ui.evalMutex.Lock()
+	ui.evals = nil
+	ui.evalGeneration++
+	ui.evalMutex.Unlock()
+	ui.evalGraph.SetSelected(-1)
+
 	ui.updateStatus()
+	ui.updateMoveList()
+	ui.updateOpening()
+	ui.updateExplorer()
+	ui.updateEvaluation()
 
 	ui.startTimer()
 }
 
+// newVariantGame starts a game of the named variant, falling back to
+// standard chess
+func (ui *ChessUI) newVariantGame(name string) *game.GameState {
+	var position *game.GameState
+	var err error
+	if name == "Chess960" {
+		id := game.RandomChess960ID()
+		fmt.Printf("Starting Chess960 position %d\n", id)
+		position, err = game.NewChess960Game(id)
+	} else if variant, ok := game.VariantByName(name); ok {
+		position, err = game.NewVariantGame(variant)
+	} else {
+		return game.NewGame()
+	}
+
+	if err != nil {
+		fmt.Printf("Error creating %s game: %v\n", name, err)
+		return game.NewGame()
+	}
+	return position
+}
+
 func (ui *ChessUI) undoMove() {
+	if ui.showingOnline() || ui.showingCorrespondence() {
+		return
+	}
 	if ui.game.UndoLastMove() {
-		ui.board.UpdateDisplay()
+		ui.board.clearPremove()
+		ui.board.SetViewPosition(nil)
 		ui.updateStatus()
+		ui.updateMoveList()
+		ui.updateOpening()
+		ui.updateExplorer()
+
+		ui.evalMutex.Lock()
+		if len(ui.evals) > len(ui.game.MoveHistory)+1 {
+			ui.evals = ui.evals[:len(ui.game.MoveHistory)+1]
+		}
+		ui.evalMutex.Unlock()
+		ui.evalGraph.SetSelected(-1)
+		ui.updateEvaluation()
+		ui.autosave()
+	}
+}
+
+// updateEvaluation searches the live position in the background and feeds
+// the result to the eval bar and graph
+func (ui *ChessUI) updateEvaluation() {
+	snapshot := ui.game.Clone()
+	ply := len(snapshot.MoveHistory)
+
+	ui.evalMutex.Lock()
+	generation := ui.evalGeneration
+	ui.evalMutex.Unlock()
+
+	go func() {
+		score := ai.EvaluatePosition(snapshot, evalDepth)
+
+		ui.evalMutex.Lock()
+		if generation != ui.evalGeneration {
+			ui.evalMutex.Unlock()
+			return
+		}
+		for len(ui.evals) <= ply {
+			ui.evals = append(ui.evals, score)
+		}
+		ui.evals[ply] = score
+		scores := append([]int{}, ui.evals...)
+		ui.evalMutex.Unlock()
+
+		ui.evalGraph.SetScores(scores)
+		if !ui.board.IsViewingHistory() {
+			ui.evalBar.SetScore(score)
+		}
+	}()
+}
+
+// showPly displays the position after the given number of half-moves. The
+// last ply returns the board to the live game.
+func (ui *ChessUI) showPly(ply int) {
+	if ply >= len(ui.game.MoveHistory) {
+		ui.board.SetViewPosition(nil)
+		ui.evalGraph.SetSelected(-1)
+	} else {
+		ui.viewedPly = ply
+		ui.board.SetViewPosition(ui.game.PositionAt(ply))
+		ui.evalGraph.SetSelected(ply)
+	}
+	ui.refreshMoveList()
+	ui.updateExplorer()
+
+	ui.evalMutex.Lock()
+	if ply < len(ui.evals) {
+		ui.evalBar.SetScore(ui.evals[ply])
 	}
+	ui.evalMutex.Unlock()
 }
 
 func (ui *ChessUI) changeTheme(theme string) {
@@ -0,0 +1,65 @@
+package match
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// LoadOpenings reads opening positions from a PGN file, whose games are
+// replayed move by move, or from a text file with one FEN or EPD per line
+func LoadOpenings(path string) ([]*game.GameState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var openings []*game.GameState
+
+	if strings.EqualFold(filepath.Ext(path), ".pgn") {
+		games, err := game.ParsePGN(file)
+		if err != nil {
+			return nil, err
+		}
+		for i, pgn := range games {
+			opening, err := pgn.Game()
+			if err != nil {
+				return nil, fmt.Errorf("%s: game %d: %w", path, i+1, err)
+			}
+			opening.GameStatus = game.InProgress
+			openings = append(openings, opening)
+		}
+		return openings, nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fen := line
+		if strings.Contains(line, ";") {
+			epd, err := game.ParseEPD(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+			}
+			fen = epd.FEN
+		}
+		opening, err := game.NewGameFromFEN(fen)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		openings = append(openings, opening)
+	}
+
+	return openings, scanner.Err()
+}
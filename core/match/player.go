@@ -0,0 +1,80 @@
+package match
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// Player is one side of an engine match
+type Player interface {
+	Name() string
+	// NewGame resets any per-game state before a game starts
+	NewGame() error
+	// BestMove returns the move to play in UCI notation and its score in
+	// centipawns from the mover's point of view
+	BestMove(position *game.GameState) (string, int, error)
+	Close() error
+}
+
+// EnginePlayer plays with the built-in search
+type EnginePlayer struct {
+	name   string
+	limits ai.SearchLimits
+	skill  ai.Skill
+	random *rand.Rand
+}
+
+// NewEnginePlayer creates a built-in engine player. A skill level below
+// ai.MaxSkillLevel replaces the search bounds with those of the skill level,
+// keeping the weights and thread count.
+func NewEnginePlayer(name string, limits ai.SearchLimits, skillLevel int) *EnginePlayer {
+	skill := ai.NewSkill(skillLevel)
+	if skill.Level < ai.MaxSkillLevel {
+		skillLimits := skill.Limits()
+		skillLimits.Weights = limits.Weights
+		skillLimits.Threads = limits.Threads
+		limits = skillLimits
+	}
+	return &EnginePlayer{
+		name:   name,
+		limits: limits,
+		skill:  skill,
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (p *EnginePlayer) Name() string {
+	return p.name
+}
+
+func (p *EnginePlayer) NewGame() error {
+	return nil
+}
+
+func (p *EnginePlayer) BestMove(position *game.GameState) (string, int, error) {
+	var move ai.Move
+	if p.skill.Level < ai.MaxSkillLevel {
+		moves := ai.SearchRootMoves(position, p.limits)
+		if len(moves) == 0 {
+			return "", 0, fmt.Errorf("%s: no legal moves", p.name)
+		}
+		move = p.skill.PickMove(moves, p.random)
+	} else {
+		result := ai.SearchWithLimits(position, p.limits)
+		if result.Move.Piece == game.Empty {
+			return "", 0, fmt.Errorf("%s: no legal moves", p.name)
+		}
+		move = result.Move
+		move.Score = result.Score
+	}
+
+	return position.UCI(move.From, move.To, move.Promotion), move.Score, nil
+}
+
+func (p *EnginePlayer) Close() error {
+	return nil
+}
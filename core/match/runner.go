@@ -0,0 +1,187 @@
+package match
+
+import (
+	"fmt"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// Config controls how match games are played and adjudicated. Score based
+// adjudication is disabled when the corresponding move count is zero.
+type Config struct {
+	Games int
+	// Openings are the positions games start from, each played once with
+	// either color. A nil entry or an empty list means the standard position.
+	Openings []*game.GameState
+	// MaxPlies ends a game as a draw after this many half-moves
+	MaxPlies int
+
+	// A side loses once its own score stays below -ResignScore for
+	// ResignMoves consecutive moves
+	ResignScore int
+	ResignMoves int
+
+	// A game is drawn once both scores stay within DrawScore for DrawMoves
+	// consecutive moves each, after DrawMinPly half-moves
+	DrawScore  int
+	DrawMoves  int
+	DrawMinPly int
+
+	// SPRT stops the match early once the test is decided
+	SPRT *SPRT
+}
+
+// DefaultConfig returns sensible adjudication settings for quick matches
+func DefaultConfig() Config {
+	return Config{
+		Games:       100,
+		MaxPlies:    400,
+		ResignScore: 1000,
+		ResignMoves: 3,
+		DrawScore:   10,
+		DrawMoves:   8,
+		DrawMinPly:  80,
+	}
+}
+
+// GameRecord is a finished match game
+type GameRecord struct {
+	White  string
+	Black  string
+	Result string
+	Reason string
+	Game   *game.GameState
+}
+
+// PGN returns the game in PGN form with match tags
+func (r *GameRecord) PGN(event string, round int) *game.PGNGame {
+	pgn := r.Game.ToPGN()
+	pgn.Tags["Event"] = event
+	pgn.Tags["Round"] = fmt.Sprint(round)
+	pgn.Tags["White"] = r.White
+	pgn.Tags["Black"] = r.Black
+	pgn.Tags["Result"] = r.Result
+	pgn.Tags["Termination"] = r.Reason
+	return pgn
+}
+
+// Run plays cfg.Games games between a and b, alternating colors on each
+// opening, and returns the score from a's point of view. onGame, if not
+// nil, is called after every game.
+func Run(a, b Player, cfg Config, onGame func(round int, record *GameRecord, stats *Stats)) (*Stats, error) {
+	stats := &Stats{}
+	openings := cfg.Openings
+	if len(openings) == 0 {
+		openings = []*game.GameState{nil}
+	}
+
+	for round := 0; round < cfg.Games; round++ {
+		opening := openings[(round/2)%len(openings)]
+		white, black := a, b
+		if round%2 == 1 {
+			white, black = b, a
+		}
+
+		record, err := PlayGame(white, black, opening, cfg)
+		if err != nil {
+			return stats, fmt.Errorf("game %d: %w", round+1, err)
+		}
+
+		switch {
+		case record.Result == "1/2-1/2":
+			stats.Draws++
+		case (record.Result == "1-0") == (white == a):
+			stats.Wins++
+		default:
+			stats.Losses++
+		}
+
+		if onGame != nil {
+			onGame(round+1, record, stats)
+		}
+
+		if cfg.SPRT != nil && cfg.SPRT.Status(stats) != SPRTContinue {
+			break
+		}
+	}
+
+	return stats, nil
+}
+
+// PlayGame plays a single game from the opening position, which may be nil
+// for the standard start
+func PlayGame(white, black Player, opening *game.GameState, cfg Config) (*GameRecord, error) {
+	position := game.NewGame()
+	if opening != nil {
+		position = opening.Clone()
+		position.GameStatus = game.InProgress
+	}
+	position.TimerActive = false
+
+	record := &GameRecord{White: white.Name(), Black: black.Name(), Game: position}
+	for _, player := range []Player{white, black} {
+		if err := player.NewGame(); err != nil {
+			return nil, err
+		}
+	}
+
+	repetitions := map[string]int{position.PositionKey(): 1}
+	losingStreak := [2]int{}
+	drawStreak := 0
+
+	for {
+		if result, reason, over := position.Outcome(repetitions); over {
+			record.Result, record.Reason = result, reason
+			return record, nil
+		}
+		if cfg.MaxPlies > 0 && len(position.MoveHistory) >= cfg.MaxPlies {
+			record.Result, record.Reason = "1/2-1/2", "max moves"
+			return record, nil
+		}
+
+		mover := position.CurrentTurn
+		player := white
+		if mover == game.BlackPlayer {
+			player = black
+		}
+
+		uciMove, score, err := player.BestMove(position)
+		if err != nil {
+			return nil, err
+		}
+		from, to, promotion, err := position.ParseUCI(uciMove)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", player.Name(), err)
+		}
+		if position.MakeMoveWithPromotion(from, to, promotion) == game.InvalidMove {
+			// An illegal move forfeits the game
+			record.Result, record.Reason = game.WinResult(1-mover), fmt.Sprintf("illegal move %s by %s", uciMove, player.Name())
+			return record, nil
+		}
+		repetitions[position.PositionKey()]++
+
+		if cfg.ResignMoves > 0 {
+			if score <= -cfg.ResignScore {
+				losingStreak[mover]++
+			} else {
+				losingStreak[mover] = 0
+			}
+			if losingStreak[mover] >= cfg.ResignMoves {
+				record.Result, record.Reason = game.WinResult(1-mover), "adjudication"
+				return record, nil
+			}
+		}
+
+		if cfg.DrawMoves > 0 && len(position.MoveHistory) >= cfg.DrawMinPly {
+			if score >= -cfg.DrawScore && score <= cfg.DrawScore {
+				drawStreak++
+			} else {
+				drawStreak = 0
+			}
+			if drawStreak >= 2*cfg.DrawMoves {
+				record.Result, record.Reason = "1/2-1/2", "adjudication"
+				return record, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package match
+
+import (
+	"fmt"
+	"math"
+)
+
+// Stats counts match results from the first player's point of view
+type Stats struct {
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+// Games returns the number of games played
+func (s *Stats) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Score returns the first player's average score between 0 and 1
+func (s *Stats) Score() float64 {
+	if s.Games() == 0 {
+		return 0.5
+	}
+	return (float64(s.Wins) + float64(s.Draws)/2) / float64(s.Games())
+}
+
+// variance returns the per-game variance of the score
+func (s *Stats) variance() float64 {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0
+	}
+	mean := s.Score()
+	return (float64(s.Wins)*math.Pow(1-mean, 2) +
+		float64(s.Draws)*math.Pow(0.5-mean, 2) +
+		float64(s.Losses)*math.Pow(mean, 2)) / n
+}
+
+// Elo returns the estimated rating difference of the first player and the
+// half width of its 95% confidence interval
+func (s *Stats) Elo() (float64, float64) {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0, 0
+	}
+
+	score := s.Score()
+	margin := 1.959964 * math.Sqrt(s.variance()/n)
+	low := scoreToElo(score - margin)
+	high := scoreToElo(score + margin)
+	return scoreToElo(score), (high - low) / 2
+}
+
+func (s *Stats) String() string {
+	elo, margin := s.Elo()
+	return fmt.Sprintf("W/D/L: %d/%d/%d  score %.1f%%  Elo %+.1f ± %.1f",
+		s.Wins, s.Draws, s.Losses, s.Score()*100, elo, margin)
+}
+
+// scoreToElo converts an expected score to a rating difference, clamped so
+// that perfect scores stay finite
+func scoreToElo(score float64) float64 {
+	score = math.Max(0.001, math.Min(0.999, score))
+	return -400 * math.Log10(1/score-1)
+}
+
+func eloToScore(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// SPRTStatus is the state of a sequential probability ratio test
+type SPRTStatus int
+
+const (
+	SPRTContinue SPRTStatus = iota
+	SPRTAcceptH0
+	SPRTAcceptH1
+)
+
+func (s SPRTStatus) String() string {
+	switch s {
+	case SPRTAcceptH0:
+		return "H0 accepted"
+	case SPRTAcceptH1:
+		return "H1 accepted"
+	default:
+		return "continue"
+	}
+}
+
+// SPRT tests H0: elo = Elo0 against H1: elo = Elo1 with error rates Alpha
+// and Beta
+type SPRT struct {
+	Elo0  float64
+	Elo1  float64
+	Alpha float64
+	Beta  float64
+}
+
+// Bounds returns the lower and upper log-likelihood ratio bounds
+func (t *SPRT) Bounds() (float64, float64) {
+	return math.Log(t.Beta / (1 - t.Alpha)), math.Log((1 - t.Beta) / t.Alpha)
+}
+
+// LLR returns the log-likelihood ratio of the results using the normal
+// approximation of the trinomial model. It stays 0 until the results show
+// some variance, e.g. while one side has won every game.
+func (t *SPRT) LLR(stats *Stats) float64 {
+	variance := stats.variance()
+	if stats.Games() == 0 || variance == 0 {
+		return 0
+	}
+
+	s0, s1 := eloToScore(t.Elo0), eloToScore(t.Elo1)
+	return float64(stats.Games()) * (s1 - s0) * (2*stats.Score() - s0 - s1) / (2 * variance)
+}
+
+// Status decides whether the test can stop
+func (t *SPRT) Status(stats *Stats) SPRTStatus {
+	lower, upper := t.Bounds()
+	llr := t.LLR(stats)
+	switch {
+	case llr >= upper:
+		return SPRTAcceptH1
+	case llr <= lower:
+		return SPRTAcceptH0
+	default:
+		return SPRTContinue
+	}
+}
+
+// Describe summarizes the test state for progress output
+func (t *SPRT) Describe(stats *Stats) string {
+	lower, upper := t.Bounds()
+	return fmt.Sprintf("SPRT [%.1f, %.1f]: LLR %.2f (%.2f, %.2f) %s",
+		t.Elo0, t.Elo1, t.LLR(stats), lower, upper, t.Status(stats))
+}
@@ -0,0 +1,218 @@
+package match
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// uciTimeout is how long to wait for an engine to answer a handshake
+const uciTimeout = 10 * time.Second
+
+// UCIPlayer drives an external engine speaking the Universal Chess Interface
+type UCIPlayer struct {
+	name    string
+	limits  ai.SearchLimits
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string
+	options map[string]string
+}
+
+// NewUCIPlayer starts the engine executable and performs the UCI handshake.
+// options are sent with setoption before the engine is used.
+func NewUCIPlayer(name, path string, args []string, limits ai.SearchLimits, options map[string]string) (*UCIPlayer, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", path, err)
+	}
+
+	p := &UCIPlayer{
+		name:    name,
+		limits:  limits,
+		cmd:     cmd,
+		stdin:   stdin,
+		lines:   make(chan string, 64),
+		options: options,
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			p.lines <- scanner.Text()
+		}
+		close(p.lines)
+	}()
+
+	p.send("uci")
+	if _, err := p.waitFor("uciok", uciTimeout); err != nil {
+		p.Close()
+		return nil, err
+	}
+	for option, value := range options {
+		p.send(fmt.Sprintf("setoption name %s value %s", option, value))
+	}
+
+	return p, nil
+}
+
+func (p *UCIPlayer) Name() string {
+	return p.name
+}
+
+func (p *UCIPlayer) NewGame() error {
+	p.send("ucinewgame")
+	p.send("isready")
+	_, err := p.waitFor("readyok", uciTimeout)
+	return err
+}
+
+func (p *UCIPlayer) BestMove(position *game.GameState) (string, int, error) {
+	p.send(PositionCommand(position))
+
+	goCmd := "go"
+	if p.limits.Depth > 0 {
+		goCmd += fmt.Sprintf(" depth %d", p.limits.Depth)
+	}
+	if p.limits.Nodes > 0 {
+		goCmd += fmt.Sprintf(" nodes %d", p.limits.Nodes)
+	}
+	if p.limits.MoveTime > 0 {
+		goCmd += fmt.Sprintf(" movetime %d", p.limits.MoveTime.Milliseconds())
+	}
+	p.send(goCmd)
+
+	// Without a time limit give deep searches plenty of room
+	timeout := 10 * time.Minute
+	if p.limits.MoveTime > 0 {
+		timeout = p.limits.MoveTime*2 + uciTimeout
+	}
+
+	score := 0
+	deadline := time.After(timeout)
+	for {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				return "", 0, fmt.Errorf("%s: engine exited", p.name)
+			}
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			switch fields[0] {
+			case "info":
+				if s, ok := parseInfoScore(fields); ok {
+					score = s
+				}
+			case "bestmove":
+				if len(fields) < 2 {
+					return "", 0, fmt.Errorf("%s: malformed bestmove %q", p.name, line)
+				}
+				return fields[1], score, nil
+			}
+		case <-deadline:
+			return "", 0, fmt.Errorf("%s: no bestmove after %v", p.name, timeout)
+		}
+	}
+}
+
+func (p *UCIPlayer) Close() error {
+	p.send("quit")
+	p.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- p.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(uciTimeout):
+		return p.cmd.Process.Kill()
+	}
+}
+
+func (p *UCIPlayer) send(command string) {
+	fmt.Fprintln(p.stdin, command)
+}
+
+// waitFor reads engine output until a line equal to token arrives
+func (p *UCIPlayer) waitFor(token string, timeout time.Duration) (string, error) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				return "", fmt.Errorf("%s: engine exited while waiting for %s", p.name, token)
+			}
+			if strings.TrimSpace(line) == token {
+				return line, nil
+			}
+			if fields := strings.Fields(line); len(fields) > 2 && fields[0] == "id" && fields[1] == "name" && p.name == "" {
+				p.name = strings.Join(fields[2:], " ")
+			}
+		case <-deadline:
+			return "", fmt.Errorf("%s: timed out waiting for %s", p.name, token)
+		}
+	}
+}
+
+// PositionCommand builds the UCI position command for a game, sending the
+// start position followed by every move played
+func PositionCommand(position *game.GameState) string {
+	var sb strings.Builder
+	if position.StartFEN == "" {
+		sb.WriteString("position startpos")
+	} else {
+		sb.WriteString("position fen " + position.StartFEN)
+	}
+
+	if len(position.MoveHistory) > 0 {
+		sb.WriteString(" moves")
+		replay := position.PositionAt(0)
+		for _, move := range position.MoveHistory {
+			sb.WriteString(" " + replay.UCI(move.From, move.To, move.Promotion))
+			replay.MakeMoveWithPromotion(move.From, move.To, move.Promotion)
+		}
+	}
+
+	return sb.String()
+}
+
+// parseInfoScore extracts the score of an info line, converting mate
+// distances to the built-in engine's mate scores
+func parseInfoScore(fields []string) (int, bool) {
+	for i := 0; i+2 < len(fields); i++ {
+		if fields[i] != "score" {
+			continue
+		}
+		value, err := strconv.Atoi(fields[i+2])
+		if err != nil {
+			return 0, false
+		}
+		switch fields[i+1] {
+		case "cp":
+			return value, true
+		case "mate":
+			if value > 0 {
+				return ai.MateScore - (2*value - 1), true
+			}
+			return -ai.MateScore - 2*value, true
+		}
+	}
+	return 0, false
+}
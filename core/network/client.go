@@ -0,0 +1,217 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Reconnection backoff of the client
+const (
+	reconnectDelay    = 250 * time.Millisecond
+	maxReconnectDelay = 5 * time.Second
+)
+
+// ErrDisconnected is returned for requests made while the client is
+// reconnecting
+var ErrDisconnected = errors.New("not connected to the server")
+
+// Client is a player's connection to a server. It reconnects on its own when
+// the connection drops and resumes the session, so games in progress carry
+// on.
+type Client struct {
+	address string
+	name    string
+
+	mu       sync.Mutex
+	conn     transport
+	token    string
+	closed   bool
+	messages chan Message
+}
+
+// Dial connects to a server at a TCP "host:port" address or a ws:// URL and
+// logs in under name
+func Dial(address, name string) (*Client, error) {
+	c := &Client{
+		address:  address,
+		name:     name,
+		messages: make(chan Message, 256),
+	}
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	go c.readLoop(conn)
+	return c, nil
+}
+
+// Name returns the name the client logged in with
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Messages delivers the server's messages, along with MsgDisconnected and
+// MsgReconnected, until the client is closed
+func (c *Client) Messages() <-chan Message {
+	return c.messages
+}
+
+// Seek posts a seek in the lobby, or a challenge when seek.Opponent is set
+func (c *Client) Seek(seek Seek) error {
+	return c.send(Message{Type: MsgSeek, Seek: &seek})
+}
+
+// Cancel withdraws one of the client's seeks
+func (c *Client) Cancel(seekID int) error {
+	return c.send(Message{Type: MsgCancel, SeekID: seekID})
+}
+
+// Accept accepts a seek or a challenge
+func (c *Client) Accept(seekID int) error {
+	return c.send(Message{Type: MsgAccept, SeekID: seekID})
+}
+
+// Decline turns down a challenge
+func (c *Client) Decline(seekID int) error {
+	return c.send(Message{Type: MsgDecline, SeekID: seekID})
+}
+
+// Move plays a move in UCI notation
+func (c *Client) Move(gameID int, move string) error {
+	return c.send(Message{Type: MsgMove, Game: gameID, Move: move})
+}
+
+// Resign resigns a game
+func (c *Client) Resign(gameID int) error {
+	return c.send(Message{Type: MsgResign, Game: gameID})
+}
+
+// Watch follows a game in progress as a spectator
+func (c *Client) Watch(gameID int) error {
+	return c.send(Message{Type: MsgWatch, Game: gameID})
+}
+
+// Unwatch stops following a game
+func (c *Client) Unwatch(gameID int) error {
+	return c.send(Message{Type: MsgUnwatch, Game: gameID})
+}
+
+// Close disconnects for good. Games in progress are left to the server's
+// clocks and abandonment rules.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+func (c *Client) send(msg Message) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return ErrDisconnected
+	}
+	return conn.WriteMessage(msg)
+}
+
+// connect opens a connection and logs in, resuming the session if the
+// client has one
+func (c *Client) connect() (transport, error) {
+	conn, err := dial(c.address)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+	if err := conn.WriteMessage(Message{Type: MsgHello, Name: c.name, Token: token}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reply, err := conn.ReadMessage()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if reply.Type != MsgWelcome {
+		conn.Close()
+		if reply.Type == MsgError {
+			return nil, errors.New(reply.Error)
+		}
+		return nil, fmt.Errorf("unexpected %q message from server", reply.Type)
+	}
+
+	c.mu.Lock()
+	c.token = reply.Token
+	c.mu.Unlock()
+	return conn, nil
+}
+
+// readLoop delivers messages and reconnects whenever the connection drops
+func (c *Client) readLoop(conn transport) {
+	defer close(c.messages)
+	for {
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				break
+			}
+			c.messages <- msg
+		}
+		conn.Close()
+
+		c.mu.Lock()
+		c.conn = nil
+		closed := c.closed
+		c.mu.Unlock()
+		if closed {
+			return
+		}
+		c.messages <- Message{Type: MsgDisconnected}
+
+		conn = c.reconnect()
+		if conn == nil {
+			return
+		}
+		c.messages <- Message{Type: MsgReconnected}
+	}
+}
+
+// reconnect retries with a growing delay until it is connected again or the
+// client is closed, in which case it returns nil
+func (c *Client) reconnect() transport {
+	delay := reconnectDelay
+	for {
+		time.Sleep(delay)
+
+		conn, err := c.connect()
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			if conn != nil {
+				conn.Close()
+			}
+			return nil
+		}
+		if err == nil {
+			c.conn = conn
+			c.mu.Unlock()
+			return conn
+		}
+		c.mu.Unlock()
+
+		delay = min(2*delay, maxReconnectDelay)
+	}
+}
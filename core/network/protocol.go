@@ -0,0 +1,216 @@
+// Package network hosts chess games between players over the network and
+// connects to such a server.
+//
+// Client and server exchange JSON messages: one per line over TCP, or one
+// per text frame over WebSocket. Every message has a type and only the
+// fields that type uses. Moves are in UCI notation and durations are in
+// nanoseconds, as encoding/json writes a time.Duration.
+package network
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// Message types sent by the client
+const (
+	// MsgHello logs in with Name, and with the Token of an earlier session
+	// to take it over after a reconnect
+	MsgHello = "hello"
+	// MsgSeek posts Seek in the lobby, or challenges Seek.Opponent
+	MsgSeek = "seek"
+	// MsgCancel withdraws the player's seek SeekID
+	MsgCancel = "cancel"
+	// MsgAccept accepts the seek or challenge SeekID and starts a game
+	MsgAccept = "accept"
+	// MsgResign resigns Game
+	MsgResign = "resign"
+	// MsgWatch subscribes to Game as a spectator. The server answers with
+	// MsgGame and then sends every move and the end of the game.
+	MsgWatch = "watch"
+	// MsgUnwatch stops watching Game
+	MsgUnwatch = "unwatch"
+)
+
+// Message types sent by the server
+const (
+	// MsgWelcome answers a hello with the session Token
+	MsgWelcome = "welcome"
+	// MsgLobby lists the open seeks, the players online and the games in
+	// progress
+	MsgLobby = "lobby"
+	// MsgChallenge tells a player Seek.Player has challenged them
+	MsgChallenge = "challenge"
+	// MsgGame describes a whole game: sent when it starts, when a player or
+	// spectator reconnects or starts watching, and after a rejected move
+	MsgGame = "game"
+	// MsgOpponent tells whether the opponent in Game is Online
+	MsgOpponent = "opponent"
+	// MsgEnd ends Game with Result and Termination
+	MsgEnd = "end"
+	// MsgError reports a request the server refused
+	MsgError = "error"
+)
+
+// Message types sent both ways
+const (
+	// MsgMove plays Move in Game. The server echoes it to both players and
+	// the spectators with the clocks after the move.
+	MsgMove = "move"
+	// MsgDecline turns down the challenge SeekID. The server passes it on to
+	// the challenger.
+	MsgDecline = "decline"
+)
+
+// Message types the Client delivers about its own connection
+const (
+	// MsgDisconnected reports the connection was lost; the client keeps
+	// reconnecting until it is closed
+	MsgDisconnected = "disconnected"
+	// MsgReconnected reports the session was resumed. The server follows up
+	// with the lobby and every game the player is playing or watching.
+	MsgReconnected = "reconnected"
+)
+
+// Message is one protocol message
+type Message struct {
+	Type  string `json:"type"`
+	Name  string `json:"name,omitempty"`
+	Token string `json:"token,omitempty"`
+
+	Seek    *Seek      `json:"seek,omitempty"`
+	SeekID  int        `json:"seek_id,omitempty"`
+	Seeks   []Seek     `json:"seeks,omitempty"`
+	Players []string   `json:"players,omitempty"`
+	Games   []GameInfo `json:"games,omitempty"`
+
+	Game  int    `json:"game,omitempty"`
+	White string `json:"white,omitempty"`
+	Black string `json:"black,omitempty"`
+	// Color is the color the recipient plays, empty for a spectator
+	Color       string       `json:"color,omitempty"`
+	Variant     string       `json:"variant,omitempty"`
+	FEN         string       `json:"fen,omitempty"`
+	TimeControl *TimeControl `json:"time_control,omitempty"`
+	Moves       []string     `json:"moves,omitempty"`
+	Move        string       `json:"move,omitempty"`
+	// Ply is the number of half-moves played after Move
+	Ply        int           `json:"ply,omitempty"`
+	WhiteClock time.Duration `json:"white_clock,omitempty"`
+	BlackClock time.Duration `json:"black_clock,omitempty"`
+	Online     bool          `json:"online,omitempty"`
+	Spectators int           `json:"spectators,omitempty"`
+
+	Result      string `json:"result,omitempty"`
+	Termination string `json:"termination,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// TimeControl is a game's starting time per player and the time added
+// after each move
+type TimeControl struct {
+	Base      time.Duration `json:"base"`
+	Increment time.Duration `json:"increment,omitempty"`
+}
+
+func (tc TimeControl) String() string {
+	return fmt.Sprintf("%g+%g", tc.Base.Minutes(), tc.Increment.Seconds())
+}
+
+// Seek is an offer to play. A seek with an Opponent is a challenge that
+// only that player sees and can accept.
+type Seek struct {
+	ID       int    `json:"id,omitempty"`
+	Player   string `json:"player,omitempty"`
+	Opponent string `json:"opponent,omitempty"`
+	// Variant is "Standard", "Chess960" or the name of one of game.Variants
+	Variant     string      `json:"variant,omitempty"`
+	TimeControl TimeControl `json:"time_control"`
+	// Color is the seeker's color, "white" or "black"; empty picks one at
+	// random
+	Color string `json:"color,omitempty"`
+}
+
+// GameInfo describes a game in progress in the lobby
+type GameInfo struct {
+	ID          int         `json:"id"`
+	White       string      `json:"white"`
+	Black       string      `json:"black"`
+	Variant     string      `json:"variant,omitempty"`
+	TimeControl TimeControl `json:"time_control"`
+	Ply         int         `json:"ply,omitempty"`
+	Spectators  int         `json:"spectators,omitempty"`
+}
+
+// Colors as written in messages
+const (
+	White = "white"
+	Black = "black"
+)
+
+// ColorName returns the message color of a player
+func ColorName(player int) string {
+	if player == game.BlackPlayer {
+		return Black
+	}
+	return White
+}
+
+// ReplayGame rebuilds the game described by a MsgGame message, with the
+// clocks set to the times the server sent
+func ReplayGame(msg Message) (*game.GameState, error) {
+	g, err := newGame(msg.Variant, msg.FEN)
+	if err != nil {
+		return nil, err
+	}
+	for i, move := range msg.Moves {
+		if err := playUCI(g, move); err != nil {
+			return nil, fmt.Errorf("move %d: %v", i+1, err)
+		}
+	}
+	g.WhitePlayerTime = msg.WhiteClock
+	g.BlackPlayerTime = msg.BlackClock
+	g.TimerActive = false
+	return g, nil
+}
+
+// newGame creates a game of the named variant. An empty fen starts from the
+// variant's usual start position.
+func newGame(variant, fen string) (*game.GameState, error) {
+	chess960 := variant == "Chess960"
+	var rules game.Variant
+	if variant != "" && variant != "Standard" && !chess960 {
+		var ok bool
+		if rules, ok = game.VariantByName(variant); !ok {
+			return nil, fmt.Errorf("unknown variant %q", variant)
+		}
+		if fen == "" {
+			fen = rules.StartFEN()
+		}
+	}
+
+	g := game.NewGame()
+	if fen != "" {
+		var err error
+		if g, err = game.NewGameFromFEN(fen); err != nil {
+			return nil, err
+		}
+	}
+	g.Chess960 = g.Chess960 || chess960
+	g.SetVariant(rules)
+	return g, nil
+}
+
+// playUCI makes a legal move given in UCI notation
+func playUCI(g *game.GameState, move string) error {
+	from, to, promotion, err := g.ParseUCI(move)
+	if err != nil {
+		return err
+	}
+	if !g.IsLegal(from, to, promotion) || g.MakeMoveWithPromotion(from, to, promotion) == game.InvalidMove {
+		return fmt.Errorf("illegal move %q", move)
+	}
+	return nil
+}
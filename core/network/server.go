@@ -0,0 +1,774 @@
+package network
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// outgoingBuffer is how many messages may wait for a slow connection before
+// the server drops it
+const outgoingBuffer = 64
+
+// ServerConfig holds the server settings
+type ServerConfig struct {
+	// AbandonTimeout is how long a player may stay disconnected from a
+	// game in progress before losing it. Zero never forfeits; the clock
+	// still runs.
+	AbandonTimeout time.Duration
+	// Logf receives connection and game events; nil discards them
+	Logf func(format string, args ...interface{})
+}
+
+// DefaultServerConfig returns the settings used by cmd/server
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{AbandonTimeout: 2 * time.Minute}
+}
+
+// Server hosts a lobby of seeks and challenges and the games started from
+// them. The clocks are kept by the server: a player whose time runs out
+// loses, whether or not they are connected.
+type Server struct {
+	cfg ServerConfig
+
+	mu        sync.Mutex
+	sessions  map[string]*session
+	seeks     map[int]*Seek
+	games     map[int]*serverGame
+	nextID    int
+	listeners []net.Listener
+	peers     map[*peer]bool
+	closed    bool
+}
+
+// session is a logged in player. It outlives its connection while it plays
+// or watches a game, so that the player can reconnect and carry on.
+type session struct {
+	name  string
+	token string
+	peer  *peer
+}
+
+// peer is one client connection with its own writer goroutine
+type peer struct {
+	transport transport
+	out       chan Message
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// serverGame is a game in progress
+type serverGame struct {
+	id          int
+	players     [2]*session
+	state       *game.GameState
+	variant     string
+	timeControl TimeControl
+	moves       []string
+	clocks      [2]time.Duration
+	turnStart   time.Time
+	positions   map[string]int
+	flag        *time.Timer
+	abandon     [2]*time.Timer
+	spectators  map[*session]bool
+}
+
+// NewServer creates a server with no players
+func NewServer(cfg ServerConfig) *Server {
+	return &Server{
+		cfg:      cfg,
+		sessions: make(map[string]*session),
+		seeks:    make(map[int]*Seek),
+		games:    make(map[int]*serverGame),
+		peers:    make(map[*peer]bool),
+	}
+}
+
+// Serve accepts TCP clients on l until the server is closed
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return net.ErrClosed
+	}
+	s.listeners = append(s.listeners, l)
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		go s.handle(newLineTransport(conn))
+	}
+}
+
+// ServeHTTP accepts a WebSocket client
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t, err := upgradeWebSocket(w, r)
+	if err != nil {
+		s.logf("WebSocket upgrade from %s failed: %v", r.RemoteAddr, err)
+		return
+	}
+	s.handle(t)
+}
+
+// Close stops the listeners, disconnects every client and stops the clocks
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for _, l := range s.listeners {
+		l.Close()
+	}
+	for p := range s.peers {
+		p.close()
+	}
+	for _, g := range s.games {
+		g.stopTimers()
+	}
+	return nil
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.cfg.Logf != nil {
+		s.cfg.Logf(format, args...)
+	}
+}
+
+// handle serves one connection: a hello, then requests until it drops
+func (s *Server) handle(t transport) {
+	hello, err := t.ReadMessage()
+	if err != nil {
+		t.Close()
+		return
+	}
+	if hello.Type != MsgHello {
+		t.WriteMessage(Message{Type: MsgError, Error: "expected hello"})
+		t.Close()
+		return
+	}
+
+	p := &peer{
+		transport: t,
+		out:       make(chan Message, outgoingBuffer),
+		done:      make(chan struct{}),
+	}
+	sess, err := s.login(p, hello)
+	if err != nil {
+		t.WriteMessage(Message{Type: MsgError, Error: err.Error()})
+		t.Close()
+		return
+	}
+	go p.writeLoop()
+
+	for {
+		msg, err := t.ReadMessage()
+		if err != nil {
+			break
+		}
+		s.handleMessage(sess, msg)
+	}
+
+	p.close()
+	s.disconnect(sess, p)
+}
+
+// login attaches a connection to a new session, or to the session of the
+// same name when the token matches
+func (s *Server) login(p *peer, hello Message) (*session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, fmt.Errorf("server is shutting down")
+	}
+	if hello.Name == "" {
+		return nil, fmt.Errorf("a name is required")
+	}
+
+	sess, exists := s.sessions[hello.Name]
+	if exists && hello.Token != sess.token {
+		return nil, fmt.Errorf("name %q is taken", hello.Name)
+	}
+	s.peers[p] = true
+
+	if exists {
+		if sess.peer != nil {
+			sess.peer.close()
+		}
+		sess.peer = p
+		s.logf("%s reconnected", sess.name)
+	} else {
+		sess = &session{name: hello.Name, token: newToken(), peer: p}
+		s.sessions[sess.name] = sess
+		s.logf("%s connected", sess.name)
+	}
+	p.send(Message{Type: MsgWelcome, Name: sess.name, Token: sess.token})
+
+	for _, g := range s.sortedGames() {
+		if g.spectators[sess] {
+			p.send(g.message(sess))
+			continue
+		}
+		color, ok := g.colorOf(sess)
+		if !ok {
+			continue
+		}
+		if g.abandon[color] != nil {
+			g.abandon[color].Stop()
+			g.abandon[color] = nil
+		}
+		p.send(g.message(sess))
+		if opponent := g.players[1-color]; opponent != sess {
+			opponent.send(Message{Type: MsgOpponent, Game: g.id, Name: sess.name, Online: true})
+		}
+	}
+	s.broadcastLobby()
+	return sess, nil
+}
+
+// disconnect marks the session offline once its connection p has dropped.
+// Its seeks are withdrawn and its games wait AbandonTimeout for it.
+func (s *Server) disconnect(sess *session, p *peer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.peers, p)
+	if sess.peer != p || s.closed {
+		return
+	}
+	sess.peer = nil
+	s.logf("%s disconnected", sess.name)
+
+	for id, seek := range s.seeks {
+		if seek.Player == sess.name || seek.Opponent == sess.name {
+			delete(s.seeks, id)
+		}
+	}
+
+	for _, g := range s.sortedGames() {
+		color, ok := g.colorOf(sess)
+		if !ok {
+			continue
+		}
+		g.players[1-color].send(Message{Type: MsgOpponent, Game: g.id, Name: sess.name})
+		if s.cfg.AbandonTimeout > 0 {
+			g.abandon[color] = time.AfterFunc(s.cfg.AbandonTimeout, func() {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				if s.games[g.id] == g && sess.peer == nil {
+					s.endGame(g, game.WinResult(1-color), "abandonment")
+				}
+			})
+		}
+	}
+	if !s.involved(sess) {
+		delete(s.sessions, sess.name)
+	}
+	s.broadcastLobby()
+}
+
+// handleMessage carries out one request of a logged in player
+func (s *Server) handleMessage(sess *session, msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	switch msg.Type {
+	case MsgSeek:
+		err = s.seek(sess, msg.Seek)
+	case MsgCancel:
+		err = s.cancel(sess, msg.SeekID)
+	case MsgAccept:
+		err = s.accept(sess, msg.SeekID)
+	case MsgDecline:
+		err = s.decline(sess, msg.SeekID)
+	case MsgMove:
+		err = s.move(sess, msg.Game, msg.Move)
+	case MsgResign:
+		err = s.resign(sess, msg.Game)
+	case MsgWatch:
+		err = s.watch(sess, msg.Game)
+	case MsgUnwatch:
+		err = s.unwatch(sess, msg.Game)
+	default:
+		err = fmt.Errorf("unknown message type %q", msg.Type)
+	}
+	if err == nil {
+		return
+	}
+	sess.send(Message{Type: MsgError, Game: msg.Game, SeekID: msg.SeekID, Error: err.Error()})
+	// Resynchronize a client whose move was refused
+	if g, ok := s.games[msg.Game]; ok && msg.Type == MsgMove {
+		if _, playing := g.colorOf(sess); playing {
+			sess.send(g.message(sess))
+		}
+	}
+}
+
+func (s *Server) seek(sess *session, request *Seek) error {
+	if request == nil {
+		return fmt.Errorf("seek is missing")
+	}
+	seek := *request
+	if seek.Variant == "" {
+		seek.Variant = "Standard"
+	}
+	if _, err := newGame(seek.Variant, ""); err != nil {
+		return err
+	}
+	if variant, ok := game.VariantByName(seek.Variant); ok {
+		seek.Variant = variant.Name()
+	}
+	if seek.TimeControl.Base <= 0 || seek.TimeControl.Increment < 0 {
+		return fmt.Errorf("invalid time control %v", seek.TimeControl)
+	}
+	if seek.Color != "" && seek.Color != White && seek.Color != Black {
+		return fmt.Errorf("invalid color %q", seek.Color)
+	}
+	if seek.Opponent != "" {
+		opponent, ok := s.sessions[seek.Opponent]
+		if !ok || opponent.peer == nil {
+			return fmt.Errorf("%s is not online", seek.Opponent)
+		}
+		if opponent == sess {
+			return fmt.Errorf("you cannot challenge yourself")
+		}
+	}
+
+	s.nextID++
+	seek.ID = s.nextID
+	seek.Player = sess.name
+	s.seeks[seek.ID] = &seek
+
+	if seek.Opponent != "" {
+		challenge := seek
+		s.sessions[seek.Opponent].send(Message{Type: MsgChallenge, Seek: &challenge})
+	}
+	s.broadcastLobby()
+	return nil
+}
+
+func (s *Server) cancel(sess *session, id int) error {
+	seek, ok := s.seeks[id]
+	if !ok || seek.Player != sess.name {
+		return fmt.Errorf("no seek %d of yours", id)
+	}
+	delete(s.seeks, id)
+	s.broadcastLobby()
+	return nil
+}
+
+func (s *Server) decline(sess *session, id int) error {
+	seek, ok := s.seeks[id]
+	if !ok || seek.Opponent != sess.name {
+		return fmt.Errorf("no challenge %d to you", id)
+	}
+	delete(s.seeks, id)
+	if challenger, ok := s.sessions[seek.Player]; ok {
+		challenger.send(Message{Type: MsgDecline, SeekID: id, Name: sess.name})
+	}
+	s.broadcastLobby()
+	return nil
+}
+
+// accept starts a game from a seek. Both players' other seeks are withdrawn.
+func (s *Server) accept(sess *session, id int) error {
+	seek, ok := s.seeks[id]
+	if !ok || (seek.Opponent != "" && seek.Opponent != sess.name) {
+		return fmt.Errorf("no seek %d open to you", id)
+	}
+	if seek.Player == sess.name {
+		return fmt.Errorf("you cannot accept your own seek")
+	}
+	seeker, ok := s.sessions[seek.Player]
+	if !ok {
+		return fmt.Errorf("%s has left", seek.Player)
+	}
+
+	state, err := s.newGameState(seek.Variant)
+	if err != nil {
+		return err
+	}
+
+	for seekID, other := range s.seeks {
+		if other.Player == seeker.name || other.Player == sess.name {
+			delete(s.seeks, seekID)
+		}
+	}
+
+	s.nextID++
+	g := &serverGame{
+		id:          s.nextID,
+		state:       state,
+		variant:     seek.Variant,
+		timeControl: seek.TimeControl,
+		clocks:      [2]time.Duration{seek.TimeControl.Base, seek.TimeControl.Base},
+		turnStart:   time.Now(),
+		positions:   map[string]int{state.PositionKey(): 1},
+		spectators:  make(map[*session]bool),
+	}
+	seekerColor := game.WhitePlayer
+	switch seek.Color {
+	case Black:
+		seekerColor = game.BlackPlayer
+	case "":
+		seekerColor = randomInt(2)
+	}
+	g.players[seekerColor] = seeker
+	g.players[1-seekerColor] = sess
+	s.games[g.id] = g
+	s.logf("game %d started: %s vs %s, %s %v", g.id, g.players[0].name, g.players[1].name, g.variant, g.timeControl)
+
+	for _, player := range g.players {
+		player.send(g.message(player))
+	}
+	s.startClock(g)
+	s.broadcastLobby()
+	return nil
+}
+
+// newGameState creates the starting position of a seek's variant. Chess960
+// games start from a random position.
+func (s *Server) newGameState(variant string) (*game.GameState, error) {
+	if variant == "Chess960" {
+		return game.NewChess960Game(randomInt(960))
+	}
+	return newGame(variant, "")
+}
+
+// move plays a move for the side to move, charges its clock and ends the
+// game if the move decided it
+func (s *Server) move(sess *session, id int, uci string) error {
+	g, ok := s.games[id]
+	if !ok {
+		return fmt.Errorf("no game %d", id)
+	}
+	color, ok := g.colorOf(sess)
+	if !ok {
+		return fmt.Errorf("you are not playing game %d", id)
+	}
+	if color != g.state.CurrentTurn {
+		return fmt.Errorf("it is not your turn")
+	}
+
+	from, to, promotion, err := g.state.ParseUCI(uci)
+	if err != nil || !g.state.IsLegal(from, to, promotion) {
+		return fmt.Errorf("illegal move %q", uci)
+	}
+
+	now := time.Now()
+	g.clocks[color] -= now.Sub(g.turnStart)
+	if g.clocks[color] <= 0 {
+		g.clocks[color] = 0
+		s.endGame(g, game.WinResult(1-color), "time forfeit")
+		return nil
+	}
+	g.clocks[color] += g.timeControl.Increment
+	g.turnStart = now
+
+	uci = g.state.UCI(from, to, promotion)
+	g.state.MakeMoveWithPromotion(from, to, promotion)
+	g.moves = append(g.moves, uci)
+	g.positions[g.state.PositionKey()]++
+
+	g.broadcast(Message{
+		Type:       MsgMove,
+		Game:       g.id,
+		Move:       uci,
+		Ply:        len(g.moves),
+		WhiteClock: g.clocks[game.WhitePlayer],
+		BlackClock: g.clocks[game.BlackPlayer],
+	})
+
+	if result, termination, over := g.state.Outcome(g.positions); over {
+		s.endGame(g, result, termination)
+		return nil
+	}
+	s.startClock(g)
+	return nil
+}
+
+// watch subscribes a spectator to a game and sends it the game so far
+func (s *Server) watch(sess *session, id int) error {
+	g, ok := s.games[id]
+	if !ok {
+		return fmt.Errorf("no game %d", id)
+	}
+	if _, playing := g.colorOf(sess); playing {
+		return fmt.Errorf("you are playing game %d", id)
+	}
+	g.spectators[sess] = true
+	sess.send(g.message(sess))
+	s.broadcastLobby()
+	return nil
+}
+
+func (s *Server) unwatch(sess *session, id int) error {
+	g, ok := s.games[id]
+	if !ok || !g.spectators[sess] {
+		return fmt.Errorf("you are not watching game %d", id)
+	}
+	delete(g.spectators, sess)
+	s.broadcastLobby()
+	return nil
+}
+
+func (s *Server) resign(sess *session, id int) error {
+	g, ok := s.games[id]
+	if !ok {
+		return fmt.Errorf("no game %d", id)
+	}
+	color, ok := g.colorOf(sess)
+	if !ok {
+		return fmt.Errorf("you are not playing game %d", id)
+	}
+	s.endGame(g, game.WinResult(1-color), "resignation")
+	return nil
+}
+
+// startClock arms the flag of the side to move
+func (s *Server) startClock(g *serverGame) {
+	if g.flag != nil {
+		g.flag.Stop()
+	}
+	mover, ply := g.state.CurrentTurn, len(g.moves)
+	g.flag = time.AfterFunc(g.clocks[mover], func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.games[g.id] == g && len(g.moves) == ply {
+			g.clocks[mover] = 0
+			s.endGame(g, game.WinResult(1-mover), "time forfeit")
+		}
+	})
+}
+
+// endGame reports the result to the players and spectators and forgets the
+// game
+func (s *Server) endGame(g *serverGame, result, termination string) {
+	g.stopTimers()
+	delete(s.games, g.id)
+	s.logf("game %d ended %s by %s", g.id, result, termination)
+
+	g.broadcast(Message{
+		Type:        MsgEnd,
+		Game:        g.id,
+		Result:      result,
+		Termination: termination,
+		WhiteClock:  g.clocks[game.WhitePlayer],
+		BlackClock:  g.clocks[game.BlackPlayer],
+	})
+	for _, sess := range g.audience() {
+		if sess.peer == nil && !s.involved(sess) {
+			delete(s.sessions, sess.name)
+		}
+	}
+	s.broadcastLobby()
+}
+
+// involved reports whether the session plays or watches a game in progress
+func (s *Server) involved(sess *session) bool {
+	for _, g := range s.games {
+		if _, ok := g.colorOf(sess); ok || g.spectators[sess] {
+			return true
+		}
+	}
+	return false
+}
+
+// broadcastLobby sends every online player the seeks they may see
+func (s *Server) broadcastLobby() {
+	var players []string
+	for name, sess := range s.sessions {
+		if sess.peer != nil {
+			players = append(players, name)
+		}
+	}
+	sort.Strings(players)
+
+	var ids []int
+	for id := range s.seeks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var games []GameInfo
+	for _, g := range s.sortedGames() {
+		games = append(games, GameInfo{
+			ID:          g.id,
+			White:       g.players[game.WhitePlayer].name,
+			Black:       g.players[game.BlackPlayer].name,
+			Variant:     g.variant,
+			TimeControl: g.timeControl,
+			Ply:         len(g.moves),
+			Spectators:  len(g.spectators),
+		})
+	}
+
+	for _, sess := range s.sessions {
+		if sess.peer == nil {
+			continue
+		}
+		var seeks []Seek
+		for _, id := range ids {
+			seek := s.seeks[id]
+			if seek.Opponent == "" || seek.Opponent == sess.name || seek.Player == sess.name {
+				seeks = append(seeks, *seek)
+			}
+		}
+		sess.send(Message{Type: MsgLobby, Seeks: seeks, Players: players, Games: games})
+	}
+}
+
+// sortedGames returns the games in progress in the order they started
+func (s *Server) sortedGames() []*serverGame {
+	var games []*serverGame
+	for _, g := range s.games {
+		games = append(games, g)
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].id < games[j].id })
+	return games
+}
+
+// colorOf returns the color a session plays in the game
+func (g *serverGame) colorOf(sess *session) (int, bool) {
+	for color, player := range g.players {
+		if player == sess {
+			return color, true
+		}
+	}
+	return 0, false
+}
+
+// audience returns the players and spectators of the game
+func (g *serverGame) audience() []*session {
+	audience := []*session{g.players[0], g.players[1]}
+	for sess := range g.spectators {
+		audience = append(audience, sess)
+	}
+	return audience
+}
+
+// broadcast sends a message to the players and spectators
+func (g *serverGame) broadcast(msg Message) {
+	for _, sess := range g.audience() {
+		sess.send(msg)
+	}
+}
+
+// message describes the whole game to one of its players or spectators,
+// with the clock of the side to move running
+func (g *serverGame) message(sess *session) Message {
+	color, playing := g.colorOf(sess)
+	clocks := g.clocks
+	clocks[g.state.CurrentTurn] -= time.Since(g.turnStart)
+	if clocks[g.state.CurrentTurn] < 0 {
+		clocks[g.state.CurrentTurn] = 0
+	}
+
+	timeControl := g.timeControl
+	fen := ""
+	if g.state.StartFEN != "" && g.state.StartFEN != game.StandardFEN {
+		fen = g.state.StartFEN
+	}
+	msg := Message{
+		Type:        MsgGame,
+		Game:        g.id,
+		White:       g.players[game.WhitePlayer].name,
+		Black:       g.players[game.BlackPlayer].name,
+		Variant:     g.variant,
+		FEN:         fen,
+		TimeControl: &timeControl,
+		Moves:       append([]string{}, g.moves...),
+		Ply:         len(g.moves),
+		WhiteClock:  clocks[game.WhitePlayer],
+		BlackClock:  clocks[game.BlackPlayer],
+		Spectators:  len(g.spectators),
+	}
+	if playing {
+		msg.Color = ColorName(color)
+		msg.Online = g.players[1-color].peer != nil
+	}
+	return msg
+}
+
+func (g *serverGame) stopTimers() {
+	if g.flag != nil {
+		g.flag.Stop()
+	}
+	for _, timer := range g.abandon {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// send queues a message for the session if it is connected
+func (sess *session) send(msg Message) {
+	if sess.peer != nil {
+		sess.peer.send(msg)
+	}
+}
+
+// send queues a message, dropping a connection that has fallen too far
+// behind
+func (p *peer) send(msg Message) {
+	select {
+	case p.out <- msg:
+	case <-p.done:
+	default:
+		p.close()
+	}
+}
+
+func (p *peer) writeLoop() {
+	for {
+		select {
+		case msg := <-p.out:
+			if err := p.transport.WriteMessage(msg); err != nil {
+				p.close()
+				return
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *peer) close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		p.transport.Close()
+	})
+}
+
+func newToken() string {
+	token := make([]byte, 16)
+	rand.Read(token)
+	return hex.EncodeToString(token)
+}
+
+func randomInt(n int) int {
+	value, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0
+	}
+	return int(value.Int64())
+}
@@ -0,0 +1,303 @@
+package network
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// transport carries messages over one connection
+type transport interface {
+	ReadMessage() (Message, error)
+	WriteMessage(msg Message) error
+	Close() error
+}
+
+// lineTransport sends one JSON message per line over a TCP connection
+type lineTransport struct {
+	conn    net.Conn
+	decoder *json.Decoder
+	writeMu sync.Mutex
+	encoder *json.Encoder
+}
+
+func newLineTransport(conn net.Conn) *lineTransport {
+	return &lineTransport{
+		conn:    conn,
+		decoder: json.NewDecoder(conn),
+		encoder: json.NewEncoder(conn),
+	}
+}
+
+func (t *lineTransport) ReadMessage() (Message, error) {
+	var msg Message
+	err := t.decoder.Decode(&msg)
+	return msg, err
+}
+
+func (t *lineTransport) WriteMessage(msg Message) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.encoder.Encode(msg)
+}
+
+func (t *lineTransport) Close() error {
+	return t.conn.Close()
+}
+
+// WebSocket opcodes and limits (RFC 6455)
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+
+	wsGUID           = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxMessageSize = 1 << 20
+)
+
+// wsTransport sends one JSON message per text frame over a WebSocket. The
+// client side masks its frames as the protocol requires.
+type wsTransport struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	client  bool
+	writeMu sync.Mutex
+}
+
+// upgradeWebSocket answers a WebSocket handshake and takes over its
+// connection
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsTransport, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		http.Error(w, "expected a WebSocket handshake", http.StatusBadRequest)
+		return nil, errors.New("not a WebSocket handshake")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection cannot be upgraded", http.StatusInternalServerError)
+		return nil, errors.New("response writer cannot be hijacked")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", wsAccept(key))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsTransport{conn: conn, reader: rw.Reader}, nil
+}
+
+// dialWebSocket connects to a ws:// URL
+func dialWebSocket(address string) (*wsTransport, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "80")
+	}
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n",
+		u.RequestURI(), u.Host, key)
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, &http.Request{Method: http.MethodGet})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != wsAccept(key) {
+		conn.Close()
+		return nil, fmt.Errorf("WebSocket handshake failed: %s", resp.Status)
+	}
+	return &wsTransport{conn: conn, reader: reader, client: true}, nil
+}
+
+// wsAccept returns the Sec-WebSocket-Accept value for a handshake key
+func wsAccept(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains reports whether a comma separated header lists token
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *wsTransport) ReadMessage() (Message, error) {
+	var data []byte
+	for {
+		fin, opcode, payload, err := t.readFrame()
+		if err != nil {
+			return Message{}, err
+		}
+
+		switch opcode {
+		case wsPing:
+			if err := t.writeFrame(wsPong, payload); err != nil {
+				return Message{}, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			t.writeFrame(wsClose, nil)
+			return Message{}, io.EOF
+		}
+
+		data = append(data, payload...)
+		if len(data) > wsMaxMessageSize {
+			return Message{}, errors.New("WebSocket message too large")
+		}
+		if fin {
+			var msg Message
+			err := json.Unmarshal(data, &msg)
+			return msg, err
+		}
+	}
+}
+
+func (t *wsTransport) WriteMessage(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return t.writeFrame(wsText, data)
+}
+
+func (t *wsTransport) Close() error {
+	return t.conn.Close()
+}
+
+// readFrame reads one frame and unmasks its payload
+func (t *wsTransport) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(t.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(t.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(t.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > wsMaxMessageSize {
+		return false, 0, nil, errors.New("WebSocket frame too large")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(t.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(t.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame writes a whole message as a single frame
+func (t *wsTransport) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+
+	maskBit := byte(0)
+	if t.client {
+		maskBit = 0x80
+	}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+
+	if t.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := start; i < len(frame); i++ {
+			frame[i] ^= mask[(i-start)%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err := t.conn.Write(frame)
+	return err
+}
+
+// dial connects to a server at a TCP "host:port" address or a ws:// URL
+func dial(address string) (transport, error) {
+	if strings.Contains(address, "://") {
+		return dialWebSocket(address)
+	}
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return newLineTransport(conn), nil
+}
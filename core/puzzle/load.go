@@ -0,0 +1,213 @@
+package puzzle
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// Load reads puzzles from a file, choosing the format by extension:
+//
+//   - .csv in the Lichess puzzle database layout (PuzzleId, FEN, Moves,
+//     Rating, ..., Themes), where FEN is the position before the opponent's
+//     move and Moves starts with that move
+//   - .pgn with a FEN tag per game and the solution as the moves, rated by
+//     the optional PuzzleId, Rating and Themes tags
+//   - anything else as EPD, with the solution in a pv operation or else
+//     the best moves of a bm operation, any of which solves the puzzle,
+//     and the optional id, c0 (rating) and c1 (themes) operations
+func Load(path string) ([]*Puzzle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var puzzles []*Puzzle
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		puzzles, err = ReadCSV(file)
+	case ".pgn":
+		puzzles, err = ReadPGN(file)
+	default:
+		puzzles, err = ReadEPD(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return puzzles, nil
+}
+
+// ReadCSV reads puzzles in the Lichess database layout. A header row, if
+// present, is used to find the columns.
+func ReadCSV(r io.Reader) ([]*Puzzle, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	columns := map[string]int{"PuzzleId": 0, "FEN": 1, "Moves": 2, "Rating": 3, "Themes": 7}
+	var puzzles []*Puzzle
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return puzzles, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if line == 1 && len(record) > 1 && record[1] == "FEN" {
+			for i, name := range record {
+				columns[name] = i
+			}
+			continue
+		}
+
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		position, err := game.NewGameFromFEN(field("FEN"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		position.TimerActive = false
+		moves := strings.Fields(field("Moves"))
+		if len(moves) < 2 {
+			return nil, fmt.Errorf("line %d: expected the opponent's move and a solution", line)
+		}
+		// The first move sets up the puzzle
+		if err := playUCI(position, moves[0]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		rating, _ := strconv.Atoi(field("Rating"))
+		puzzles = append(puzzles, &Puzzle{
+			ID:       field("PuzzleId"),
+			FEN:      position.FEN(),
+			Solution: moves[1:],
+			Rating:   rating,
+			Themes:   strings.Fields(field("Themes")),
+		})
+	}
+}
+
+// ReadPGN reads puzzles stored as PGN games starting from a FEN
+func ReadPGN(r io.Reader) ([]*Puzzle, error) {
+	games, err := game.ParsePGN(r)
+	if err != nil {
+		return nil, err
+	}
+
+	puzzles := make([]*Puzzle, 0, len(games))
+	for i, pgn := range games {
+		fen, ok := pgn.Tags["FEN"]
+		if !ok {
+			return nil, fmt.Errorf("game %d: no FEN tag", i+1)
+		}
+
+		sans := make([]string, len(pgn.Moves))
+		for j, move := range pgn.Moves {
+			sans[j] = move.SAN
+		}
+		solution, err := sanToUCI(fen, sans)
+		if err != nil {
+			return nil, fmt.Errorf("game %d: %w", i+1, err)
+		}
+
+		id := pgn.Tags["PuzzleId"]
+		if id == "" {
+			id = strconv.Itoa(i + 1)
+		}
+		rating, _ := strconv.Atoi(pgn.Tags["Rating"])
+		puzzles = append(puzzles, &Puzzle{
+			ID:       id,
+			FEN:      fen,
+			Solution: solution,
+			Rating:   rating,
+			Themes:   strings.Fields(strings.ReplaceAll(pgn.Tags["Themes"], ",", " ")),
+		})
+	}
+	return puzzles, nil
+}
+
+// ReadEPD reads puzzles from EPD lines
+func ReadEPD(r io.Reader) ([]*Puzzle, error) {
+	records, err := game.ReadEPD(r)
+	if err != nil {
+		return nil, err
+	}
+
+	puzzles := make([]*Puzzle, 0, len(records))
+	for i, epd := range records {
+		// bm lists alternative best moves rather than a line, so the first
+		// is the solution and the others are accepted too
+		moves, best := epd.Moves("pv"), epd.Moves("bm")
+		if len(moves) == 0 && len(best) > 0 {
+			moves = best[:1]
+		}
+		solution, err := sanToUCI(epd.FEN, moves)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		var alternatives []string
+		if len(solution) == 1 {
+			for _, san := range best {
+				move, err := sanToUCI(epd.FEN, []string{san})
+				if err != nil {
+					return nil, fmt.Errorf("record %d: %w", i+1, err)
+				}
+				if move[0] != solution[0] {
+					alternatives = append(alternatives, move[0])
+				}
+			}
+		}
+
+		id := epd.ID()
+		if id == "" {
+			id = strconv.Itoa(i + 1)
+		}
+		rating, _ := strconv.Atoi(epd.Operations["c0"])
+		puzzles = append(puzzles, &Puzzle{
+			ID:           id,
+			FEN:          epd.FEN,
+			Solution:     solution,
+			Alternatives: alternatives,
+			Rating:       rating,
+			Themes:       strings.Fields(strings.ReplaceAll(epd.Operations["c1"], ",", " ")),
+		})
+	}
+	return puzzles, nil
+}
+
+// sanToUCI converts a move sequence played from fen to UCI notation
+func sanToUCI(fen string, sans []string) ([]string, error) {
+	if len(sans) == 0 {
+		return nil, fmt.Errorf("no solution moves")
+	}
+
+	position, err := game.NewGameFromFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	position.TimerActive = false
+
+	moves := make([]string, len(sans))
+	for i, san := range sans {
+		from, to, promotion, err := position.ParseSAN(san)
+		if err != nil {
+			return nil, fmt.Errorf("move %s: %w", san, err)
+		}
+		moves[i] = position.UCI(from, to, promotion)
+		position.MakeMoveWithPromotion(from, to, promotion)
+	}
+	return moves, nil
+}
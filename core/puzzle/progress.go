@@ -0,0 +1,144 @@
+package puzzle
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Rating settings. New solvers start at DefaultRating and their rating moves
+// faster during the first provisionalAttempts puzzles.
+const (
+	DefaultRating       = 1500
+	provisionalAttempts = 20
+	provisionalK        = 40
+	establishedK        = 16
+)
+
+// nextCandidates is the number of puzzles closest to the solver's rating
+// that the next puzzle is picked from
+const nextCandidates = 10
+
+// Result records the first attempt at a puzzle
+type Result struct {
+	Solved       bool      `json:"solved"`
+	RatingChange int       `json:"rating_change"`
+	Date         time.Time `json:"date"`
+}
+
+// Progress is the solver's puzzle rating and history
+type Progress struct {
+	Rating   int               `json:"rating"`
+	Attempts int               `json:"attempts"`
+	Solved   int               `json:"solved"`
+	History  map[string]Result `json:"history"`
+	// LastFile is the puzzle file used last
+	LastFile string `json:"last_file,omitempty"`
+}
+
+// NewProgress returns the progress of a new solver
+func NewProgress() *Progress {
+	return &Progress{Rating: DefaultRating, History: map[string]Result{}}
+}
+
+// DefaultProgressPath returns where progress is stored for the current user
+func DefaultProgressPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-chess", "puzzles.json"), nil
+}
+
+// LoadProgress reads progress from path. A missing file gives the progress
+// of a new solver.
+func LoadProgress(path string) (*Progress, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewProgress(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	progress := NewProgress()
+	if err := json.Unmarshal(data, progress); err != nil {
+		return nil, err
+	}
+	if progress.History == nil {
+		progress.History = map[string]Result{}
+	}
+	return progress, nil
+}
+
+// Save writes the progress to path, creating its directory if needed
+func (p *Progress) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Record rates the first attempt at a puzzle as a game between the solver
+// and the puzzle, and returns the rating change. Repeat attempts are not
+// rated.
+func (p *Progress) Record(puzzle *Puzzle, solved bool) int {
+	if _, played := p.History[puzzle.ID]; played {
+		return 0
+	}
+
+	k := float64(establishedK)
+	if p.Attempts < provisionalAttempts {
+		k = provisionalK
+	}
+	expected := 1 / (1 + math.Pow(10, float64(puzzle.Rating-p.Rating)/400))
+	score := 0.0
+	if solved {
+		score = 1
+		p.Solved++
+	}
+	change := int(math.Round(k * (score - expected)))
+
+	p.Rating += change
+	p.Attempts++
+	p.History[puzzle.ID] = Result{Solved: solved, RatingChange: change, Date: time.Now()}
+	return change
+}
+
+// Next picks an unplayed puzzle close to the solver's rating. It returns
+// nil when every puzzle has been played.
+func (p *Progress) Next(puzzles []*Puzzle, random *rand.Rand) *Puzzle {
+	var unplayed []*Puzzle
+	for _, puzzle := range puzzles {
+		if _, played := p.History[puzzle.ID]; !played {
+			unplayed = append(unplayed, puzzle)
+		}
+	}
+	if len(unplayed) == 0 {
+		return nil
+	}
+
+	distance := func(puzzle *Puzzle) int {
+		if puzzle.Rating > p.Rating {
+			return puzzle.Rating - p.Rating
+		}
+		return p.Rating - puzzle.Rating
+	}
+	sort.SliceStable(unplayed, func(i, j int) bool {
+		return distance(unplayed[i]) < distance(unplayed[j])
+	})
+
+	if len(unplayed) > nextCandidates {
+		unplayed = unplayed[:nextCandidates]
+	}
+	return unplayed[random.Intn(len(unplayed))]
+}
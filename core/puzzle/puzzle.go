@@ -0,0 +1,171 @@
+package puzzle
+
+import (
+	"fmt"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// Puzzle is a tactics exercise. The solver is the side to move in FEN and
+// Solution alternates the solver's moves and the opponent's replies, in UCI
+// notation, ending with the solver's last move.
+type Puzzle struct {
+	ID       string
+	FEN      string
+	Solution []string
+	// Alternatives are other first moves that solve a one-move puzzle, such
+	// as the further best moves of an EPD bm operation
+	Alternatives []string
+	Rating       int
+	Themes       []string
+}
+
+// Position returns the starting position of the puzzle
+func (p *Puzzle) Position() (*game.GameState, error) {
+	position, err := game.NewGameFromFEN(p.FEN)
+	if err != nil {
+		return nil, err
+	}
+	position.TimerActive = false
+	return position, nil
+}
+
+// Outcome is the verdict on a move played in a puzzle
+type Outcome int
+
+const (
+	// Wrong means the move is not a solution; it is taken back
+	Wrong Outcome = iota
+	// Correct means the move is right and the solution continues
+	Correct
+	// Solved means the puzzle is complete
+	Solved
+)
+
+// Attempt tracks a solver working through a puzzle
+type Attempt struct {
+	Puzzle *Puzzle
+	Game   *game.GameState
+	// Failed is set on the first wrong move and stays set
+	Failed bool
+
+	solver int
+	step   int
+}
+
+// NewAttempt sets up a puzzle for solving
+func NewAttempt(p *Puzzle) (*Attempt, error) {
+	if len(p.Solution) == 0 {
+		return nil, fmt.Errorf("puzzle %s has no solution", p.ID)
+	}
+	position, err := p.Position()
+	if err != nil {
+		return nil, fmt.Errorf("puzzle %s: %w", p.ID, err)
+	}
+	return &Attempt{Puzzle: p, Game: position, solver: position.CurrentTurn}, nil
+}
+
+// Solver returns the color the solver plays
+func (a *Attempt) Solver() int {
+	return a.solver
+}
+
+// Done reports whether the whole solution has been played
+func (a *Attempt) Done() bool {
+	return a.step >= len(a.Puzzle.Solution)
+}
+
+// Hint returns the next solution move for the solver
+func (a *Attempt) Hint() (string, bool) {
+	if a.Done() || a.step%2 != 0 {
+		return "", false
+	}
+	return a.Puzzle.Solution[a.step], true
+}
+
+// Play judges the solver's move. A move other than the expected one is
+// still accepted when it mates at once, or when it is one of the
+// alternatives of a one-move puzzle; other solutions, such as a different
+// mating line, are not searched for and count as wrong. A wrong move is not
+// played and marks the attempt failed. An illegal move is an error and
+// leaves the attempt as it was.
+func (a *Attempt) Play(move string) (Outcome, error) {
+	expected, ok := a.Hint()
+	if !ok {
+		return Wrong, fmt.Errorf("it is not the solver's turn")
+	}
+
+	from, to, promotion, err := a.Game.ParseUCI(move)
+	if err != nil {
+		return Wrong, err
+	}
+	if !a.Game.IsLegal(from, to, promotion) {
+		return Wrong, fmt.Errorf("illegal move %s", move)
+	}
+
+	if move != expected {
+		after := a.Game.Clone()
+		after.MakeMoveWithPromotion(from, to, promotion)
+		if !a.isAlternative(move) && !isMateBy(after, a.solver) {
+			a.Failed = true
+			return Wrong, nil
+		}
+		// An alternative solution ends the puzzle
+		a.Game.MakeMoveWithPromotion(from, to, promotion)
+		a.step = len(a.Puzzle.Solution)
+		return Solved, nil
+	}
+
+	a.Game.MakeMoveWithPromotion(from, to, promotion)
+	a.step++
+	if a.Done() {
+		return Solved, nil
+	}
+	return Correct, nil
+}
+
+// Reply plays the opponent's next move from the solution and returns it
+func (a *Attempt) Reply() (string, error) {
+	if a.Done() || a.step%2 != 1 {
+		return "", fmt.Errorf("no opponent reply is due")
+	}
+	move := a.Puzzle.Solution[a.step]
+	if err := playUCI(a.Game, move); err != nil {
+		return "", fmt.Errorf("puzzle %s: %w", a.Puzzle.ID, err)
+	}
+	a.step++
+	return move, nil
+}
+
+// isAlternative reports whether move is another solution of a one-move
+// puzzle
+func (a *Attempt) isAlternative(move string) bool {
+	if a.step != 0 || len(a.Puzzle.Solution) != 1 {
+		return false
+	}
+	for _, alternative := range a.Puzzle.Alternatives {
+		if move == alternative {
+			return true
+		}
+	}
+	return false
+}
+
+// isMateBy reports whether the position is checkmate delivered by player
+func isMateBy(position *game.GameState, player int) bool {
+	if player == game.WhitePlayer {
+		return position.GameStatus == game.WhiteWon
+	}
+	return position.GameStatus == game.BlackWon
+}
+
+func playUCI(position *game.GameState, move string) error {
+	from, to, promotion, err := position.ParseUCI(move)
+	if err != nil {
+		return err
+	}
+	if position.MakeMoveWithPromotion(from, to, promotion) == game.InvalidMove {
+		return fmt.Errorf("illegal move %s", move)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Package savegame writes a game in progress to disk, with the AI and board
+// settings it is played with, so it can be resumed later.
+//
+// A save is a JSON document carrying a format version. The game is kept as
+// its start position and moves in UCI notation, so the whole history can be
+// replayed, together with the final position as a check.
+package savegame
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// Version is the format version written by this package
+const Version = 1
+
+// Save is a saved game
+type Save struct {
+	Version int       `json:"version"`
+	Saved   time.Time `json:"saved"`
+
+	// Variant is "Standard", "Chess960" or the name of one of game.Variants
+	Variant  string   `json:"variant"`
+	StartFEN string   `json:"start_fen,omitempty"`
+	Moves    []string `json:"moves,omitempty"`
+	// FEN is the position after the moves
+	FEN         string        `json:"fen"`
+	WhiteTime   time.Duration `json:"white_time"`
+	BlackTime   time.Duration `json:"black_time"`
+	Result      string        `json:"result,omitempty"`
+	Termination string        `json:"termination,omitempty"`
+
+	AI AISettings `json:"ai"`
+	// Theme is the board theme as named in the theme selector
+	Theme string `json:"theme,omitempty"`
+}
+
+// AISettings are the AI controls of the game
+type AISettings struct {
+	Enabled bool `json:"enabled"`
+	// Color is the side the AI plays, "white" or "black"
+	Color  string `json:"color"`
+	Skill  int    `json:"skill"`
+	Ponder bool   `json:"ponder,omitempty"`
+}
+
+// DefaultPath returns where the game is autosaved for the current user
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-chess", "autosave.json"), nil
+}
+
+// FromGame records a game. A running clock is charged up to now. The AI
+// settings and theme are left for the caller to fill in.
+func FromGame(g *game.GameState) *Save {
+	clocks := g.Clone()
+	clocks.StopTimer()
+
+	s := &Save{
+		Version:   Version,
+		Saved:     time.Now(),
+		Variant:   "Standard",
+		StartFEN:  g.StartFEN,
+		FEN:       g.FEN(),
+		WhiteTime: clocks.WhitePlayerTime,
+		BlackTime: clocks.BlackPlayerTime,
+	}
+	if g.Variant != nil {
+		s.Variant = g.Variant.Name()
+	} else if g.Chess960 {
+		s.Variant = "Chess960"
+	}
+	replay := g.PositionAt(0)
+	for _, move := range g.MoveHistory {
+		s.Moves = append(s.Moves, replay.UCI(move.From, move.To, move.Promotion))
+		replay.MakeMoveWithPromotion(move.From, move.To, move.Promotion)
+	}
+	if g.IsGameOver() {
+		s.Result = g.ResultString()
+		s.Termination = g.Termination
+	}
+	return s
+}
+
+// Game replays the saved game. The returned game has its timer stopped.
+func (s *Save) Game() (*game.GameState, error) {
+	g := game.NewGame()
+	if s.StartFEN != "" {
+		var err error
+		if g, err = game.NewGameFromFEN(s.StartFEN); err != nil {
+			return nil, err
+		}
+	}
+	switch s.Variant {
+	case "", "Standard":
+	case "Chess960":
+		g.Chess960 = true
+	default:
+		rules, ok := game.VariantByName(s.Variant)
+		if !ok {
+			return nil, fmt.Errorf("unknown variant %q", s.Variant)
+		}
+		g.SetVariant(rules)
+	}
+	g.TimerActive = false
+
+	for i, move := range s.Moves {
+		from, to, promotion, err := g.ParseUCI(move)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %v", i+1, err)
+		}
+		if !g.IsLegal(from, to, promotion) || g.MakeMoveWithPromotion(from, to, promotion) == game.InvalidMove {
+			return nil, fmt.Errorf("move %d: illegal move %q", i+1, move)
+		}
+	}
+	if s.FEN != "" && g.FEN() != s.FEN {
+		return nil, fmt.Errorf("the moves lead to %s instead of the saved position", g.FEN())
+	}
+
+	g.WhitePlayerTime = s.WhiteTime
+	g.BlackPlayerTime = s.BlackTime
+	switch s.Result {
+	case "1-0":
+		g.GameStatus = game.WhiteWon
+	case "0-1":
+		g.GameStatus = game.BlackWon
+	case "1/2-1/2":
+		g.GameStatus = game.GameDraw
+	}
+	if s.Result != "" {
+		g.Termination = s.Termination
+	}
+	return g, nil
+}
+
+// Resumable reports whether the save holds a game worth resuming: one in
+// progress with at least one move played
+func (s *Save) Resumable() bool {
+	return s.Result == "" && len(s.Moves) > 0
+}
+
+// Load reads a save. A save written by a newer version is refused.
+func Load(path string) (*Save, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Save
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	switch {
+	case s.Version == 0:
+		return nil, fmt.Errorf("%s: not a saved game", path)
+	case s.Version > Version:
+		return nil, fmt.Errorf("%s: saved by a newer version (format %d)", path, s.Version)
+	}
+	return &s, nil
+}
+
+// Write saves to path, creating its directory if needed. The file is
+// replaced in one step, so an interrupted write keeps the previous save.
+func (s *Save) Write(path string) error {
+	if s.Version == 0 {
+		return errors.New("save has no format version")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
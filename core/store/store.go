@@ -0,0 +1,419 @@
+// Package store is a small embedded key/value database kept in one file.
+//
+// Keys live in named buckets and all data is held in memory. Every update
+// is appended to the file as a single checksummed record and synced before
+// it becomes visible, so a crash loses at most the update being written. A
+// torn record at the end of the file is discarded when the file is opened,
+// and Compact rewrites the file with only the live keys.
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fileMagic starts every database file
+const fileMagic = "GOCHESSDB1\n"
+
+// ErrNotFound is returned by Tx.Get for a missing key
+var ErrNotFound = errors.New("key not found")
+
+// op is one change in a record
+type op struct {
+	Bucket string `json:"b"`
+	Key    string `json:"k"`
+	Value  []byte `json:"v,omitempty"`
+	Delete bool   `json:"d,omitempty"`
+}
+
+// DB is an open database file
+type DB struct {
+	mu      sync.RWMutex
+	path    string
+	file    *os.File
+	buckets map[string]map[string][]byte
+	// size is the length of the file and live the bytes a compacted file
+	// would need, used to decide when to compact
+	size int64
+	live int64
+
+	// sorted caches the keys of each bucket in order for scans. A bucket's
+	// entry is dropped when keys are added to or removed from it.
+	sortMu sync.Mutex
+	sorted map[string][]string
+}
+
+// Open opens the database at path, creating it and its directory if needed.
+// A file much larger than its live data is compacted.
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{
+		path:    path,
+		file:    file,
+		buckets: map[string]map[string][]byte{},
+		sorted:  map[string][]string{},
+	}
+	if err := db.load(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if db.size > 2*db.live+1<<20 {
+		if err := db.compact(); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// load replays the records of the file, truncating a torn last record
+func (db *DB) load() error {
+	info, err := db.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		if _, err := db.file.WriteString(fileMagic); err != nil {
+			return err
+		}
+		db.size = int64(len(fileMagic))
+		return db.file.Sync()
+	}
+
+	reader := bufio.NewReader(db.file)
+	magic := make([]byte, len(fileMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != fileMagic {
+		return errors.New("not a database file")
+	}
+
+	offset := int64(len(fileMagic))
+	for {
+		ops, n, err := readRecord(reader)
+		if err != nil {
+			// Everything after the last complete record is a write that
+			// never finished
+			if err := db.file.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+		db.apply(ops)
+		offset += n
+	}
+	db.size = offset
+	_, err = db.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// readRecord reads one length-prefixed, checksummed record
+func readRecord(r io.Reader) ([]op, int64, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, 0, err
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, 0, errors.New("checksum mismatch")
+	}
+
+	var ops []op
+	if err := json.Unmarshal(payload, &ops); err != nil {
+		return nil, 0, err
+	}
+	return ops, int64(len(header)) + int64(length), nil
+}
+
+// encodeRecord frames ops as a record
+func encodeRecord(ops []op) ([]byte, error) {
+	payload, err := json.Marshal(ops)
+	if err != nil {
+		return nil, err
+	}
+	record := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	return append(record, payload...), nil
+}
+
+// apply changes the in-memory data and the live size estimate
+func (db *DB) apply(ops []op) {
+	for _, o := range ops {
+		bucket := db.buckets[o.Bucket]
+		old, exists := bucket[o.Key]
+		if exists {
+			db.live -= int64(len(o.Key) + len(old))
+		}
+		if exists == o.Delete {
+			delete(db.sorted, o.Bucket)
+		}
+		if o.Delete {
+			delete(bucket, o.Key)
+			continue
+		}
+		if bucket == nil {
+			bucket = map[string][]byte{}
+			db.buckets[o.Bucket] = bucket
+		}
+		bucket[o.Key] = o.Value
+		db.live += int64(len(o.Key) + len(o.Value))
+	}
+}
+
+// Close closes the file
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.file == nil {
+		return nil
+	}
+	err := db.file.Close()
+	db.file = nil
+	return err
+}
+
+// View runs fn with a read-only transaction
+func (db *DB) View(fn func(tx *Tx) error) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return fn(&Tx{db: db})
+}
+
+// Update runs fn with a read-write transaction. The changes are written as
+// one record when fn returns nil and discarded when it returns an error.
+func (db *DB) Update(fn func(tx *Tx) error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.file == nil {
+		return errors.New("database is closed")
+	}
+
+	tx := &Tx{db: db, writable: true, pending: map[string]map[string]op{}}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if len(tx.ops) == 0 {
+		return nil
+	}
+
+	record, err := encodeRecord(tx.ops)
+	if err != nil {
+		return err
+	}
+	if _, err := db.file.Write(record); err != nil {
+		// Drop the partial record so later records stay readable
+		db.file.Truncate(db.size)
+		db.file.Seek(db.size, io.SeekStart)
+		return err
+	}
+	if err := db.file.Sync(); err != nil {
+		return err
+	}
+	db.size += int64(len(record))
+	db.apply(tx.ops)
+	return nil
+}
+
+// Compact rewrites the file with only the live keys
+func (db *DB) Compact() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.file == nil {
+		return errors.New("database is closed")
+	}
+	return db.compact()
+}
+
+func (db *DB) compact() error {
+	var ops []op
+	for _, bucket := range sortedKeys(db.buckets) {
+		for _, key := range sortedKeys(db.buckets[bucket]) {
+			ops = append(ops, op{Bucket: bucket, Key: key, Value: db.buckets[bucket][key]})
+		}
+	}
+
+	temp := db.path + ".tmp"
+	file, err := os.OpenFile(temp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	size := int64(len(fileMagic))
+	_, err = file.WriteString(fileMagic)
+	if err == nil && len(ops) > 0 {
+		var record []byte
+		if record, err = encodeRecord(ops); err == nil {
+			_, err = file.Write(record)
+			size += int64(len(record))
+		}
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(temp)
+		return err
+	}
+	if err := os.Rename(temp, db.path); err != nil {
+		file.Close()
+		return err
+	}
+
+	db.file.Close()
+	db.file = file
+	db.size = size
+	return nil
+}
+
+// Tx reads and, inside Update, changes the database
+type Tx struct {
+	db       *DB
+	writable bool
+	ops      []op
+	// pending holds this transaction's changes by bucket and key
+	pending map[string]map[string]op
+}
+
+// Get returns the value of a key, or ErrNotFound
+func (tx *Tx) Get(bucket, key string) ([]byte, error) {
+	if o, ok := tx.pending[bucket][key]; ok {
+		if o.Delete {
+			return nil, ErrNotFound
+		}
+		return o.Value, nil
+	}
+	value, ok := tx.db.buckets[bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+// Put sets the value of a key
+func (tx *Tx) Put(bucket, key string, value []byte) error {
+	return tx.change(op{Bucket: bucket, Key: key, Value: append([]byte{}, value...)})
+}
+
+// Delete removes a key; a missing key is not an error
+func (tx *Tx) Delete(bucket, key string) error {
+	return tx.change(op{Bucket: bucket, Key: key, Delete: true})
+}
+
+func (tx *Tx) change(o op) error {
+	if !tx.writable {
+		return errors.New("read-only transaction")
+	}
+	tx.ops = append(tx.ops, o)
+	if tx.pending[o.Bucket] == nil {
+		tx.pending[o.Bucket] = map[string]op{}
+	}
+	tx.pending[o.Bucket][o.Key] = o
+	return nil
+}
+
+// ForEach calls fn for every key of a bucket in key order, including the
+// transaction's own changes. It stops at the first error fn returns.
+func (tx *Tx) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	return tx.ForEachPrefix(bucket, "", fn)
+}
+
+// ForEachPrefix is ForEach limited to the keys starting with prefix
+func (tx *Tx) ForEachPrefix(bucket, prefix string, fn func(key string, value []byte) error) error {
+	keys := tx.db.sortedKeys(bucket)
+	if len(tx.pending[bucket]) > 0 {
+		merged := map[string]bool{}
+		for _, key := range keys {
+			merged[key] = true
+		}
+		for key := range tx.pending[bucket] {
+			merged[key] = true
+		}
+		keys = sortedKeys(merged)
+	}
+
+	for i := sort.SearchStrings(keys, prefix); i < len(keys) && strings.HasPrefix(keys[i], prefix); i++ {
+		key := keys[i]
+		value, err := tx.Get(bucket, key)
+		if err == ErrNotFound || key == sequenceKey {
+			continue
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetJSON decodes the value of a key into v
+func (tx *Tx) GetJSON(bucket, key string, v interface{}) error {
+	value, err := tx.Get(bucket, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(value, v)
+}
+
+// PutJSON stores v encoded as JSON
+func (tx *Tx) PutJSON(bucket, key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return tx.Put(bucket, key, value)
+}
+
+// NextSequence returns the next number of a counter kept in the bucket
+func (tx *Tx) NextSequence(bucket string) (uint64, error) {
+	var sequence uint64
+	if err := tx.GetJSON(bucket, sequenceKey, &sequence); err != nil && err != ErrNotFound {
+		return 0, err
+	}
+	sequence++
+	return sequence, tx.PutJSON(bucket, sequenceKey, sequence)
+}
+
+// sequenceKey holds a bucket's NextSequence counter. ForEach skips it.
+const sequenceKey = "\x00sequence"
+
+// sortedKeys returns the keys of a bucket in order, sorting them only after
+// the bucket's keys changed
+func (db *DB) sortedKeys(bucket string) []string {
+	db.sortMu.Lock()
+	defer db.sortMu.Unlock()
+	keys, ok := db.sorted[bucket]
+	if !ok {
+		keys = sortedKeys(db.buckets[bucket])
+		db.sorted[bucket] = keys
+	}
+	return keys
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
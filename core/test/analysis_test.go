@@ -0,0 +1,81 @@
+package test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// expectedAccuracy is the accuracy of a move that drops the mover's score
+// from before to after, by the formula of the analysis boards
+func expectedAccuracy(before, after int) float64 {
+	winPercent := func(score int) float64 {
+		score = max(-1000, min(1000, score))
+		return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(score)))-1)
+	}
+	drop := max(0, winPercent(before)-winPercent(after))
+	return max(0, min(100, 103.1668*math.Exp(-0.04354*drop)-3.1669))
+}
+
+func TestAnalyzeGame(t *testing.T) {
+	// 3... Nf6?? allows mate
+	g := playSAN(t, "e4 e5 Qh5 Nc6 Bc4 Nf6 Qxf7#")
+	analysis := ai.AnalyzeGame(g, ai.AnalysisOptions{Depth: 3}, nil)
+	if len(analysis.Moves) != 7 || len(analysis.Evals) != 8 {
+		t.Fatalf("%d moves and %d evaluations", len(analysis.Moves), len(analysis.Evals))
+	}
+
+	blunder := analysis.Moves[5]
+	if blunder.SAN != "Nf6" || blunder.Class != ai.Blunder || blunder.Loss < ai.BlunderLoss || blunder.BestSAN == "Nf6" {
+		t.Errorf("Nf6: %+v", blunder)
+	}
+	if mate := analysis.Moves[6]; mate.Class != ai.GoodMove || mate.Loss != 0 {
+		t.Errorf("Qxf7#: %+v", mate)
+	}
+
+	// Accuracy follows the drop in winning chances, from the mover's side
+	for _, move := range analysis.Moves {
+		before := move.EvalBefore
+		if move.Player == game.BlackPlayer {
+			before = -before
+		}
+		if want := expectedAccuracy(before, before-move.Loss); math.Abs(move.Accuracy-want) > 1e-9 {
+			t.Errorf("%s: accuracy %.2f, want %.2f", move.SAN, move.Accuracy, want)
+		}
+	}
+	if accuracy := analysis.Moves[5].Accuracy; accuracy > 10 {
+		t.Errorf("the blunder has accuracy %.1f", accuracy)
+	}
+
+	// Per side, the average centipawn loss and accuracy are the means over
+	// the side's moves
+	for player, summary := range []ai.SideSummary{analysis.White, analysis.Black} {
+		moves, loss, accuracy := 0, 0, 0.0
+		for _, move := range analysis.Moves {
+			if move.Player == player {
+				moves++
+				loss += move.Loss
+				accuracy += move.Accuracy
+			}
+		}
+		if summary.Moves != moves || summary.AverageLoss != float64(loss)/float64(moves) ||
+			math.Abs(summary.Accuracy-accuracy/float64(moves)) > 1e-9 {
+			t.Errorf("player %d: %+v, want %d moves, loss %d, accuracy %.2f", player, summary, moves, loss, accuracy)
+		}
+	}
+	if analysis.Black.Blunders != 1 || analysis.Black.AverageLoss < ai.BlunderLoss/3 || analysis.Black.Accuracy >= analysis.White.Accuracy {
+		t.Errorf("White %+v, Black %+v", analysis.White, analysis.Black)
+	}
+
+	pgn := g.ToPGN()
+	analysis.Annotate(pgn)
+	if pgn.Moves[5].NAG != 4 || !strings.Contains(pgn.Moves[5].Comment, "Blunder. "+blunder.BestSAN+" was best.") {
+		t.Errorf("Nf6 annotated as $%d {%s}", pgn.Moves[5].NAG, pgn.Moves[5].Comment)
+	}
+	if text := pgn.String(); !strings.Contains(text, "3... Nf6 $4") || !strings.Contains(text, "[Annotator ") {
+		t.Errorf("annotated PGN:\n%s", text)
+	}
+}
@@ -0,0 +1,166 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+const standardFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+func backRankLetters(t *testing.T, id int) string {
+	t.Helper()
+	fen, err := game.Chess960FEN(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranks := strings.Split(strings.Fields(fen)[0], "/")
+	return ranks[7]
+}
+
+// isLegal reports whether the piece on from may move to to
+func isLegal(g *game.GameState, from, to game.Position) bool {
+	for _, move := range g.GetPossibleMoves(from) {
+		if move == to {
+			return true
+		}
+	}
+	return false
+}
+
+func TestChess960BackRanks(t *testing.T) {
+	if fen, err := game.Chess960FEN(game.StandardChess960ID); err != nil || fen != standardFEN {
+		t.Errorf("position 518: %q, %v", fen, err)
+	}
+	for id, want := range map[int]string{0: "BBQNNRKR", 959: "RKRNNQBB", 518: "RNBQKBNR"} {
+		if rank := backRankLetters(t, id); rank != want {
+			t.Errorf("position %d: %s, want %s", id, rank, want)
+		}
+	}
+
+	seen := map[string]bool{}
+	for id := 0; id < 960; id++ {
+		rank := backRankLetters(t, id)
+		seen[rank] = true
+		bishops := strings.Index(rank, "B") + strings.LastIndex(rank, "B")
+		king := strings.Index(rank, "K")
+		if bishops%2 == 0 || king < strings.Index(rank, "R") || king > strings.LastIndex(rank, "R") {
+			t.Fatalf("position %d: invalid setup %s", id, rank)
+		}
+	}
+	if len(seen) != 960 {
+		t.Errorf("only %d distinct setups", len(seen))
+	}
+
+	for _, id := range []int{-1, 960} {
+		if _, err := game.Chess960BackRank(id); err == nil {
+			t.Errorf("position %d accepted", id)
+		}
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		move  string
+		after string
+	}{
+		{"standard kingside", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "r3k2r/8/8/8/8/8/8/R4RK1 b kq -"},
+		{"standard queenside", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", "2kr3r/8/8/8/8/8/8/R3K2R w KQ -"},
+		// The king stays on g1 and only the rook moves
+		{"king on its target", "1r4kr/8/8/8/8/8/8/1R4KR w HBhb - 0 1", "g1h1", "1r4kr/8/8/8/8/8/8/1R3RK1 b kq -"},
+		// The rook stays on d1 and only the king moves
+		{"rook on its target", "r3k2r/8/8/8/8/8/8/3RK2R w HDkq - 0 1", "e1d1", "r3k2r/8/8/8/8/8/8/2KR3R b kq -"},
+		// King and rook swap squares
+		{"king and rook swap", "7k/8/8/8/8/8/8/5KR1 w G - 0 1", "f1g1", "7k/8/8/8/8/8/8/5RK1 b - -"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := game.NewGameFromFEN(test.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.TimerActive = false
+			before := g.FEN()
+
+			from, to, promotion, err := g.ParseUCI(test.move)
+			if err != nil || !isLegal(g, from, to) {
+				t.Fatalf("%s is not legal: %v", test.move, err)
+			}
+			if g.MakeMoveWithPromotion(from, to, promotion) == game.InvalidMove {
+				t.Fatalf("%s was rejected", test.move)
+			}
+			if key := g.PositionKey(); key != test.after {
+				t.Errorf("after %s: %s, want %s", test.move, key, test.after)
+			}
+
+			if !g.UndoLastMove() || g.FEN() != before {
+				t.Errorf("undo: %s, want %s", g.FEN(), before)
+			}
+		})
+	}
+}
+
+func TestChess960CastlingBlocked(t *testing.T) {
+	// The b1 knight blocks the queenside rook's path to d1, and the king
+	// would pass through the f1 square attacked by the f8 rook
+	g, err := game.NewGameFromFEN("1k3r2/8/8/8/8/8/8/RN2K2R w KQ - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, move := range []string{"e1c1", "e1g1"} {
+		from, to, _, _ := g.ParseUCI(move)
+		if isLegal(g, from, to) {
+			t.Errorf("%s is legal", move)
+		}
+	}
+}
+
+func TestCastlingFENRoundTrip(t *testing.T) {
+	tests := []struct {
+		fen      string
+		shredder string
+		chess960 bool
+	}{
+		{standardFEN, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1", false},
+		{"bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1", "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w HFhf - 0 1", true},
+		// Another rook between the castling rook and the edge needs the file
+		{"rk2r2r/8/8/8/8/8/8/RK2R2R w EQeq - 0 1", "rk2r2r/8/8/8/8/8/8/RK2R2R w EAea - 0 1", true},
+		{"4k3/8/8/8/8/8/8/4K2R b K - 3 20", "4k3/8/8/8/8/8/8/4K2R b H - 3 20", false},
+	}
+	for _, test := range tests {
+		for _, fen := range []string{test.fen, test.shredder} {
+			g, err := game.NewGameFromFEN(fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.FEN() != test.fen || g.ShredderFEN() != test.shredder || g.Chess960 != test.chess960 {
+				t.Errorf("%s: FEN %s, Shredder-FEN %s, Chess960 %v", fen, g.FEN(), g.ShredderFEN(), g.Chess960)
+			}
+		}
+	}
+
+	g, err := game.NewChess960Game(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Chess960 || g.FEN() != tests[1].fen {
+		t.Errorf("position 0: %s, Chess960 %v", g.FEN(), g.Chess960)
+	}
+}
+
+func TestChess960CastlingIsNoCapture(t *testing.T) {
+	// The king castles by moving onto its own rook
+	g, err := game.NewGameFromFEN("1r4kr/8/8/8/8/8/8/1R4KR w HBhb - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for move, want := range map[string]bool{"g1h1": false, "b1b8": true, "b1b4": false} {
+		from, to, _, _ := g.ParseUCI(move)
+		if !isLegal(g, from, to) || g.IsCapture(from, to) != want {
+			t.Errorf("%s: capture %v, want %v", move, g.IsCapture(from, to), want)
+		}
+	}
+}
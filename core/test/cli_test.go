@@ -0,0 +1,89 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/cli"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// runCLI plays a script of input lines through a terminal session and
+// returns the output
+func runCLI(t *testing.T, options cli.Options, lines ...string) string {
+	t.Helper()
+	options.ASCII = true
+	var out strings.Builder
+	if err := cli.Run(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out, options); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+// outputLine returns the first output line starting with prefix
+func outputLine(t *testing.T, output, prefix string) string {
+	t.Helper()
+	for _, line := range strings.Split(output, "\n") {
+		if i := strings.Index(line, prefix); i >= 0 {
+			return line[i:]
+		}
+	}
+	t.Fatalf("no %q in the output:\n%s", prefix, output)
+	return ""
+}
+
+func TestCLICommands(t *testing.T) {
+	output := runCLI(t, cli.Options{AI: true, AIColor: game.BlackPlayer, Level: 1},
+		"level", "level 2", "level 30", "e4", "pgn", "undo", "fen", "quit")
+
+	for _, want := range []string{"AI skill level 1", "AI skill level 2", "The level is a number from 1 to 20"} {
+		if !strings.Contains(output, want+"\n") {
+			t.Errorf("missing %q", want)
+		}
+	}
+
+	// The AI answers e4 with a legal move, which the PGN records
+	reply := strings.TrimPrefix(outputLine(t, output, "AI plays "), "AI plays ")
+	playSAN(t, "e4 "+reply)
+	if !strings.Contains(output, "1. e4 "+reply) {
+		t.Errorf("the PGN does not record 1. e4 %s", reply)
+	}
+
+	// Undo takes back the AI's reply and the user's move
+	if fen := outputLine(t, output, game.StandardFEN[:8]); fen != game.StandardFEN {
+		t.Errorf("after undo: %s", fen)
+	}
+}
+
+func TestCLIPositions(t *testing.T) {
+	const bareKings = "8/8/8/8/8/8/8/K6k w - - 0 1"
+	output := runCLI(t, cli.Options{},
+		"fen "+bareKings, "fen", "Kb1", "new", "fen not a position", "fen", "quit")
+
+	if !strings.Contains(output, bareKings+"\n") {
+		t.Errorf("fen does not show the position set up")
+	}
+	outputLine(t, output, "Draw by insufficient material")
+	outputLine(t, output, "The game is over")
+	outputLine(t, output, "Invalid FEN")
+	if !strings.HasSuffix(strings.TrimSpace(output), game.StandardFEN+"\nwhite>") {
+		t.Errorf("new does not start over:\n%s", output)
+	}
+}
+
+func TestCLIRepetition(t *testing.T) {
+	output := runCLI(t, cli.Options{},
+		"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8", "e4", "undo", "Ng4", "quit")
+
+	if strings.Count(output, "Draw by threefold repetition") != 1 {
+		t.Errorf("the repetition is not announced once:\n%s", output)
+	}
+	outputLine(t, output, "The game is over")
+	if strings.Contains(output, "is not legal") {
+		t.Errorf("a move was rejected:\n%s", output)
+	}
+	// After undo the game goes on
+	if !strings.HasSuffix(strings.TrimSpace(output), "white>") {
+		t.Errorf("Ng4 after undo was not played:\n%s", output)
+	}
+}
@@ -0,0 +1,217 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/correspondence"
+	"github.com/h3bzzz/go-chess/core/store"
+)
+
+// fakeClock is a settable time for correspondence managers
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func openCorrespondence(t *testing.T, path string, clock *fakeClock) *correspondence.Manager {
+	t.Helper()
+	manager, err := correspondence.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager.Now = clock.Now
+	t.Cleanup(func() { manager.Close() })
+	return manager
+}
+
+func TestStorePersistsAndDropsTornRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := store.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		err := db.Update(func(tx *store.Tx) error {
+			id, err := tx.NextSequence("items")
+			if err != nil {
+				return err
+			}
+			return tx.PutJSON("items", string(rune('a'+id)), id)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = db.Update(func(tx *store.Tx) error {
+		tx.Put("items", "b", []byte("changed"))
+		return errors.New("rolled back")
+	})
+	if err == nil {
+		t.Fatal("Update did not return the error of its function")
+	}
+	db.Close()
+
+	// A write cut short by a crash
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte{0, 0, 1, 0, 'x'})
+	file.Close()
+
+	db, err = store.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var keys []string
+	err = db.View(func(tx *store.Tx) error {
+		return tx.ForEach("items", func(key string, value []byte) error {
+			keys = append(keys, key+"="+string(value))
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 || keys[0] != "b=1" || keys[1] != "c=2" || keys[2] != "d=3" {
+		t.Fatalf("items after reopening = %v", keys)
+	}
+
+	if err := db.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *store.Tx) error { return tx.Delete("items", "b") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if db, err = store.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	err = db.View(func(tx *store.Tx) error {
+		_, err := tx.Get("items", "b")
+		return err
+	})
+	if err != store.ErrNotFound {
+		t.Fatalf("deleted key after compaction: %v", err)
+	}
+}
+
+func TestCorrespondenceResumesAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "correspondence.db")
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	manager := openCorrespondence(t, path, clock)
+
+	first, err := manager.NewGame("alice", "bob", "Standard", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := manager.NewGame("carol", "alice", "King of the Hill", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, move := range []string{"f2f3", "e7e5", "g2g4"} {
+		clock.advance(time.Hour)
+		if _, err := manager.Move(first.ID, move); err != nil {
+			t.Fatalf("%s: %v", move, err)
+		}
+	}
+	if _, err := manager.Move(first.ID, "e1e3"); err == nil {
+		t.Fatal("an illegal move was accepted")
+	}
+	manager.Close()
+
+	manager = openCorrespondence(t, path, clock)
+	games, err := manager.Games()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The one-day game is more urgent
+	if len(games) != 2 || games[0].ID != second.ID || games[1].ID != first.ID {
+		t.Fatalf("games after reopening = %+v", games)
+	}
+	if len(games[1].Moves) != 3 {
+		t.Fatalf("moves after reopening = %v", games[1].Moves)
+	}
+
+	g, err := manager.Move(first.ID, "d8h4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Result != "0-1" || g.Termination != "checkmate" {
+		t.Fatalf("after mate: %s (%s)", g.Result, g.Termination)
+	}
+	if _, err := manager.Move(first.ID, "a2a3"); err != correspondence.ErrGameOver {
+		t.Fatalf("move after the end: %v", err)
+	}
+}
+
+func TestCorrespondenceVacationAndTimeout(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}
+	manager := openCorrespondence(t, filepath.Join(t.TempDir(), "correspondence.db"), clock)
+
+	g, err := manager.NewGame("alice", "bob", "Standard", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline, err := manager.Deadline(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.now.Add(2 * correspondence.Day); !deadline.Equal(want) {
+		t.Fatalf("deadline = %v, want %v", deadline, want)
+	}
+
+	// A vacation starting the next day stops alice's clock for five days
+	if err := manager.TakeVacation("alice", clock.now.Add(correspondence.Day), 5); err != nil {
+		t.Fatal(err)
+	}
+	if deadline, err = manager.Deadline(g); err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.now.Add(7 * correspondence.Day); !deadline.Equal(want) {
+		t.Fatalf("deadline with vacation = %v, want %v", deadline, want)
+	}
+	// bob's vacation does not help alice
+	if err := manager.TakeVacation("bob", clock.now, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.TakeVacation("alice", clock.now.Add(30*correspondence.Day), correspondence.MaxVacationDays); err == nil {
+		t.Fatal("a vacation beyond the yearly allowance was accepted")
+	}
+
+	clock.advance(6 * correspondence.Day)
+	if timedOut, err := manager.CheckTimeouts(); err != nil || len(timedOut) != 0 {
+		t.Fatalf("timeouts during the vacation: %v, %v", timedOut, err)
+	}
+	if _, err := manager.Move(g.ID, "e2e4"); err != nil {
+		t.Fatal(err)
+	}
+
+	// bob is back from vacation and misses his deadline
+	clock.advance(2*correspondence.Day + time.Minute)
+	timedOut, err := manager.CheckTimeouts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timedOut) != 1 || timedOut[0].Result != "1-0" || timedOut[0].Termination != "time forfeit" {
+		t.Fatalf("timeouts = %+v", timedOut)
+	}
+
+	// A late move loses instead of being played
+	late, err := manager.NewGame("carol", "dave", "Standard", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(correspondence.Day + time.Second)
+	g, err = manager.Move(late.ID, "e2e4")
+	if err != correspondence.ErrTimeForfeit || g.Result != "0-1" || len(g.Moves) != 0 {
+		t.Fatalf("late move: %v, %+v", err, g)
+	}
+}
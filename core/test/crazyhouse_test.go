@@ -0,0 +1,154 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+func crazyhouseGame(t *testing.T, fen string) *game.GameState {
+	t.Helper()
+	return variantGame(t, game.Crazyhouse{}, fen)
+}
+
+func TestCrazyhouseCaptureFillsPocket(t *testing.T) {
+	const fen = "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR[] w KQkq - 0 2"
+	g := crazyhouseGame(t, fen)
+
+	playUCI(t, g, "e4d5")
+	if g.PocketCount(game.WhitePlayer, game.WhitePawn) != 1 {
+		t.Errorf("pocket after exd5: %s", g.FEN())
+	}
+	playUCI(t, g, "d8d5")
+	if key := g.PositionKey(); key != "rnb1kbnr/ppp1pppp/8/3q4/8/8/PPPP1PPP/RNBQKBNR[Pp] w KQkq -" {
+		t.Errorf("after Qxd5: %s", key)
+	}
+
+	undoTo(t, g, "rnbqkbnr/ppp1pppp/8/3P4/8/8/PPPP1PPP/RNBQKBNR[P] b KQkq - 0 2")
+	undoTo(t, g, fen)
+}
+
+func TestCrazyhousePromotedPieceReturnsAsPawn(t *testing.T) {
+	g := crazyhouseGame(t, "1r2k3/P7/8/8/8/8/8/4K3[] w - - 0 1")
+
+	playUCI(t, g, "a7a8q")
+	afterPromotion := g.FEN()
+	if afterPromotion != "Q~r2k3/8/8/8/8/8/8/4K3[] b - - 0 1" {
+		t.Errorf("after a8=Q: %s", afterPromotion)
+	}
+	playUCI(t, g, "b8a8")
+	if g.PocketCount(game.BlackPlayer, game.BlackPawn) != 1 || g.PocketCount(game.BlackPlayer, game.BlackQueen) != 0 {
+		t.Errorf("capturing the promoted queen: %s", g.FEN())
+	}
+	undoTo(t, g, afterPromotion)
+}
+
+func TestCrazyhouseFENRoundTrip(t *testing.T) {
+	for _, fen := range []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1",
+		"Q~3k3/8/8/8/8/8/8/4K3[QRBNPPqn] b - - 4 30",
+	} {
+		if got := crazyhouseGame(t, fen).FEN(); got != fen {
+			t.Errorf("%s came back as %s", fen, got)
+		}
+	}
+	if _, err := game.NewGameFromFEN("4k3/8/8/8/8/8/8/4K3[Kx] w - - 0 1"); err == nil {
+		t.Error("a king in the pocket was accepted")
+	}
+}
+
+func TestCrazyhouseDrops(t *testing.T) {
+	const fen = "4k3/8/8/8/8/8/8/4K3[NPp] w - - 0 1"
+	g := crazyhouseGame(t, fen)
+
+	// Pawns stay off the first and last rank; other pieces go anywhere
+	// empty
+	pawnSquares := g.GetPossibleDrops(game.WhitePawn)
+	if len(pawnSquares) != 48 {
+		t.Errorf("%d pawn drop squares, want 48", len(pawnSquares))
+	}
+	for _, square := range pawnSquares {
+		if square.Y == 0 || square.Y == 7 {
+			t.Errorf("pawn drop on %s", game.SquareName(square))
+		}
+	}
+	if squares := g.GetPossibleDrops(game.WhiteKnight); len(squares) != 62 {
+		t.Errorf("%d knight drop squares, want 62", len(squares))
+	}
+	if squares := g.GetPossibleDrops(game.WhiteQueen); len(squares) != 0 {
+		t.Errorf("a queen that is not in the pocket can be dropped on %v", squares)
+	}
+	if g.MakeDrop(game.WhitePawn, game.Position{X: 0, Y: 7}) != game.InvalidMove {
+		t.Error("dropped a pawn on the last rank")
+	}
+
+	// Drops in SAN and UCI
+	from, to, piece, err := g.ParseSAN("N@f3")
+	if err != nil || from != to || piece != game.WhiteKnight || game.SquareName(to) != "f3" {
+		t.Fatalf("N@f3: %v %v %d %v", from, to, piece, err)
+	}
+	if san, uci := g.SAN(from, to, piece), g.UCI(from, to, piece); san != "N@f3" || uci != "N@f3" {
+		t.Errorf("knight drop written as %s and %s", san, uci)
+	}
+	e4 := game.Position{X: 4, Y: 3}
+	if san, uci := g.SAN(e4, e4, game.WhitePawn), g.UCI(e4, e4, game.WhitePawn); san != "@e4" || uci != "P@e4" {
+		t.Errorf("pawn drop written as %s and %s", san, uci)
+	}
+
+	if from, to, piece, err := g.ParseUCI("N@f3"); err != nil || from != to || g.MakeDrop(piece, to) == game.InvalidMove {
+		t.Fatalf("N@f3 was rejected: %v", err)
+	}
+	if g.FEN() != "4k3/8/8/8/8/5N2/8/4K3[Pp] b - - 1 1" {
+		t.Errorf("after N@f3: %s", g.FEN())
+	}
+	if last := g.MoveHistory[len(g.MoveHistory)-1]; !last.Drop {
+		t.Error("the drop is not recorded as one")
+	}
+	undoTo(t, g, fen)
+}
+
+func TestCrazyhouseDropsInCheck(t *testing.T) {
+	// Only a drop between the rook and the king answers the check
+	g := crazyhouseGame(t, "4k3/8/8/8/8/8/8/r3K3[N] w - - 0 1")
+	var names []string
+	for _, square := range g.GetPossibleDrops(game.WhiteKnight) {
+		names = append(names, game.SquareName(square))
+	}
+	if len(names) != 3 || names[0] != "b1" || names[1] != "c1" || names[2] != "d1" {
+		t.Errorf("drops in check: %v, want [b1 c1 d1]", names)
+	}
+	if g.MakeDrop(game.WhiteKnight, game.Position{X: 5, Y: 2}) != game.InvalidMove {
+		t.Error("a drop ignored the check")
+	}
+}
+
+func TestCrazyhouseDropStopsMateFromFEN(t *testing.T) {
+	// A back rank mate in standard chess, but a knight drop blocks it
+	const fen = "R6k/6pp/8/8/8/8/8/K7[n] b - - 0 1"
+	g, err := game.NewGameFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.GameStatus != game.WhiteWon {
+		t.Errorf("under the standard rules: status %v", g.GameStatus)
+	}
+	g.SetVariant(game.Crazyhouse{})
+	if g.GameStatus != game.InProgress || g.IsGameOver() {
+		t.Errorf("with a knight to drop: status %v", g.GameStatus)
+	}
+	if squares := g.GetPossibleDrops(game.BlackKnight); len(squares) != 6 {
+		t.Errorf("%d blocking drops, want b8 to g8", len(squares))
+	}
+
+	pgns, err := game.ParsePGN(strings.NewReader("[Variant \"Crazyhouse\"]\n[FEN \"" + fen + "\"]\n\n*\n"))
+	if err != nil || len(pgns) != 1 {
+		t.Fatalf("%d games: %v", len(pgns), err)
+	}
+	if g, err = pgns[0].Game(); err != nil {
+		t.Fatal(err)
+	}
+	if g.GameStatus != game.InProgress {
+		t.Errorf("from PGN: status %v", g.GameStatus)
+	}
+}
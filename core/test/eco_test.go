@@ -0,0 +1,73 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// playSAN plays moves in SAN from the standard starting position
+func playSAN(t *testing.T, moves string) *game.GameState {
+	t.Helper()
+	g := game.NewGame()
+	g.TimerActive = false
+	for _, san := range strings.Fields(moves) {
+		from, to, promotion, err := g.ParseSAN(san)
+		if err != nil {
+			t.Fatalf("%s: %v", san, err)
+		}
+		g.MakeMoveWithPromotion(from, to, promotion)
+	}
+	return g
+}
+
+func TestOpeningTableIsValid(t *testing.T) {
+	count, err := game.Openings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count < 300 {
+		t.Fatalf("the opening table has only %d positions", count)
+	}
+}
+
+func TestClassifyOpening(t *testing.T) {
+	tests := []struct {
+		moves string
+		eco   string
+		name  string
+	}{
+		{"e4 c5 Nf3 d6 d4 cxd4 Nxd4 Nf6 Nc3 a6", "B90", "Sicilian Defense: Najdorf Variation"},
+		// Moves after the book keep the last opening reached
+		{"e4 e5 Nf3 Nc6 Bb5 a6 Bxc6 dxc6 O-O f6 d4", "C69", "Ruy Lopez: Exchange Variation, Normal Variation"},
+		// A transposition is recognized by its position
+		{"Nf3 d5 d4 Nf6 c4 e6 Nc3", "D37", "Queen's Gambit Declined: Three Knights Variation"},
+		{"c4 e5", "A20", "English Opening: King's English Variation"},
+	}
+	for _, test := range tests {
+		opening, ok := playSAN(t, test.moves).ClassifyOpening()
+		if !ok || opening.ECO != test.eco || opening.Name != test.name {
+			t.Errorf("%s: got %s %q, want %s %q", test.moves, opening.ECO, opening.Name, test.eco, test.name)
+		}
+	}
+
+	if _, ok := game.NewGame().ClassifyOpening(); ok {
+		t.Error("the starting position was classified")
+	}
+
+	atomic, err := game.NewVariantGame(game.Atomic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, to, promotion, _ := atomic.ParseSAN("e4")
+	atomic.MakeMoveWithPromotion(from, to, promotion)
+	if _, ok := atomic.ClassifyOpening(); ok {
+		t.Error("a variant game was classified")
+	}
+
+	pgn := playSAN(t, "d4 Nf6 c4 g6 Nc3 d5").ToPGN()
+	if pgn.Tags["ECO"] != "D80" || pgn.Tags["Opening"] != "Grünfeld Defense" {
+		t.Errorf("PGN tags ECO %q, Opening %q", pgn.Tags["ECO"], pgn.Tags["Opening"])
+	}
+}
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/engine"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// engineSession drives engine.Run through pipes
+type engineSession struct {
+	t     *testing.T
+	in    *io.PipeWriter
+	lines chan string
+}
+
+func startEngine(t *testing.T) *engineSession {
+	t.Helper()
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	s := &engineSession{t: t, in: inWriter, lines: make(chan string, 100)}
+
+	go func() {
+		engine.Run(inReader, outWriter, engine.Options{Threads: 1})
+		outWriter.Close()
+	}()
+	go func() {
+		scanner := bufio.NewScanner(outReader)
+		for scanner.Scan() {
+			s.lines <- scanner.Text()
+		}
+		close(s.lines)
+	}()
+	t.Cleanup(func() { inWriter.Close() })
+	return s
+}
+
+func (s *engineSession) send(lines ...string) {
+	for _, line := range lines {
+		if _, err := io.WriteString(s.in, line+"\n"); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+}
+
+// expect returns the first output line starting with prefix, failing on
+// any other line starting with one of the unwanted prefixes
+func (s *engineSession) expect(prefix string, unwanted ...string) string {
+	s.t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.t.Fatalf("engine exited waiting for %q", prefix)
+			}
+			if strings.HasPrefix(line, prefix) {
+				return line
+			}
+			for _, bad := range unwanted {
+				if strings.HasPrefix(line, bad) {
+					s.t.Fatalf("got %q waiting for %q", line, prefix)
+				}
+			}
+		case <-timeout:
+			s.t.Fatalf("no %q from the engine", prefix)
+		}
+	}
+}
+
+// legalReply checks a move in UCI notation is legal after the moves
+func legalReply(t *testing.T, moves []string, reply string) {
+	t.Helper()
+	g := game.NewGame()
+	g.TimerActive = false
+	for _, move := range append(moves, reply) {
+		from, to, promotion, err := g.ParseUCI(move)
+		if err != nil || !g.IsLegal(from, to, promotion) {
+			t.Fatalf("%s is not legal after %v", move, moves)
+		}
+		g.MakeMoveWithPromotion(from, to, promotion)
+	}
+}
+
+func TestUCIEngine(t *testing.T) {
+	s := startEngine(t)
+	s.send("uci")
+	s.expect("uciok")
+	s.send("isready")
+	s.expect("readyok")
+
+	s.send("position startpos moves e2e4 e7e5", "go depth 2")
+	move := strings.Fields(s.expect("bestmove"))[1]
+	legalReply(t, []string{"e2e4", "e7e5"}, move)
+
+	// Back rank mate in one
+	s.send("position fen 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "go depth 3")
+	if line := s.expect("bestmove"); line != "bestmove a1a8" {
+		t.Errorf("mate in one: %s", line)
+	}
+
+	s.send("go infinite", "stop")
+	s.expect("bestmove")
+	s.send("quit")
+}
+
+func TestXBoardEngine(t *testing.T) {
+	s := startEngine(t)
+	s.send("xboard", "protover 2")
+	if feature := s.expect("feature"); !strings.Contains(feature, "usermove=1") || !strings.HasSuffix(feature, "done=1") {
+		t.Errorf("features: %s", feature)
+	}
+
+	// The engine plays Black after "new" and answers White's move
+	s.send("new", "sd 2", "usermove e2e4")
+	move := strings.TrimPrefix(s.expect("move", "Illegal"), "move ")
+	legalReply(t, []string{"e2e4"}, move)
+
+	s.send("usermove e2e5")
+	s.expect("Illegal move: e2e5", "move")
+
+	// In force mode moves are only recorded
+	s.send("force", "usermove d2d4", "ping 7")
+	s.expect("pong 7", "move")
+
+	// "go" makes the engine play the side to move
+	s.send("setboard 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "sd 3", "go")
+	if line := s.expect("move"); line != "move a1a8" {
+		t.Errorf("mate in one: %s", line)
+	}
+	s.expect("1-0 {")
+
+	s.send("new", "level 40 5 0", "time 500", "otim 500", "white", "go")
+	move = strings.TrimPrefix(s.expect("move", "Illegal"), "move ")
+	legalReply(t, nil, move)
+	s.send("quit")
+}
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/game"
+	"github.com/h3bzzz/go-chess/core/puzzle"
+)
+
+func TestParseEPD(t *testing.T) {
+	epd, err := game.ParseEPD(`2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001; the first";` +
+		` am Qxh6  Qg5; pv Qg6 fxg6;c0 "spaces  kept"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if epd.FEN != "2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - 0 1" {
+		t.Errorf("FEN %q", epd.FEN)
+	}
+	// A semicolon inside quotes does not end the operation
+	if epd.ID() != "WAC.001; the first" {
+		t.Errorf("id %q", epd.ID())
+	}
+	for opcode, want := range map[string][]string{
+		"bm": {"Qg6"},
+		"am": {"Qxh6", "Qg5"},
+		"pv": {"Qg6", "fxg6"},
+		"sm": {},
+	} {
+		if moves := epd.Moves(opcode); !reflect.DeepEqual(moves, want) {
+			t.Errorf("%s: %v, want %v", opcode, moves, want)
+		}
+	}
+	if comment := epd.Operations["c0"]; comment != "spaces  kept" {
+		t.Errorf("c0 %q", comment)
+	}
+
+	g, err := epd.Game()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, to, _, err := g.ParseSAN(epd.Moves("bm")[0]); err != nil || g.UCI(from, to, game.Empty) != "g3g6" {
+		t.Errorf("bm does not parse in the position: %v", err)
+	}
+}
+
+func TestParseEPDMoveCounters(t *testing.T) {
+	tests := []struct {
+		line string
+		fen  string
+	}{
+		{"8/8/8/8/8/8/8/K6k w - -", "8/8/8/8/8/8/8/K6k w - - 0 1"},
+		{"8/8/8/8/8/8/8/K6k b - - hmvc 12; fmvn 40;", "8/8/8/8/8/8/8/K6k b - - 12 40"},
+		// Counters that are not numbers are ignored
+		{"8/8/8/8/8/8/8/K6k w - - hmvc x; fmvn 7;", "8/8/8/8/8/8/8/K6k w - - 0 7"},
+	}
+	for _, test := range tests {
+		epd, err := game.ParseEPD(test.line)
+		if err != nil {
+			t.Errorf("%s: %v", test.line, err)
+			continue
+		}
+		if epd.FEN != test.fen {
+			t.Errorf("%s: FEN %q, want %q", test.line, epd.FEN, test.fen)
+		}
+	}
+}
+
+func TestReadEPD(t *testing.T) {
+	records, err := game.ReadEPD(strings.NewReader(`# a suite
+8/8/8/8/8/8/8/K6k w - - id "one";
+
+8/8/8/8/8/8/8/K6k b - - id "two";
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].ID() != "one" || records[1].ID() != "two" {
+		t.Fatalf("records %v", records)
+	}
+
+	for _, bad := range []string{
+		"8/8/8/8 w",
+		"8/8/8/8/8/8/8/K6k x - - id \"bad side\";",
+	} {
+		_, err := game.ReadEPD(strings.NewReader("8/8/8/8/8/8/8/K6k w - -\n" + bad + "\n"))
+		if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+			t.Errorf("%q: error %v, want one for line 2", bad, err)
+		}
+	}
+}
+
+func TestEnPassantIsReported(t *testing.T) {
+	games, err := game.ParsePGN(strings.NewReader("1. e4 Nf6 2. e5 d5 3. exd6 *\n"))
+	if err != nil || len(games) != 1 {
+		t.Fatalf("%d games: %v", len(games), err)
+	}
+	if _, err := games[0].Game(); !errors.Is(err, game.ErrEnPassant) || !strings.HasPrefix(err.Error(), "move 5: ") {
+		t.Errorf("exd6: %v", err)
+	}
+
+	// A best move taken en passant
+	_, err = puzzle.ReadEPD(strings.NewReader("rnbqkb1r/ppp1pppp/5n2/3pP3/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 bm exd6;"))
+	if !errors.Is(err, game.ErrEnPassant) {
+		t.Errorf("bm exd6: %v", err)
+	}
+
+	// Black takes en passant too; a pawn capturing on an empty square with
+	// nothing behind it is just illegal
+	for _, test := range []struct {
+		moves, san string
+		enPassant  bool
+	}{
+		{"Nf3 e5 Nc3 e4 d4", "exd3", true},
+		{"Nf3 e5 Nc3 e4 d4", "dxe3", false},
+		{"Nf3 e5 Nc3 e4 a3", "exd3", false},
+	} {
+		g := playSAN(t, test.moves)
+		if _, _, _, err := g.ParseSAN(test.san); err == nil || errors.Is(err, game.ErrEnPassant) != test.enPassant {
+			t.Errorf("%s %s: %v", test.moves, test.san, err)
+		}
+	}
+}
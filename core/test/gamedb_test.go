@@ -0,0 +1,168 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/game"
+	"github.com/h3bzzz/go-chess/core/gamedb"
+)
+
+const databasePGN = `[Event "Club"]
+[Site "?"]
+[Date "2023.05.14"]
+[Round "1"]
+[White "Carlsen, Magnus"]
+[Black "Nepomniachtchi, Ian"]
+[Result "1-0"]
+[ECO "C65"]
+[Opening "Ruy Lopez: Berlin Defense"]
+[WhiteElo "2850"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 Nf6 4. O-O Nxe4 1-0
+
+[Event "Club"]
+[Site "?"]
+[Date "2024.01.20"]
+[Round "2"]
+[White "Ding, Liren"]
+[Black "Carlsen, Magnus"]
+[Result "1/2-1/2"]
+[ECO "B90"]
+[Opening "Sicilian Defense: Najdorf Variation"]
+
+1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 1/2-1/2
+
+[Event "Club"]
+[Site "?"]
+[Date "2024.03.02"]
+[Round "3"]
+[White "Ding, Liren"]
+[Black "Firouzja, Alireza"]
+[Result "0-1"]
+[ECO "C60"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Bxc6 dxc6 0-1
+`
+
+func TestGameDatabaseSearch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.db")
+	db, err := gamedb.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := db.Import(strings.NewReader(databasePGN))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 3 || len(result.Failed) != 0 {
+		t.Fatalf("import = %+v", result)
+	}
+	if result, _ = db.Import(strings.NewReader(databasePGN)); result.Imported != 0 || result.Duplicates != 3 {
+		t.Fatalf("importing again = %+v", result)
+	}
+	db.Close()
+
+	// The indexes survive reopening
+	if db, err = gamedb.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ruyLopez := "r1bqkbnr/pppp1ppp/2n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R b KQkq - 3 3"
+	tests := []struct {
+		name  string
+		query gamedb.Query
+		want  []string
+	}{
+		{"all", gamedb.Query{}, []string{"3", "2", "1"}},
+		{"player", gamedb.Query{Player: "carlsen"}, []string{"2", "1"}},
+		{"player and result", gamedb.Query{Player: "ding", Result: "0-1"}, []string{"3"}},
+		{"year", gamedb.Query{DateFrom: "2024", DateTo: "2024"}, []string{"3", "2"}},
+		{"date range", gamedb.Query{DateTo: "2024.02"}, []string{"2", "1"}},
+		{"ECO prefix", gamedb.Query{ECO: "c6"}, []string{"3", "1"}},
+		{"opening name", gamedb.Query{Opening: "najdorf"}, []string{"2"}},
+		{"position", gamedb.Query{FEN: ruyLopez}, []string{"3", "1"}},
+		{"material", gamedb.Query{Material: "KQRRBNNPPPPPPPPvKQRRBBNPPPPPPPP"}, []string{"3"}},
+		{"limit", gamedb.Query{Limit: 1}, []string{"3"}},
+	}
+	for _, test := range tests {
+		games, err := db.Search(test.query)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		var got []string
+		for _, g := range games {
+			got = append(got, g.Round)
+		}
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s: rounds %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	games, err := db.Search(gamedb.Query{Player: "Firouzja"})
+	if err != nil || len(games) != 1 {
+		t.Fatalf("search by player: %v, %v", games, err)
+	}
+	state, err := games[0].State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.MoveHistory) != 8 || state.ResultString() != "0-1" {
+		t.Fatalf("opened game has %d moves and result %s", len(state.MoveHistory), state.ResultString())
+	}
+}
+
+func TestOpeningExplorer(t *testing.T) {
+	db, err := gamedb.Open(filepath.Join(t.TempDir(), "games.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Import(strings.NewReader(databasePGN)); err != nil {
+		t.Fatal(err)
+	}
+
+	moves, err := db.Explore(game.NewGame())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 1 || moves[0].SAN != "e4" || moves[0].Move != "e2e4" || moves[0].Games != 3 {
+		t.Fatalf("moves from the start = %+v", moves)
+	}
+	if moves[0].WhiteWins != 1 || moves[0].Draws != 1 || moves[0].BlackWins != 1 {
+		t.Errorf("results after 1.e4 = %+v", moves[0])
+	}
+
+	moves, err = db.Explore(playSAN(t, "e4 e5 Nf3 Nc6 Bb5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 2 {
+		t.Fatalf("moves after 3.Bb5 = %+v", moves)
+	}
+	for _, move := range moves {
+		switch move.SAN {
+		case "Nf6":
+			if move.WhitePercent() != 100 || move.AverageWhite != 2850 || move.AverageBlack != 0 {
+				t.Errorf("3...Nf6 = %+v", move)
+			}
+		case "a6":
+			if move.BlackPercent() != 100 || move.AverageWhite != 0 {
+				t.Errorf("3...a6 = %+v", move)
+			}
+		default:
+			t.Errorf("unexpected move %s", move.SAN)
+		}
+	}
+
+	// Other variants keep their own statistics
+	atomic, err := game.NewVariantGame(game.Atomic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moves, _ = db.Explore(atomic); len(moves) != 0 {
+		t.Errorf("moves in an Atomic game = %+v", moves)
+	}
+}
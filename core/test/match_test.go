@@ -0,0 +1,123 @@
+package test
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/game"
+	"github.com/h3bzzz/go-chess/core/match"
+)
+
+func TestStatsElo(t *testing.T) {
+	tests := []struct {
+		wins, draws, losses int
+		elo, margin         float64
+	}{
+		{60, 20, 20, 147.19, 66.01},
+		{50, 0, 50, 0, 68.99},
+		{20, 60, 20, 0, 43.29},
+		{30, 50, 20, 34.86, 48.47},
+		// More games with the same score narrow the interval
+		{400, 300, 300, 34.86, 18.08},
+		// A perfect score is clamped instead of infinite
+		{10, 0, 0, 1199.83, 0},
+		{0, 0, 5, -1199.83, 0},
+		{0, 0, 0, 0, 0},
+	}
+	for _, test := range tests {
+		stats := match.Stats{Wins: test.wins, Draws: test.draws, Losses: test.losses}
+		elo, margin := stats.Elo()
+		if math.Abs(elo-test.elo) > 0.01 || math.Abs(margin-test.margin) > 0.01 {
+			t.Errorf("%d/%d/%d: Elo %.2f ± %.2f, want %.2f ± %.2f",
+				test.wins, test.draws, test.losses, elo, margin, test.elo, test.margin)
+		}
+	}
+}
+
+func TestSPRT(t *testing.T) {
+	sprt := match.SPRT{Elo0: 0, Elo1: 5, Alpha: 0.05, Beta: 0.05}
+	lower, upper := sprt.Bounds()
+	if math.Abs(lower+2.944) > 0.001 || math.Abs(upper-2.944) > 0.001 {
+		t.Errorf("bounds (%.3f, %.3f), want (-2.944, 2.944)", lower, upper)
+	}
+
+	tests := []struct {
+		wins, draws, losses int
+		llr                 float64
+		status              match.SPRTStatus
+	}{
+		{600, 200, 200, 8.832, match.SPRTAcceptH1},
+		{200, 200, 600, -9.156, match.SPRTAcceptH0},
+		{520, 1000, 480, 0.738, match.SPRTContinue},
+		{10, 10, 10, -0.005, match.SPRTContinue},
+		// Without variance there is nothing to test yet
+		{10, 0, 0, 0, match.SPRTContinue},
+		{0, 0, 0, 0, match.SPRTContinue},
+	}
+	for _, test := range tests {
+		stats := &match.Stats{Wins: test.wins, Draws: test.draws, Losses: test.losses}
+		llr := sprt.LLR(stats)
+		if math.Abs(llr-test.llr) > 0.001 || sprt.Status(stats) != test.status {
+			t.Errorf("%d/%d/%d: LLR %.3f %v, want %.3f %v",
+				test.wins, test.draws, test.losses, llr, sprt.Status(stats), test.llr, test.status)
+		}
+	}
+}
+
+// scriptedPlayer plays fixed moves in UCI notation, one per half-move of
+// the game
+type scriptedPlayer struct {
+	moves []string
+}
+
+func (p *scriptedPlayer) Name() string   { return "script" }
+func (p *scriptedPlayer) NewGame() error { return nil }
+func (p *scriptedPlayer) Close() error   { return nil }
+
+func (p *scriptedPlayer) BestMove(position *game.GameState) (string, int, error) {
+	ply := len(position.MoveHistory)
+	if ply >= len(p.moves) {
+		return "", 0, fmt.Errorf("no move scripted for ply %d", ply)
+	}
+	return p.moves[ply], 0, nil
+}
+
+func TestPlayGameAdjudication(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		moves    string
+		maxPlies int
+		result   string
+		reason   string
+	}{
+		{"checkmate", "", "f2f3 e7e5 g2g4 d8h4", 0, "0-1", "checkmate"},
+		{"stalemate", "k7/8/8/1Q6/8/8/8/7K w - - 0 1", "b5b6", 0, "1/2-1/2", "stalemate"},
+		{"threefold repetition", "", "g1f3 g8f6 f3g1 f6g8 g1f3 g8f6 f3g1 f6g8", 0, "1/2-1/2", "threefold repetition"},
+		{"fifty-move rule", "k7/8/8/8/8/8/8/KQ6 w - - 99 80", "b1b2", 0, "1/2-1/2", "fifty-move rule"},
+		{"insufficient material", "k7/8/8/8/8/8/8/7K w - - 0 1", "", 0, "1/2-1/2", "insufficient material"},
+		{"illegal move", "", "e2e5", 0, "0-1", "illegal move e2e5 by script"},
+		{"max moves", "", "e2e4 e7e5 g1f3", 2, "1/2-1/2", "max moves"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var opening *game.GameState
+			if test.fen != "" {
+				var err error
+				if opening, err = game.NewGameFromFEN(test.fen); err != nil {
+					t.Fatal(err)
+				}
+			}
+			player := &scriptedPlayer{moves: strings.Fields(test.moves)}
+			record, err := match.PlayGame(player, player, opening, match.Config{MaxPlies: test.maxPlies})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record.Result != test.result || record.Reason != test.reason {
+				t.Errorf("got %s (%s), want %s (%s)", record.Result, record.Reason, test.result, test.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package test
+
+import (
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/network"
+)
+
+// messageTimeout bounds the wait for any expected message
+const messageTimeout = 5 * time.Second
+
+var blitz = network.TimeControl{Base: 5 * time.Minute, Increment: 2 * time.Second}
+
+// startServer runs a server on a local port and returns its address
+func startServer(t *testing.T, cfg network.ServerConfig) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := network.NewServer(cfg)
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return listener.Addr().String()
+}
+
+func dial(t *testing.T, address, name string) *network.Client {
+	t.Helper()
+	client, err := network.Dial(address, name)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// expect skips messages until one of the given type arrives
+func expect(t *testing.T, client *network.Client, msgType string) network.Message {
+	t.Helper()
+	timeout := time.After(messageTimeout)
+	for {
+		select {
+		case msg, ok := <-client.Messages():
+			if !ok {
+				t.Fatalf("%s: connection closed waiting for %q", client.Name(), msgType)
+			}
+			if msg.Type == msgType {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("%s: timed out waiting for %q", client.Name(), msgType)
+		}
+	}
+}
+
+// expectLobby waits for a lobby that satisfies ok
+func expectLobby(t *testing.T, client *network.Client, ok func(network.Message) bool) network.Message {
+	t.Helper()
+	for {
+		if msg := expect(t, client, network.MsgLobby); ok(msg) {
+			return msg
+		}
+	}
+}
+
+// startGame has seeker post a seek that the other client accepts, and
+// returns both players' game messages
+func startGame(t *testing.T, seeker, accepter *network.Client, seek network.Seek) (network.Message, network.Message) {
+	t.Helper()
+	if err := seeker.Seek(seek); err != nil {
+		t.Fatal(err)
+	}
+	lobby := expectLobby(t, accepter, func(msg network.Message) bool { return len(msg.Seeks) > 0 })
+	if err := accepter.Accept(lobby.Seeks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	return expect(t, seeker, network.MsgGame), expect(t, accepter, network.MsgGame)
+}
+
+// byColor returns the white and black clients of a game
+func byColor(first, second *network.Client, firstGame network.Message) (*network.Client, *network.Client) {
+	if firstGame.Color == network.White {
+		return first, second
+	}
+	return second, first
+}
+
+// play makes the moves alternately and checks both players see each one
+func play(t *testing.T, white, black *network.Client, gameID int, moves ...string) {
+	t.Helper()
+	for i, move := range moves {
+		mover := white
+		if i%2 == 1 {
+			mover = black
+		}
+		if err := mover.Move(gameID, move); err != nil {
+			t.Fatal(err)
+		}
+		for _, client := range []*network.Client{white, black} {
+			if msg := expect(t, client, network.MsgMove); msg.Move != move {
+				t.Fatalf("%s saw %q, expected %q", client.Name(), msg.Move, move)
+			}
+		}
+	}
+}
+
+func TestNetworkGameToCheckmate(t *testing.T) {
+	address := startServer(t, network.DefaultServerConfig())
+	alice := dial(t, address, "alice")
+	bob := dial(t, address, "bob")
+
+	aliceGame, bobGame := startGame(t, alice, bob, network.Seek{TimeControl: blitz, Color: network.White})
+	if aliceGame.Color != network.White || bobGame.Color != network.Black {
+		t.Fatalf("colors %q and %q, expected alice white", aliceGame.Color, bobGame.Color)
+	}
+	if aliceGame.White != "alice" || aliceGame.Black != "bob" || aliceGame.Game != bobGame.Game {
+		t.Fatalf("games do not match: %+v %+v", aliceGame, bobGame)
+	}
+
+	play(t, alice, bob, aliceGame.Game, "f2f3", "e7e5", "g2g4", "d8h4")
+
+	for _, client := range []*network.Client{alice, bob} {
+		end := expect(t, client, network.MsgEnd)
+		if end.Result != "0-1" || end.Termination != "checkmate" {
+			t.Errorf("%s: got %s by %s, expected 0-1 by checkmate", client.Name(), end.Result, end.Termination)
+		}
+	}
+}
+
+func TestNetworkRejectsIllegalMoves(t *testing.T) {
+	address := startServer(t, network.DefaultServerConfig())
+	alice := dial(t, address, "alice")
+	bob := dial(t, address, "bob")
+
+	aliceGame, _ := startGame(t, alice, bob, network.Seek{TimeControl: blitz})
+	white, black := byColor(alice, bob, aliceGame)
+
+	for _, tc := range []struct {
+		client *network.Client
+		move   string
+	}{
+		{black, "e7e5"}, // not black's turn
+		{white, "e2e5"},
+		{white, "e1e2"}, // own pawn in the way
+		{white, "junk"},
+	} {
+		if err := tc.client.Move(aliceGame.Game, tc.move); err != nil {
+			t.Fatal(err)
+		}
+		if msg := expect(t, tc.client, network.MsgError); msg.Game != aliceGame.Game {
+			t.Errorf("%s: error for game %d, expected %d", tc.move, msg.Game, aliceGame.Game)
+		}
+		// The server resends the game so the client can resynchronize
+		if msg := expect(t, tc.client, network.MsgGame); len(msg.Moves) != 0 {
+			t.Errorf("%s: game has moves %v", tc.move, msg.Moves)
+		}
+	}
+
+	play(t, white, black, aliceGame.Game, "e2e4")
+}
+
+func TestNetworkChallenge(t *testing.T) {
+	address := startServer(t, network.DefaultServerConfig())
+	alice := dial(t, address, "alice")
+	bob := dial(t, address, "bob")
+	carol := dial(t, address, "carol")
+
+	expectLobby(t, alice, func(msg network.Message) bool { return len(msg.Players) == 3 })
+	if err := alice.Seek(network.Seek{Opponent: "bob", Variant: "king of the hill", TimeControl: blitz}); err != nil {
+		t.Fatal(err)
+	}
+	challenge := expect(t, bob, network.MsgChallenge)
+	if challenge.Seek.Player != "alice" || challenge.Seek.Variant != "King of the Hill" {
+		t.Fatalf("unexpected challenge %+v", challenge.Seek)
+	}
+
+	// Nobody else sees or can accept the challenge
+	expectLobby(t, carol, func(msg network.Message) bool { return len(msg.Seeks) == 0 })
+	carol.Accept(challenge.Seek.ID)
+	expect(t, carol, network.MsgError)
+
+	if err := bob.Decline(challenge.Seek.ID); err != nil {
+		t.Fatal(err)
+	}
+	if msg := expect(t, alice, network.MsgDecline); msg.Name != "bob" {
+		t.Fatalf("decline from %q", msg.Name)
+	}
+
+	alice.Seek(network.Seek{Opponent: "bob", Variant: "Crazyhouse", TimeControl: blitz})
+	challenge = expect(t, bob, network.MsgChallenge)
+	bob.Accept(challenge.Seek.ID)
+	aliceGame := expect(t, alice, network.MsgGame)
+	expect(t, bob, network.MsgGame)
+	if aliceGame.Variant != "Crazyhouse" {
+		t.Fatalf("variant %q, expected Crazyhouse", aliceGame.Variant)
+	}
+
+	white, black := byColor(alice, bob, aliceGame)
+	play(t, white, black, aliceGame.Game, "e2e4", "d7d5", "e4d5", "d8d5", "P@e4")
+}
+
+func TestNetworkTimeForfeit(t *testing.T) {
+	address := startServer(t, network.DefaultServerConfig())
+	alice := dial(t, address, "alice")
+	bob := dial(t, address, "bob")
+
+	fast := network.TimeControl{Base: 300 * time.Millisecond}
+	aliceGame, _ := startGame(t, alice, bob, network.Seek{TimeControl: fast, Color: network.White})
+	play(t, alice, bob, aliceGame.Game, "e2e4")
+
+	// Black never answers
+	for _, client := range []*network.Client{alice, bob} {
+		end := expect(t, client, network.MsgEnd)
+		if end.Result != "1-0" || end.Termination != "time forfeit" || end.BlackClock != 0 {
+			t.Errorf("%s: got %s by %s with black clock %v", client.Name(), end.Result, end.Termination, end.BlackClock)
+		}
+	}
+}
+
+func TestNetworkReconnectResumesGame(t *testing.T) {
+	address := startServer(t, network.DefaultServerConfig())
+	proxy := startProxy(t, address)
+	alice := dial(t, proxy.address, "alice")
+	bob := dial(t, address, "bob")
+
+	aliceGame, _ := startGame(t, alice, bob, network.Seek{TimeControl: blitz, Color: network.White})
+	play(t, alice, bob, aliceGame.Game, "e2e4", "e7e5")
+
+	proxy.cut()
+	expect(t, alice, network.MsgDisconnected)
+	if msg := expect(t, bob, network.MsgOpponent); msg.Online {
+		t.Fatal("opponent reported online after disconnecting")
+	}
+
+	expect(t, alice, network.MsgReconnected)
+	resumed := expect(t, alice, network.MsgGame)
+	if resumed.Game != aliceGame.Game || strings.Join(resumed.Moves, " ") != "e2e4 e7e5" {
+		t.Fatalf("resumed game %d with moves %v", resumed.Game, resumed.Moves)
+	}
+	if msg := expect(t, bob, network.MsgOpponent); !msg.Online {
+		t.Fatal("opponent not reported online after reconnecting")
+	}
+
+	position, err := network.ReplayGame(resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if position.FEN() != "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2" {
+		t.Fatalf("resumed position %s", position.FEN())
+	}
+	if position.WhitePlayerTime <= 0 || position.WhitePlayerTime > blitz.Base+blitz.Increment {
+		t.Fatalf("resumed white clock %v", position.WhitePlayerTime)
+	}
+
+	play(t, alice, bob, aliceGame.Game, "g1f3", "b8c6")
+}
+
+func TestNetworkSpectators(t *testing.T) {
+	address := startServer(t, network.DefaultServerConfig())
+	proxy := startProxy(t, address)
+	alice := dial(t, address, "alice")
+	bob := dial(t, address, "bob")
+	carol := dial(t, proxy.address, "carol")
+	dave := dial(t, address, "dave")
+
+	aliceGame, _ := startGame(t, alice, bob, network.Seek{TimeControl: blitz, Color: network.White})
+	play(t, alice, bob, aliceGame.Game, "e2e4", "c7c5")
+
+	// Spectators joining mid-game get the whole history
+	lobby := expectLobby(t, carol, func(msg network.Message) bool { return len(msg.Games) == 1 })
+	if info := lobby.Games[0]; info.ID != aliceGame.Game || info.White != "alice" || info.Black != "bob" {
+		t.Fatalf("lobby game %+v", info)
+	}
+	for _, spectator := range []*network.Client{carol, dave} {
+		if err := spectator.Watch(aliceGame.Game); err != nil {
+			t.Fatal(err)
+		}
+		watched := expect(t, spectator, network.MsgGame)
+		if watched.Color != "" || strings.Join(watched.Moves, " ") != "e2e4 c7c5" {
+			t.Fatalf("%s watches as %q with moves %v", spectator.Name(), watched.Color, watched.Moves)
+		}
+	}
+	expectLobby(t, alice, func(msg network.Message) bool {
+		return len(msg.Games) == 1 && msg.Games[0].Spectators == 2
+	})
+
+	// Spectators cannot play
+	carol.Move(aliceGame.Game, "g1f3")
+	expect(t, carol, network.MsgError)
+
+	alice.Move(aliceGame.Game, "g1f3")
+	for _, spectator := range []*network.Client{carol, dave} {
+		msg := expect(t, spectator, network.MsgMove)
+		if msg.Move != "g1f3" || msg.Ply != 3 || msg.WhiteClock <= 0 || msg.BlackClock <= 0 {
+			t.Fatalf("%s saw %+v", spectator.Name(), msg)
+		}
+	}
+
+	// A spectator who loses the connection keeps watching
+	proxy.cut()
+	expect(t, carol, network.MsgReconnected)
+	if resumed := expect(t, carol, network.MsgGame); len(resumed.Moves) != 3 {
+		t.Fatalf("resumed watching with moves %v", resumed.Moves)
+	}
+
+	bob.Move(aliceGame.Game, "d7d6")
+	if msg := expect(t, carol, network.MsgMove); msg.Move != "d7d6" {
+		t.Fatalf("carol saw %q after reconnecting", msg.Move)
+	}
+
+	if err := dave.Unwatch(aliceGame.Game); err != nil {
+		t.Fatal(err)
+	}
+	expectLobby(t, alice, func(msg network.Message) bool {
+		return len(msg.Games) == 1 && msg.Games[0].Spectators == 1
+	})
+
+	alice.Resign(aliceGame.Game)
+	if end := expect(t, carol, network.MsgEnd); end.Result != "0-1" || end.Termination != "resignation" {
+		t.Fatalf("carol saw %s by %s", end.Result, end.Termination)
+	}
+}
+
+func TestNetworkAbandonment(t *testing.T) {
+	cfg := network.DefaultServerConfig()
+	cfg.AbandonTimeout = 200 * time.Millisecond
+	address := startServer(t, cfg)
+	alice := dial(t, address, "alice")
+	bob := dial(t, address, "bob")
+
+	aliceGame, _ := startGame(t, alice, bob, network.Seek{TimeControl: blitz, Color: network.Black})
+	alice.Close()
+
+	end := expect(t, bob, network.MsgEnd)
+	if end.Game != aliceGame.Game || end.Result != "1-0" || end.Termination != "abandonment" {
+		t.Fatalf("got %s by %s, expected 1-0 by abandonment", end.Result, end.Termination)
+	}
+
+	// The name is free again once alice has no games left
+	dial(t, address, "alice")
+}
+
+func TestNetworkNameTaken(t *testing.T) {
+	address := startServer(t, network.DefaultServerConfig())
+	dial(t, address, "alice")
+	if _, err := network.Dial(address, "alice"); err == nil {
+		t.Fatal("second client logged in as alice")
+	}
+}
+
+func TestNetworkWebSocket(t *testing.T) {
+	server := network.NewServer(network.DefaultServerConfig())
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		server.Close()
+		httpServer.Close()
+	})
+	address := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	alice := dial(t, address, "alice")
+	bob := dial(t, startServerOn(t, server), "bob")
+
+	aliceGame, _ := startGame(t, alice, bob, network.Seek{TimeControl: blitz, Variant: "Chess960"})
+	if aliceGame.Variant != "Chess960" || aliceGame.FEN == "" {
+		t.Fatalf("Chess960 game %q from %q", aliceGame.Variant, aliceGame.FEN)
+	}
+	white, black := byColor(alice, bob, aliceGame)
+	play(t, white, black, aliceGame.Game, "a2a3", "h7h6")
+}
+
+// startServerOn serves TCP clients from an existing server, so that TCP and
+// WebSocket clients meet in the same lobby
+func startServerOn(t *testing.T, server *network.Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	return listener.Addr().String()
+}
+
+// proxy forwards TCP connections to a server and can cut them all, as a
+// network failure would
+type proxy struct {
+	address string
+	mu      sync.Mutex
+	conns   []net.Conn
+}
+
+func startProxy(t *testing.T, target string) *proxy {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &proxy{address: listener.Addr().String()}
+	t.Cleanup(func() {
+		listener.Close()
+		p.cut()
+	})
+
+	go func() {
+		for {
+			client, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server, err := net.Dial("tcp", target)
+			if err != nil {
+				client.Close()
+				continue
+			}
+			p.mu.Lock()
+			p.conns = append(p.conns, client, server)
+			p.mu.Unlock()
+			go io.Copy(server, client)
+			go io.Copy(client, server)
+		}
+	}()
+	return p
+}
+
+func (p *proxy) cut() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.conns {
+		conn.Close()
+	}
+	p.conns = nil
+}
@@ -0,0 +1,47 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		name        string
+		variant     game.Variant
+		fen         string
+		repetitions int
+		result      string
+		termination string
+	}{
+		{"in progress", nil, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 2, "", ""},
+		{"checkmate", nil, "k7/1Q6/1K6/8/8/8/8/8 b - - 0 1", 1, "1-0", "checkmate"},
+		{"stalemate", nil, "k7/2Q5/1K6/8/8/8/8/8 b - - 0 1", 1, "1/2-1/2", "stalemate"},
+		{"variant win", game.KingOfTheHill{}, "k7/8/8/8/4K3/8/8/8 b - - 0 1", 1, "1-0", "king of the hill"},
+		{"threefold repetition", nil, "k7/8/8/8/8/8/8/R6K w - - 0 1", 3, "1/2-1/2", "threefold repetition"},
+		{"fifty-move rule", nil, "k7/8/8/8/8/8/8/R6K w - - 100 80", 1, "1/2-1/2", "fifty-move rule"},
+		{"insufficient material", nil, "k7/8/8/8/8/8/8/N6K w - - 0 1", 1, "1/2-1/2", "insufficient material"},
+		// Bare kings are not a draw when the rules change
+		{"bare kings in a variant", game.KingOfTheHill{}, "k7/8/8/8/8/8/8/7K w - - 0 1", 1, "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := variantGame(t, test.variant, test.fen)
+			result, termination, over := g.Outcome(map[string]int{g.PositionKey(): test.repetitions})
+			if result != test.result || termination != test.termination || over != (test.result != "") {
+				t.Errorf("%q %q %v, want %q %q", result, termination, over, test.result, test.termination)
+			}
+		})
+	}
+
+	// A game decided by a move keeps its result and termination
+	g := variantGame(t, game.ThreeCheck{}, "4k3/8/8/8/8/8/8/R3K3 w - - 0 1")
+	playUCI(t, g, "a1a8", "e8e7", "a8a7", "e7e6", "a7a6")
+	if result, termination, over := g.Outcome(nil); result != "1-0" || termination != "three checks" || !over {
+		t.Errorf("three checks: %q %q %v", result, termination, over)
+	}
+	if game.WinResult(game.WhitePlayer) != "1-0" || game.WinResult(game.BlackPlayer) != "0-1" {
+		t.Error("WinResult")
+	}
+}
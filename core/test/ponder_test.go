@@ -0,0 +1,124 @@
+package test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// ponderLevel searches long enough that reusing a ponder search shows
+const ponderLevel = 10
+
+// startPonder lets an AI playing black answer 1. e4 and returns it
+// pondering on White's reply, with the number of goroutines before it began
+func startPonder(t *testing.T) (*ai.ChessAI, *game.GameState, int) {
+	t.Helper()
+	g := playSAN(t, "e4")
+	goroutines := runtime.NumGoroutine()
+	engine := ai.NewChessAI(g, game.WhitePlayer, ponderLevel)
+	engine.SetPondering(true)
+	if !engine.MakeMove() {
+		t.Fatal("the AI did not answer 1. e4")
+	}
+	return engine, g, goroutines
+}
+
+// waitPonderMove waits for the AI to predict the opponent's reply
+func waitPonderMove(t *testing.T, engine *ai.ChessAI) ai.Move {
+	t.Helper()
+	for deadline := time.Now().Add(30 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if reply, ok := engine.PonderMove(); ok {
+			return reply
+		}
+	}
+	t.Fatal("the AI predicts no reply")
+	return ai.Move{}
+}
+
+// waitGoroutines waits for the number of goroutines to fall back to want
+func waitGoroutines(t *testing.T, want int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if runtime.NumGoroutine() <= want {
+			return
+		}
+	}
+	t.Errorf("%d goroutines still running, want %d", runtime.NumGoroutine(), want)
+}
+
+// aiMoves lets the AI move and returns how long it took
+func aiMoves(t *testing.T, engine *ai.ChessAI, g *game.GameState) time.Duration {
+	t.Helper()
+	plies := len(g.MoveHistory)
+	start := time.Now()
+	if !engine.MakeMove() || len(g.MoveHistory) != plies+1 {
+		t.Fatal("the AI did not move")
+	}
+	return time.Since(start)
+}
+
+func TestPonderHit(t *testing.T) {
+	engine, g, goroutines := startPonder(t)
+	defer engine.StopPondering()
+	reply := waitPonderMove(t, engine)
+
+	// Time searches of the predicted position without pondering. The first
+	// gives the ponder search the time to finish, the second runs alone.
+	var fresh time.Duration
+	for i := 0; i < 2; i++ {
+		position := g.Clone()
+		if position.MakeMoveWithPromotion(reply.From, reply.To, reply.Promotion) == game.InvalidMove {
+			t.Fatalf("predicted an illegal reply %v-%v", reply.From, reply.To)
+		}
+		fresh = aiMoves(t, ai.NewChessAI(position, game.WhitePlayer, ponderLevel), position)
+	}
+
+	// Playing the predicted reply reuses the finished search
+	g.MakeMoveWithPromotion(reply.From, reply.To, reply.Promotion)
+	if hit := aiMoves(t, engine, g); hit > fresh/2 {
+		t.Errorf("a ponder hit took %v, a search %v", hit, fresh)
+	}
+	engine.StopPondering()
+	waitGoroutines(t, goroutines)
+}
+
+func TestPonderMiss(t *testing.T) {
+	engine, g, goroutines := startPonder(t)
+	defer engine.StopPondering()
+	reply := waitPonderMove(t, engine)
+
+	// Any other reply cancels the ponder search and the AI searches afresh
+	for _, san := range []string{"Nf3", "Nc3"} {
+		from, to, promotion, err := g.ParseSAN(san)
+		if err != nil {
+			t.Fatalf("%s: %v", san, err)
+		}
+		if from == reply.From && to == reply.To {
+			continue
+		}
+		g.MakeMoveWithPromotion(from, to, promotion)
+		break
+	}
+	aiMoves(t, engine, g)
+	engine.SetPondering(false)
+	if _, ok := engine.PonderMove(); ok {
+		t.Error("the AI ponders after pondering was turned off")
+	}
+	waitGoroutines(t, goroutines)
+}
+
+func TestStopPondering(t *testing.T) {
+	engine, _, goroutines := startPonder(t)
+	engine.StopPondering()
+	if _, ok := engine.PonderMove(); ok {
+		t.Error("the AI ponders after StopPondering")
+	}
+	waitGoroutines(t, goroutines)
+
+	// Stopping again is harmless
+	engine.StopPondering()
+	engine.SetPondering(false)
+}
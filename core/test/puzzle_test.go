@@ -0,0 +1,94 @@
+package test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/puzzle"
+)
+
+const startEPD = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -"
+
+func TestReadEPDPuzzles(t *testing.T) {
+	puzzles, err := puzzle.ReadEPD(strings.NewReader(strings.Join([]string{
+		// bm lists alternatives, not a line
+		startEPD + ` bm e4 d4 Nf3; id "alternatives";`,
+		startEPD + ` bm e4; pv e4 e5 Nf3; id "line"; c0 "1500";`,
+	}, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(puzzles) != 2 {
+		t.Fatalf("read %d puzzles, want 2", len(puzzles))
+	}
+
+	alternatives := puzzles[0]
+	if !reflect.DeepEqual(alternatives.Solution, []string{"e2e4"}) ||
+		!reflect.DeepEqual(alternatives.Alternatives, []string{"d2d4", "g1f3"}) {
+		t.Errorf("bm puzzle: solution %v, alternatives %v", alternatives.Solution, alternatives.Alternatives)
+	}
+
+	line := puzzles[1]
+	if line.ID != "line" || line.Rating != 1500 || !reflect.DeepEqual(line.Solution, []string{"e2e4", "e7e5", "g1f3"}) {
+		t.Errorf("pv puzzle: %+v", line)
+	}
+	if len(line.Alternatives) != 0 {
+		t.Errorf("pv puzzle has alternatives %v", line.Alternatives)
+	}
+}
+
+func TestPuzzleAlternatives(t *testing.T) {
+	p := &puzzle.Puzzle{ID: "alternatives", FEN: startEPD + " 0 1", Solution: []string{"e2e4"}, Alternatives: []string{"d2d4"}}
+	tests := []struct {
+		move    string
+		outcome puzzle.Outcome
+	}{
+		{"e2e4", puzzle.Solved},
+		{"d2d4", puzzle.Solved},
+		{"g1f3", puzzle.Wrong},
+	}
+	for _, test := range tests {
+		attempt, err := puzzle.NewAttempt(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outcome, err := attempt.Play(test.move)
+		if err != nil || outcome != test.outcome {
+			t.Errorf("%s: outcome %v (%v), want %v", test.move, outcome, err, test.outcome)
+		}
+	}
+}
+
+func TestPuzzleRejectsIllegalMoves(t *testing.T) {
+	p := &puzzle.Puzzle{ID: "illegal", FEN: startEPD + " 0 1", Solution: []string{"e2e4"}}
+	attempt, err := puzzle.NewAttempt(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, move := range []string{"e2e5", "e7e5", "e1e2", "x"} {
+		if _, err := attempt.Play(move); err == nil {
+			t.Errorf("%s was accepted", move)
+		}
+	}
+	if attempt.Failed || len(attempt.Game.MoveHistory) != 0 {
+		t.Fatal("an illegal move counted as an attempt")
+	}
+	if outcome, err := attempt.Play("e2e4"); err != nil || outcome != puzzle.Solved {
+		t.Errorf("e2e4: outcome %v (%v)", outcome, err)
+	}
+}
+
+func TestPuzzleAcceptsAnotherMate(t *testing.T) {
+	// Back rank mate on a8 or, as well, with the rook on b8
+	p := &puzzle.Puzzle{ID: "mate", FEN: "6k1/5ppp/8/8/8/8/8/RR4K1 w - - 0 1", Solution: []string{"a1a8"}}
+	for move, outcome := range map[string]puzzle.Outcome{"a1a8": puzzle.Solved, "b1b8": puzzle.Solved, "a1a7": puzzle.Wrong} {
+		attempt, err := puzzle.NewAttempt(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := attempt.Play(move); err != nil || got != outcome {
+			t.Errorf("%s: outcome %v (%v), want %v", move, got, err, outcome)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/game"
+	"github.com/h3bzzz/go-chess/core/savegame"
+)
+
+func TestSaveGameRoundTrip(t *testing.T) {
+	g, err := game.NewVariantGame(game.Crazyhouse{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, move := range []string{"e2e4", "d7d5", "e4d5", "d8d5", "P@e4"} {
+		from, to, promotion, err := g.ParseUCI(move)
+		if err != nil || g.MakeMoveWithPromotion(from, to, promotion) == game.InvalidMove {
+			t.Fatalf("%s: %v", move, err)
+		}
+	}
+	g.TimerActive = false
+	g.WhitePlayerTime = 4 * time.Minute
+	g.BlackPlayerTime = 3 * time.Minute
+
+	save := savegame.FromGame(g)
+	save.AI = savegame.AISettings{Enabled: true, Color: "white", Skill: 12}
+	save.Theme = "Green"
+	path := filepath.Join(t.TempDir(), "saves", "game.json")
+	if err := save.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := savegame.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Resumable() || loaded.AI != save.AI || loaded.Theme != "Green" {
+		t.Fatalf("loaded save = %+v", loaded)
+	}
+	resumed, err := loaded.Game()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.FEN() != g.FEN() || len(resumed.MoveHistory) != 5 {
+		t.Fatalf("resumed at %s after %d moves, want %s", resumed.FEN(), len(resumed.MoveHistory), g.FEN())
+	}
+	if resumed.Variant == nil || resumed.Variant.Name() != "Crazyhouse" {
+		t.Fatal("resumed game lost its variant")
+	}
+	if resumed.WhitePlayerTime != 4*time.Minute || resumed.BlackPlayerTime != 3*time.Minute {
+		t.Fatalf("clocks = %v, %v", resumed.WhitePlayerTime, resumed.BlackPlayerTime)
+	}
+	// The history is replayable, so the resumed game can be taken back
+	if !resumed.UndoLastMove() || resumed.Pockets[game.WhitePlayer][0] != 1 {
+		t.Fatal("undoing the drop did not return the pawn to the pocket")
+	}
+}
+
+func TestSaveGameRejectsNewerVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "variant": "Standard"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := savegame.Load(path); err == nil || !strings.Contains(err.Error(), "newer version") {
+		t.Fatalf("loading a newer save: %v", err)
+	}
+
+	save := &savegame.Save{Version: savegame.Version, Moves: []string{"e2e4", "e7e5"}, FEN: game.StandardFEN}
+	if _, err := save.Game(); err == nil {
+		t.Fatal("a save whose moves do not reach its position was accepted")
+	}
+}
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// benchmarkFEN is a quiet middlegame with plenty of moves for both sides
+const benchmarkFEN = "r1bq1rk1/pp2bppp/2n1pn2/3p4/2PP4/2N1PN2/PP2BPPP/R2QKB1R w KQ - 0 8"
+
+var benchmarkThreads = []int{1, 2, 4, 8}
+
+// BenchmarkSearchThreads searches for a fixed time and reports the depth
+// reached and the node rate for each thread count
+func BenchmarkSearchThreads(b *testing.B) {
+	position, err := game.NewGameFromFEN(benchmarkFEN)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, threads := range benchmarkThreads {
+		b.Run(fmt.Sprintf("threads=%d", threads), func(b *testing.B) {
+			limits := ai.SearchLimits{MoveTime: 500 * time.Millisecond, Threads: threads}
+			depth, nodes := 0, 0
+			for i := 0; i < b.N; i++ {
+				result := ai.SearchWithLimits(position, limits)
+				depth += result.Depth
+				nodes += result.Nodes
+			}
+			b.ReportMetric(float64(depth)/float64(b.N), "depth")
+			b.ReportMetric(float64(nodes)/b.Elapsed().Seconds(), "nodes/s")
+		})
+	}
+}
+
+// BenchmarkTimeToDepth measures how long each thread count takes to finish
+// a fixed depth search
+func BenchmarkTimeToDepth(b *testing.B) {
+	position, err := game.NewGameFromFEN(benchmarkFEN)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, threads := range benchmarkThreads {
+		b.Run(fmt.Sprintf("threads=%d", threads), func(b *testing.B) {
+			limits := ai.SearchLimits{Depth: 4, Threads: threads}
+			for i := 0; i < b.N; i++ {
+				ai.SearchWithLimits(position, limits)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// TestSkillPicksBestMoveAtFullStrength picks from the same searched position
+// many times with a fixed seed. Level 20 always plays the best move; level 1 varies
+// but never gives away more than a pawn.
+func TestSkillPicksBestMoveAtFullStrength(t *testing.T) {
+	g := playSAN(t, "e4 e5 Nf3 Nc6 Bc4")
+	moves := ai.SearchRootMoves(g, ai.SearchLimits{Depth: 2})
+	if len(moves) == 0 {
+		t.Fatal("no moves")
+	}
+	best := moves[0]
+
+	for _, level := range []int{ai.MinSkillLevel, ai.MaxSkillLevel} {
+		skill := ai.NewSkill(level)
+
+		random := rand.New(rand.NewSource(1))
+		others := 0
+		for i := 0; i < 100; i++ {
+			move := skill.PickMove(moves, random)
+			if move.From != best.From || move.To != best.To {
+				others++
+			}
+			if best.Score-move.Score > ai.PieceValues[game.WhitePawn] {
+				t.Errorf("level %d: picked a move %d worse than the best", level, best.Score-move.Score)
+			}
+		}
+
+		switch {
+		case level == ai.MaxSkillLevel && others != 0:
+			t.Errorf("level 20 played another move %d times", others)
+		case level == ai.MinSkillLevel && others == 0:
+			t.Error("level 1 always played the best move")
+		}
+	}
+}
+
+// TestSkillStrengthRisesWithLevel picks from the same root moves at levels
+// five apart and expects the average loss to shrink as the level rises
+func TestSkillStrengthRisesWithLevel(t *testing.T) {
+	moves := []ai.Move{{Score: 30}, {Score: 20}, {Score: 0}, {Score: -200}}
+	for i := range moves {
+		moves[i].To.X = i
+	}
+
+	previous, previousLevel := -1, 0
+	for _, level := range []int{1, 5, 10, 15, 20} {
+		skill := ai.NewSkill(level)
+		random := rand.New(rand.NewSource(1))
+		loss := 0
+		for i := 0; i < 10000; i++ {
+			move := skill.PickMove(moves, random)
+			if move.Score < -100 {
+				t.Fatalf("level %d picked the blunder", level)
+			}
+			loss += moves[0].Score - move.Score
+		}
+		if previous >= 0 && loss > previous {
+			t.Errorf("level %d loses %d centipawns over 10000 moves, more than level %d (%d)", level, loss, previousLevel, previous)
+		}
+		previous, previousLevel = loss, level
+	}
+	if previous != 0 {
+		t.Errorf("level 20 lost %d centipawns", previous)
+	}
+}
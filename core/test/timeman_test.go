@@ -0,0 +1,129 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// simulatedLatency is the delay added to every move on top of the thinking time
+const simulatedLatency = 10 * time.Millisecond
+
+var timeControls = []struct {
+	name      string
+	base      time.Duration
+	increment time.Duration
+	moves     int
+}{
+	{"bullet 1+0", time.Minute, 0, 0},
+	{"bullet 2+1", 2 * time.Minute, time.Second, 0},
+	{"blitz 3+2", 3 * time.Minute, 2 * time.Second, 0},
+	{"blitz 5+0", 5 * time.Minute, 0, 0},
+	{"classical 40/90+30", 90 * time.Minute, 30 * time.Second, 40},
+	{"classical 120+0", 120 * time.Minute, 0, 0},
+}
+
+// TestAllocateTimeNeverFlags plays out long games on paper, using the soft
+// budget on most moves and the whole hard budget on every fourth one
+func TestAllocateTimeNeverFlags(t *testing.T) {
+	for _, tc := range timeControls {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := ai.TimeControl{Remaining: tc.base, Increment: tc.increment, MovesToGo: tc.moves}
+
+			for move := 1; move <= 150; move++ {
+				budget := ai.AllocateTime(clock)
+				if budget.Soft <= 0 || budget.Soft > budget.Hard {
+					t.Fatalf("move %d: invalid budget %+v", move, budget)
+				}
+				if budget.Hard >= clock.Remaining {
+					t.Fatalf("move %d: hard budget %v exceeds remaining %v", move, budget.Hard, clock.Remaining)
+				}
+
+				used := budget.Soft
+				if move%4 == 0 {
+					used = budget.Hard
+				}
+				clock.Remaining -= used + simulatedLatency
+				if clock.Remaining <= 0 {
+					t.Fatalf("flagged on move %d", move)
+				}
+				clock.Remaining += clock.Increment
+
+				if tc.moves > 0 {
+					clock.MovesToGo--
+					if clock.MovesToGo == 0 {
+						clock.Remaining += tc.base
+						clock.MovesToGo = tc.moves
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateTimeUsesIncrement(t *testing.T) {
+	without := ai.AllocateTime(ai.TimeControl{Remaining: 3 * time.Minute})
+	with := ai.AllocateTime(ai.TimeControl{Remaining: 3 * time.Minute, Increment: 2 * time.Second})
+	if with.Soft <= without.Soft {
+		t.Errorf("increment did not add time: %v without, %v with", without.Soft, with.Soft)
+	}
+}
+
+func TestAllocateTimeLastMoveBeforeControl(t *testing.T) {
+	clock := ai.TimeControl{Remaining: 10 * time.Second, MovesToGo: 1}
+	budget := ai.AllocateTime(clock)
+	if budget.Hard >= clock.Remaining {
+		t.Errorf("hard budget %v leaves no time on a %v clock", budget.Hard, clock.Remaining)
+	}
+	if budget.Soft < clock.Remaining/2 {
+		t.Errorf("soft budget %v wastes the last move before the control", budget.Soft)
+	}
+}
+
+// TestSearchBulletClock lets the engine play itself with a real clock
+func TestSearchBulletClock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("plays a timed game")
+	}
+
+	gameState := game.NewGame()
+	gameState.TimerActive = false
+	clocks := [2]time.Duration{3 * time.Second, 3 * time.Second}
+	const increment = 50 * time.Millisecond
+
+	for ply := 0; ply < 40 && !gameState.IsGameOver(); ply++ {
+		side := gameState.CurrentTurn
+		clock := ai.TimeControl{Remaining: clocks[side], Increment: increment}
+
+		start := time.Now()
+		result := ai.SearchWithLimits(gameState, ai.SearchLimits{Clock: &clock})
+		clocks[side] -= time.Since(start)
+		if clocks[side] <= 0 {
+			t.Fatalf("side %d flagged at ply %d", side, ply)
+		}
+		clocks[side] += increment
+
+		gameState.MakeMoveWithPromotion(result.Move.From, result.Move.To, result.Move.Promotion)
+	}
+}
+
+// TestAllocateTimeWithoutTime checks a clock that is used up, as happens
+// when the thinking time is taken off before the move is made, still
+// leaves time to move
+func TestAllocateTimeWithoutTime(t *testing.T) {
+	for _, remaining := range []time.Duration{-2 * time.Second, -time.Millisecond, 0, time.Millisecond, 40 * time.Millisecond} {
+		budget := ai.AllocateTime(ai.TimeControl{Remaining: remaining})
+		if budget.Soft < time.Millisecond || budget.Hard < budget.Soft {
+			t.Errorf("remaining %v: invalid budget %+v", remaining, budget)
+		}
+	}
+
+	gameState := game.NewGame()
+	gameState.TimerActive = false
+	clock := ai.TimeControl{Remaining: -time.Second}
+	if moves := ai.SearchRootMoves(gameState, ai.SearchLimits{Clock: &clock}); len(moves) == 0 {
+		t.Error("no move found with the clock used up")
+	}
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+)
+
+func writeTuningFile(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "positions.epd")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadTuningPositions(t *testing.T) {
+	const start = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq -"
+	path := writeTuningFile(t,
+		"# comments and blank lines are skipped",
+		"",
+		start+" 1-0",
+		// The move counters must not be taken for the result
+		start+` 0 1 "1-0"`,
+		start+" 1 0 0-1",
+		start+" 0 1 [0.5]",
+		start+` c9 "1/2-1/2";`,
+		start+` 0 1 c9 "0"; id "bare result";`,
+		start+" 1",
+	)
+	positions, err := ai.LoadTuningPositions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []float64{1, 1, 0, 0.5, 0.5, 0, 1}
+	if len(positions) != len(want) {
+		t.Fatalf("loaded %d positions, want %d", len(positions), len(want))
+	}
+	for i, position := range positions {
+		if position.Result != want[i] {
+			t.Errorf("line %d: result %v, want %v", i+1, position.Result, want[i])
+		}
+		if position.Board != positions[0].Board {
+			t.Errorf("line %d: board differs", i+1)
+		}
+	}
+}
+
+func TestLoadTuningPositionsWithoutResult(t *testing.T) {
+	// A full FEN alone ends in move counters, not a result
+	path := writeTuningFile(t, "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1")
+	if _, err := ai.LoadTuningPositions(path); err == nil {
+		t.Error("a line without a result was accepted")
+	}
+}
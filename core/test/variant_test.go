@@ -0,0 +1,153 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/h3bzzz/go-chess/core/ai"
+	"github.com/h3bzzz/go-chess/core/game"
+)
+
+// variantGame sets up a variant game from a FEN
+func variantGame(t *testing.T, variant game.Variant, fen string) *game.GameState {
+	t.Helper()
+	g, err := game.NewGameFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetVariant(variant)
+	g.TimerActive = false
+	return g
+}
+
+// playUCI plays moves in UCI notation, failing on an illegal one
+func playUCI(t *testing.T, g *game.GameState, moves ...string) {
+	t.Helper()
+	for _, move := range moves {
+		from, to, promotion, err := g.ParseUCI(move)
+		if err != nil || !isLegal(g, from, to) {
+			t.Fatalf("%s is not legal in %s", move, g.FEN())
+		}
+		g.MakeMoveWithPromotion(from, to, promotion)
+	}
+}
+
+// undoTo takes back the last move and checks the position and status are
+// restored
+func undoTo(t *testing.T, g *game.GameState, fen string) {
+	t.Helper()
+	if !g.UndoLastMove() {
+		t.Fatal("nothing to undo")
+	}
+	if g.FEN() != fen || g.GameStatus != game.InProgress {
+		t.Errorf("after undo: %s %v, want %s in progress", g.FEN(), g.GameStatus, fen)
+	}
+}
+
+func TestKingOfTheHill(t *testing.T) {
+	const fen = "k7/8/8/8/8/4K3/8/8 w - - 0 1"
+	g := variantGame(t, game.KingOfTheHill{}, fen)
+
+	// The engine walks onto the hill to win
+	result := ai.SearchWithLimits(g, ai.SearchLimits{Depth: 2})
+	if !game.IsOnHill(result.Move.To) {
+		t.Errorf("engine played %s instead of reaching the hill", g.UCI(result.Move.From, result.Move.To, result.Move.Promotion))
+	}
+
+	playUCI(t, g, "e3e4")
+	if g.GameStatus != game.WhiteWon || !g.IsGameOver() {
+		t.Errorf("king on e4: status %v", g.GameStatus)
+	}
+	undoTo(t, g, fen)
+}
+
+func TestKingOfTheHillFromFEN(t *testing.T) {
+	// The white king already stands on the hill, so White has won
+	const fen = "k7/8/8/8/4K3/8/8/8 b - - 0 1"
+	g, err := game.NewGameFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.GameStatus != game.InProgress {
+		t.Errorf("under the standard rules: status %v", g.GameStatus)
+	}
+	g.SetVariant(game.KingOfTheHill{})
+	if g.GameStatus != game.WhiteWon || g.Termination != "king of the hill" {
+		t.Errorf("king on e4: status %v, %q", g.GameStatus, g.Termination)
+	}
+	g.SetVariant(nil)
+	if g.GameStatus != game.InProgress || g.Termination != "" {
+		t.Errorf("back to the standard rules: status %v, %q", g.GameStatus, g.Termination)
+	}
+
+	pgns, err := game.ParsePGN(strings.NewReader("[Variant \"King of the Hill\"]\n[FEN \"" + fen + "\"]\n\n1-0\n"))
+	if err != nil || len(pgns) != 1 {
+		t.Fatalf("%d games: %v", len(pgns), err)
+	}
+	if g, err = pgns[0].Game(); err != nil || g.GameStatus != game.WhiteWon {
+		t.Errorf("from PGN: %v", err)
+	}
+}
+
+func TestThreeCheck(t *testing.T) {
+	g := variantGame(t, game.ThreeCheck{}, "4k3/8/8/8/8/8/8/R3K3 w - - 0 1")
+
+	playUCI(t, g, "a1a8", "e8e7", "a8a7", "e7e6")
+	before := g.FEN()
+	if g.ChecksGiven(game.WhitePlayer) != 2 || g.GameStatus != game.InProgress {
+		t.Fatalf("after two checks: %d checks, status %v", g.ChecksGiven(game.WhitePlayer), g.GameStatus)
+	}
+
+	playUCI(t, g, "a7a6")
+	if g.ChecksGiven(game.WhitePlayer) != 3 || g.GameStatus != game.WhiteWon {
+		t.Errorf("after the third check: %d checks, status %v", g.ChecksGiven(game.WhitePlayer), g.GameStatus)
+	}
+	undoTo(t, g, before)
+	if g.ChecksGiven(game.WhitePlayer) != 2 {
+		t.Errorf("undo left %d checks", g.ChecksGiven(game.WhitePlayer))
+	}
+}
+
+func TestAtomic(t *testing.T) {
+	const fen = "4kn2/3pp3/8/8/8/8/8/4R1K1 w - - 0 1"
+	g := variantGame(t, game.Atomic{}, fen)
+
+	// The capture explodes the rook, the e7 pawn, the king and the knight;
+	// the d7 pawn survives
+	playUCI(t, g, "e1e7")
+	if key := g.PositionKey(); key != "8/3p4/8/8/8/8/8/6K1 b - -" {
+		t.Errorf("after the explosion: %s", key)
+	}
+	if g.GameStatus != game.WhiteWon {
+		t.Errorf("exploding the king: status %v", g.GameStatus)
+	}
+	undoTo(t, g, fen)
+
+	// Kings never capture
+	g = variantGame(t, game.Atomic{}, "4k3/8/8/8/8/8/4p3/4K3 w - - 0 1")
+	from, to, _, _ := g.ParseUCI("e1e2")
+	if isLegal(g, from, to) {
+		t.Error("the king captured")
+	}
+}
+
+func TestAntichess(t *testing.T) {
+	// Captures are compulsory
+	g := variantGame(t, game.Antichess{}, "rnbqkbnr/p1pppppp/8/1p6/8/4P3/PPPP1PPP/RNBQKBNR w - - 0 2")
+	for _, move := range []string{"g1f3", "e3e4", "f1c4"} {
+		from, to, _, _ := g.ParseUCI(move)
+		if isLegal(g, from, to) {
+			t.Errorf("%s is legal while a capture is available", move)
+		}
+	}
+	playUCI(t, g, "f1b5")
+
+	// Losing every piece wins
+	const fen = "8/8/8/8/8/8/8/r2R4 b - - 0 1"
+	g = variantGame(t, game.Antichess{}, fen)
+	playUCI(t, g, "a1d1")
+	if g.GameStatus != game.WhiteWon {
+		t.Errorf("White lost every piece: status %v", g.GameStatus)
+	}
+	undoTo(t, g, fen)
+}